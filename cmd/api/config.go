@@ -0,0 +1,62 @@
+// cmd/api/config.go
+package main
+
+import (
+	"BoltQ/internal/api"
+	"BoltQ/internal/queue"
+	"BoltQ/pkg/config"
+)
+
+// parsedConfig holds the API's settings that are parsed by the packages that own
+// them
+type parsedConfig struct {
+	serializer       queue.Serializer
+	overflowPolicy   queue.OverflowPolicy
+	retryPolicy      queue.RetryPolicy
+	apiKeys          map[string]string
+	submitRateLimit  queue.RateLimit
+	clientRateLimits map[string]queue.RateLimit
+	corsOrigins      []string
+}
+
+// loadConfig reads and validates the API's configuration
+func loadConfig() (*config.Config, *parsedConfig, error) {
+	cfg, err := config.Load("api")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var problems config.Problems
+	s := &parsedConfig{
+		apiKeys: api.ParseAPIKeys(cfg.APIKeys),
+		retryPolicy: queue.RetryPolicy{
+			BaseDelay:  cfg.RetryBaseDelay,
+			Multiplier: cfg.RetryMultiplier,
+			MinDelay:   cfg.RetryMinDelay,
+			MaxDelay:   cfg.RetryMaxDelay,
+		},
+	}
+	if s.serializer, err = queue.SerializerByName(cfg.TaskSerializer); err != nil {
+		problems.Add("TASK_SERIALIZER", err)
+	}
+	if s.overflowPolicy, err = queue.ParseOverflowPolicy(cfg.QueueOverflowPolicy); err != nil {
+		problems.Add("QUEUE_OVERFLOW_POLICY", err)
+	}
+	if err := s.retryPolicy.Validate(); err != nil {
+		problems.Add("RETRY_*", err)
+	}
+	if s.submitRateLimit, err = queue.ParseRateLimit(cfg.SubmitRateLimit); err != nil {
+		problems.Add("SUBMIT_RATE_LIMIT", err)
+	}
+	if s.clientRateLimits, err = api.ParseClientRateLimits(cfg.ClientRateLimits); err != nil {
+		problems.Add("SUBMIT_RATE_LIMITS", err)
+	}
+	if s.corsOrigins, err = api.ParseCORSOrigins(cfg.CORSOrigins); err != nil {
+		problems.Add("CORS_ALLOWED_ORIGINS", err)
+	}
+
+	if err := problems.Err(); err != nil {
+		return nil, nil, err
+	}
+	return cfg, s, nil
+}
@@ -7,17 +7,18 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
 	"BoltQ/internal/api"
 	"BoltQ/internal/job"
 	"BoltQ/internal/queue"
-	"BoltQ/pkg/config"
+	"BoltQ/internal/worker"
 	"BoltQ/pkg/logger"
 	"BoltQ/pkg/metrics"
+	"BoltQ/pkg/tracing"
 
-	"github.com/go-redis/redis/v8"
 	"github.com/gorilla/mux"
 	"github.com/joho/godotenv"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -34,15 +35,25 @@ func main() {
 		log.Error("No .env file found or couldn't load it")
 	}
 
-	// Load configuration
-	apiPort := config.GetEnv("API_PORT", "8080")
-	metricsPort := config.GetEnv("METRICS_PORT", "9093")
-	redisAddr := config.GetEnv("REDIS_ADDR", "localhost:6379")
+	// Load and validate configuration
+	cfg, parsed, err := loadConfig()
+	if err != nil {
+		log.Error(err.Error())
+		os.Exit(1)
+	}
+
+	// Sample high-volume info logs; errors are always logged
+	log = log.WithSampling(cfg.LogSampleRate, cfg.LogSampleWindow)
 
-	// Initialize Redis client
-	redisClient := redis.NewClient(&redis.Options{
-		Addr: redisAddr,
-	})
+	// Tag logs and metrics with the instance name so replicas can be told apart
+	log = log.WithInstance(cfg.InstanceName)
+
+	// Initialize Redis client (single server, Sentinel or Cluster)
+	redisClient, err := queue.NewRedisClient(cfg.Redis)
+	if err != nil {
+		log.Error(fmt.Sprintf("Invalid Redis configuration: %v", err))
+		os.Exit(1)
+	}
 
 	// Ping Redis to make sure it's available
 	ctx := context.Background()
@@ -50,26 +61,126 @@ func main() {
 		log.Error(fmt.Sprintf("Failed to connect to Redis: %v", err))
 		os.Exit(1)
 	}
-	log.Info(fmt.Sprintf("Connected to Redis at %s", redisAddr))
+	log.Info(fmt.Sprintf("Connected to Redis at %s", queue.RedisAddrDescription(cfg.Redis)))
+
+	// Optionally export traces to an OpenTelemetry collector
+	var shutdownTracer tracing.ShutdownFunc
+	if cfg.TracingEnabled {
+		tracerCtx, cancelTracer := context.WithTimeout(context.Background(), 5*time.Second)
+		shutdownTracer, err = tracing.InitTracer(tracerCtx, "boltq-api")
+		cancelTracer()
+		if err != nil {
+			log.Error(fmt.Sprintf("Failed to initialize tracing, continuing without it: %v", err))
+		} else {
+			log.Info("Tracing enabled")
+		}
+	}
 
 	// Initialize metrics collector
 	metricsCollector := metrics.NewMetricsCollector("api")
+	metricsCollector.SetInstanceInfo(cfg.InstanceName, "api")
 
 	// Initialize queue
 	redisQueue := queue.NewRedisQueue(redisClient, log)
+	redisQueue.SetMetrics(metricsCollector)
+	redisQueue.EnableAuditLog(cfg.AuditLogEnabled)
+	redisQueue.SetMaxPayloadSize(cfg.MaxPayloadSize)
+	redisQueue.SetTaskTTL(cfg.TaskTTL, cfg.TerminalTaskTTL)
+
+	// Choose the format new tasks are stored in; every format is still read
+	redisQueue.SetSerializer(parsed.serializer)
+
+	// Optionally bound each priority queue
+	if cfg.MaxQueueLength > 0 {
+		redisQueue.SetMaxQueueLength(cfg.MaxQueueLength, parsed.overflowPolicy)
+	}
+
+	// Consume the newest task first from the listed queues
+	for _, queueName := range cfg.LIFOQueues {
+		redisQueue.SetQueueOrder(queueName, queue.OrderLIFO)
+	}
+
+	// Move the queues and task records of versions without hash-tagged keys
+	if migrated, err := redisQueue.MigrateUntaggedKeys(); err != nil {
+		log.Error(fmt.Sprintf("Error migrating untagged keys: %v", err))
+	} else if migrated > 0 {
+		log.Info(fmt.Sprintf("Migrated %d tasks and task records to hash-tagged keys", migrated))
+	}
 
 	// Initialize workflow manager
 	workflowManager := job.NewWorkflowManager(redisClient, log)
+	workflowManager.EnableArchive(cfg.WorkflowArchiveRetention, cfg.WorkflowArchiveMax)
 
 	// Initialize WebSocket manager
 	websocketManager := api.NewWebSocketManager(redisClient, log)
+	websocketManager.SetBatchWindow(cfg.WebSocketBatchWindow)
+	websocketManager.SetAllowedOrigins(parsed.corsOrigins)
 	websocketManager.Start()
 
 	// Initialize API handler
 	apiHandler := api.NewHandler(redisQueue, log, metricsCollector, workflowManager)
+	apiHandler.SetJobUpdates(websocketManager)
+
+	// Require an API key on /api/v1 routes when keys are configured
+	if len(parsed.apiKeys) > 0 {
+		apiHandler.SetAPIKeys(parsed.apiKeys)
+		log.Info(fmt.Sprintf("API key authentication enabled with %d keys", len(parsed.apiKeys)))
+	} else {
+		log.Info("API key authentication disabled, set API_KEYS to enable it")
+	}
+
+	// Optionally reject submissions of job types no worker can process
+	if len(cfg.AllowedJobTypes) > 0 {
+		apiHandler.SetAllowedJobTypes(cfg.AllowedJobTypes...)
+		log.Info(fmt.Sprintf("Accepting job types: %s", strings.Join(cfg.AllowedJobTypes, ", ")))
+	}
+	if cfg.RequireRegisteredJobTypes {
+		apiHandler.RequireRegisteredJobTypes(true)
+		log.Info("Accepting only job types registered by a running worker")
+	}
+
+	// Optionally start out serving reads only, e.g. during a migration
+	if cfg.ReadOnly {
+		apiHandler.SetReadOnly(true)
+		log.Info("API starts in read-only mode unless switched off at runtime")
+	}
+
+	// Optionally limit how fast each client may submit jobs
+	if !parsed.submitRateLimit.Unlimited() || len(parsed.clientRateLimits) > 0 {
+		apiHandler.SetSubmitRateLimits(parsed.submitRateLimit, parsed.clientRateLimits)
+		log.Info(fmt.Sprintf("Submission rate limit per client is %s, with %d client-specific limits",
+			parsed.submitRateLimit, len(parsed.clientRateLimits)))
+	}
+
+	// Optionally let consumers that can't use Redis lease jobs over HTTP
+	if cfg.JobLeaseTimeout > 0 {
+		redisQueue.SetRetryPolicy(parsed.retryPolicy)
+		errorHandler := worker.NewErrorHandler(redisQueue, log, metricsCollector)
+		errorHandler.SetMaxRetryDuration(cfg.MaxRetryDuration)
+		if len(cfg.DeadLetterRedactKeys) > 0 {
+			errorHandler.SetRedactedKeys(cfg.DeadLetterRedactKeys)
+		}
+		apiHandler.EnableHTTPConsumers(cfg.JobLeaseTimeout, errorHandler,
+			worker.NewCallbackNotifier(cfg.CallbackSecret, cfg.CallbackMaxAttempts, log))
+		log.Info(fmt.Sprintf("HTTP consumers enabled with a job lease of %s", cfg.JobLeaseTimeout))
+	}
+
+	// Optionally buffer submissions on disk while Redis is unreachable
+	var submitBuffer *queue.SubmitBuffer
+	if cfg.SubmitBufferDir != "" {
+		submitBuffer, err = queue.NewSubmitBuffer(cfg.SubmitBufferDir, cfg.SubmitBufferMaxTasks, redisQueue, log)
+		if err != nil {
+			log.Error(fmt.Sprintf("Failed to create submit buffer: %v", err))
+			os.Exit(1)
+		}
+		apiHandler.SetSubmitBuffer(submitBuffer)
+		submitBuffer.Start(cfg.SubmitBufferFlushInterval)
+		log.Info(fmt.Sprintf("Submit buffer enabled in %s", cfg.SubmitBufferDir))
+	}
 
 	// Create router
 	router := mux.NewRouter()
+	router.Use(api.RecoveryMiddleware(log, metricsCollector))
 
 	// Register API routes
 	apiHandler.RegisterRoutes(router)
@@ -77,17 +188,26 @@ func main() {
 	// Register WebSocket route
 	router.HandleFunc("/ws/jobs", websocketManager.HandleJobUpdatesWebSocket)
 
+	// Allowed CORS origins
+	corsOrigins := parsed.corsOrigins
+	log.Info(fmt.Sprintf("CORS allowed origins: %s", strings.Join(corsOrigins, ", ")))
+	for _, origin := range corsOrigins {
+		if origin == "*" {
+			log.Info("CORS allows any origin; restrict CORS_ALLOWED_ORIGINS outside development")
+		}
+	}
+
 	// 🆕 CORS middleware wrapping the router
 	corsHandler := cors.New(cors.Options{
-		AllowedOrigins:   []string{"http://localhost:5173"},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowedOrigins:   corsOrigins,
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
 		AllowedHeaders:   []string{"*"},
 		AllowCredentials: true,
 	}).Handler(router)
 
 	// API server with CORS-enabled handler
 	apiServer := &http.Server{
-		Addr:         ":" + apiPort,
+		Addr:         ":" + cfg.APIPort,
 		Handler:      corsHandler,
 		ReadTimeout:  15 * time.Second,
 		WriteTimeout: 15 * time.Second,
@@ -96,16 +216,17 @@ func main() {
 
 	// Metrics server
 	metricsRouter := mux.NewRouter()
+	metricsRouter.Use(api.RecoveryMiddleware(log, metricsCollector))
 	metricsRouter.Handle("/metrics", promhttp.Handler())
 
 	metricsServer := &http.Server{
-		Addr:    ":" + metricsPort,
+		Addr:    ":" + cfg.MetricsPort,
 		Handler: metricsRouter,
 	}
 
 	// Start API server
 	go func() {
-		log.Info(fmt.Sprintf("API server listening on port %s", apiPort))
+		log.Info(fmt.Sprintf("API server listening on port %s", cfg.APIPort))
 		if err := apiServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			log.Error(fmt.Sprintf("Error starting API server: %v", err))
 			os.Exit(1)
@@ -114,7 +235,7 @@ func main() {
 
 	// Start metrics server
 	go func() {
-		log.Info(fmt.Sprintf("Metrics server listening on port %s", metricsPort))
+		log.Info(fmt.Sprintf("Metrics server listening on port %s", cfg.MetricsPort))
 		if err := metricsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			log.Error(fmt.Sprintf("Error starting metrics server: %v", err))
 		}
@@ -127,18 +248,36 @@ func main() {
 	<-quit
 	log.Info("Shutting down servers...")
 
-	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	metricsShutdownDelay := cfg.MetricsShutdownDelay
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second+metricsShutdownDelay)
 	defer cancel()
 
 	if err := apiServer.Shutdown(shutdownCtx); err != nil {
 		log.Error(fmt.Sprintf("API server shutdown error: %v", err))
 	}
 
+	websocketManager.Stop()
+
+	if submitBuffer != nil {
+		submitBuffer.Stop()
+	}
+
+	// Flush buffered spans before exiting
+	if shutdownTracer != nil {
+		if err := shutdownTracer(shutdownCtx); err != nil {
+			log.Error(fmt.Sprintf("Tracer shutdown error: %v", err))
+		}
+	}
+
+	// Keep the metrics endpoint up long enough for a final scrape
+	if metricsShutdownDelay > 0 {
+		log.Info(fmt.Sprintf("Waiting %s for a final metrics scrape", metricsShutdownDelay))
+		time.Sleep(metricsShutdownDelay)
+	}
+
 	if err := metricsServer.Shutdown(shutdownCtx); err != nil {
 		log.Error(fmt.Sprintf("Metrics server shutdown error: %v", err))
 	}
 
-	websocketManager.Stop()
-
 	log.Info("Servers stopped")
 }
@@ -0,0 +1,463 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"BoltQ/internal/job"
+	"BoltQ/internal/queue"
+	"BoltQ/pkg/config"
+	"BoltQ/pkg/logger"
+
+	"github.com/joho/godotenv"
+)
+
+// command is a boltqctl subcommand
+type command struct {
+	args    string
+	summary string
+	run     func(ctl *controller, args []string) error
+}
+
+// commands maps each command group and subcommand to its implementation.
+// Commands without subcommands are registered under "".
+var commands = map[string]map[string]command{
+	"stats": {
+		"": {"", "Show task counts per queue", (*controller).stats},
+	},
+	"queue": {
+		"peek":    {"<priority>", "Show the next task of a priority queue", (*controller).queuePeek},
+		"delayed": {"[-limit n]", "List the delayed tasks due next", (*controller).queueDelayed},
+		"drain":   {"-yes <from> <to>", "Move every task of one queue to another", (*controller).queueDrain},
+	},
+	"dlq": {
+		"list":    {"[-limit n]", "List dead-lettered tasks, most recent first", (*controller).dlqList},
+		"show":    {"<task-id>", "Show a dead-lettered task with its attempt history", (*controller).dlqShow},
+		"requeue": {"<task-id>...", "Move dead-lettered tasks back to their queue", (*controller).dlqRequeue},
+		"purge":   {"-yes", "Remove every dead-lettered task", (*controller).dlqPurge},
+	},
+	"job": {
+		"status": {"<job-id>", "Show a job", (*controller).jobStatus},
+		"cancel": {"<job-id>...", "Cancel jobs that have not finished", (*controller).jobCancel},
+	},
+	"workflow": {
+		"list": {"[-limit n]", "List workflows", (*controller).workflowList},
+		"show": {"<workflow-id>", "Show the progress of a workflow", (*controller).workflowShow},
+	},
+}
+
+// errUsage reports a command line that doesn't match the command's arguments
+var errUsage = errors.New("invalid arguments")
+
+// controller runs commands against the queue and workflows in Redis
+type controller struct {
+	queue     *queue.RedisQueue
+	workflows *job.WorkflowManager
+}
+
+// quietLogger keeps the queue's informational logs out of the command output
+type quietLogger struct{}
+
+func (quietLogger) Info(string, ...map[string]interface{})  {}
+func (quietLogger) Debug(string, ...map[string]interface{}) {}
+func (quietLogger) Error(msg string, _ ...map[string]interface{}) {
+	fmt.Fprintln(os.Stderr, "error:", msg)
+}
+
+func main() {
+	if len(os.Args) < 2 || os.Args[1] == "help" || os.Args[1] == "-h" || os.Args[1] == "--help" {
+		printUsage()
+		os.Exit(0)
+	}
+
+	group, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", os.Args[1])
+		printUsage()
+		os.Exit(2)
+	}
+
+	name, args := "", os.Args[2:]
+	if _, single := group[""]; !single {
+		if len(args) == 0 {
+			printUsage()
+			os.Exit(2)
+		}
+		name, args = args[0], args[1:]
+	}
+	cmd, ok := group[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", strings.TrimSpace(os.Args[1]+" "+name))
+		printUsage()
+		os.Exit(2)
+	}
+
+	// Read the Redis and task storage settings from the same environment as the services
+	_ = godotenv.Load()
+	cfg, err := config.Load("boltqctl")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	ctl, err := newController(cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	defer ctl.queue.Close()
+
+	if err := cmd.run(ctl, args); err != nil {
+		if errors.Is(err, errUsage) {
+			fmt.Fprintf(os.Stderr, "usage: boltqctl %s %s\n", strings.TrimSpace(os.Args[1]+" "+name), cmd.args)
+			os.Exit(2)
+		}
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+}
+
+// printUsage lists every command
+func printUsage() {
+	fmt.Fprintln(os.Stderr, "Usage: boltqctl <command> [arguments]")
+	fmt.Fprintln(os.Stderr, "\nRedis is configured with the same environment variables and .env file as the services.")
+	fmt.Fprintln(os.Stderr, "\nCommands:")
+
+	groups := make([]string, 0, len(commands))
+	for group := range commands {
+		groups = append(groups, group)
+	}
+	sort.Strings(groups)
+
+	w := tabwriter.NewWriter(os.Stderr, 0, 0, 2, ' ', 0)
+	for _, group := range groups {
+		names := make([]string, 0, len(commands[group]))
+		for name := range commands[group] {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			cmd := commands[group][name]
+			fmt.Fprintf(w, "  %s\t%s\n", strings.TrimSpace(strings.Join([]string{group, name, cmd.args}, " ")), cmd.summary)
+		}
+	}
+	w.Flush()
+}
+
+// newController connects to Redis with the queue settings of the services
+func newController(cfg *config.Config) (*controller, error) {
+	serializer, err := queue.SerializerByName(cfg.TaskSerializer)
+	if err != nil {
+		return nil, fmt.Errorf("invalid TASK_SERIALIZER: %v", err)
+	}
+	overflowPolicy, err := queue.ParseOverflowPolicy(cfg.QueueOverflowPolicy)
+	if err != nil {
+		return nil, fmt.Errorf("invalid QUEUE_OVERFLOW_POLICY: %v", err)
+	}
+
+	redisClient, err := queue.NewRedisClient(cfg.Redis)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Redis configuration: %v", err)
+	}
+	if err := redisClient.Ping(context.Background()).Err(); err != nil {
+		return nil, fmt.Errorf("failed to connect to Redis at %s: %v", queue.RedisAddrDescription(cfg.Redis), err)
+	}
+
+	redisQueue := queue.NewRedisQueue(redisClient, quietLogger{})
+	redisQueue.SetSerializer(serializer)
+	redisQueue.SetMaxPayloadSize(cfg.MaxPayloadSize)
+	redisQueue.SetTaskTTL(cfg.TaskTTL, cfg.TerminalTaskTTL)
+	if cfg.MaxQueueLength > 0 {
+		redisQueue.SetMaxQueueLength(cfg.MaxQueueLength, overflowPolicy)
+	}
+	for _, queueName := range cfg.LIFOQueues {
+		redisQueue.SetQueueOrder(queueName, queue.OrderLIFO)
+	}
+
+	return &controller{
+		queue:     redisQueue,
+		workflows: job.NewWorkflowManager(redisClient, logger.NewLogger("boltqctl")),
+	}, nil
+}
+
+func (ctl *controller) stats(args []string) error {
+	if len(args) != 0 {
+		return errUsage
+	}
+
+	summary, err := ctl.queue.GetQueueSummary()
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, name := range []string{"critical", "high", "normal", "low"} {
+		fmt.Fprintf(w, "%s\t%d\n", name, summary.ByPriority[name])
+	}
+	fmt.Fprintf(w, "delayed\t%d\n", summary.Delayed)
+	fmt.Fprintf(w, "dead letter\t%d\n", summary.DeadLetter)
+	fmt.Fprintf(w, "total\t%d\n", summary.Total)
+	fmt.Fprintf(w, "suppressed duplicates\t%d\n", summary.SuppressedDuplicates)
+	return w.Flush()
+}
+
+func (ctl *controller) queuePeek(args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+
+	priority, ok := queue.PriorityFromName(args[0])
+	if !ok {
+		var err error
+		priority, err = strconv.Atoi(args[0])
+		if err != nil || !queue.IsValidPriority(priority) {
+			return fmt.Errorf("priority must be %d-%d or one of low, normal, high, critical",
+				queue.MinPriority, queue.MaxPriority)
+		}
+	}
+
+	task, err := ctl.queue.PeekNext(priority)
+	if err != nil {
+		return err
+	}
+	if task == nil {
+		fmt.Println("Queue is empty")
+		return nil
+	}
+	return printJSON(task)
+}
+
+func (ctl *controller) queueDelayed(args []string) error {
+	limit, err := parseLimit("queue delayed", args)
+	if err != nil {
+		return err
+	}
+
+	tasks, err := ctl.queue.PeekDelayed(limit)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tTYPE\tPRIORITY\tATTEMPTS\tDUE")
+	for _, task := range tasks {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%s\n", task.ID, task.Type, task.Priority, task.Attempts,
+			task.ScheduledAt.Format("2006-01-02 15:04:05"))
+	}
+	return w.Flush()
+}
+
+func (ctl *controller) queueDrain(args []string) error {
+	flags := flag.NewFlagSet("queue drain", flag.ContinueOnError)
+	yes := flags.Bool("yes", false, "confirm moving every task of the queue")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 2 {
+		return errUsage
+	}
+
+	from, err := queue.ParseQueueName(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	to, err := queue.ParseQueueName(flags.Arg(1))
+	if err != nil {
+		return err
+	}
+	if !*yes {
+		return fmt.Errorf("this moves every task of %s to %s, pass -yes to confirm", from, to)
+	}
+
+	count, err := ctl.queue.DrainQueue(from, to)
+	if err != nil {
+		return fmt.Errorf("moved %d tasks before failing: %w", count, err)
+	}
+	fmt.Printf("Moved %d tasks from %s to %s\n", count, from, to)
+	return nil
+}
+
+func (ctl *controller) dlqList(args []string) error {
+	limit, err := parseLimit("dlq list", args)
+	if err != nil {
+		return err
+	}
+
+	tasks, err := ctl.queue.DeadLetters(limit)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tTYPE\tPRIORITY\tATTEMPTS\tCATEGORY\tLAST ERROR")
+	for _, task := range tasks {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%s\t%s\n", task.ID, task.Type, task.Priority, task.Attempts,
+			task.ErrorCategory, truncate(task.LastError, 60))
+	}
+	return w.Flush()
+}
+
+func (ctl *controller) dlqShow(args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+
+	task, err := ctl.queue.DeadLetter(args[0])
+	if err != nil {
+		return err
+	}
+	return printJSON(task)
+}
+
+func (ctl *controller) dlqRequeue(args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+
+	failed := 0
+	for _, taskID := range args {
+		task, err := ctl.queue.RequeueDeadLetter(taskID)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", taskID, err)
+			failed++
+			continue
+		}
+		fmt.Printf("Requeued %s to %s\n", task.ID, queue.PriorityName(task.Priority))
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d tasks could not be requeued", failed, len(args))
+	}
+	return nil
+}
+
+func (ctl *controller) dlqPurge(args []string) error {
+	flags := flag.NewFlagSet("dlq purge", flag.ContinueOnError)
+	yes := flags.Bool("yes", false, "confirm removing every dead-lettered task")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 0 {
+		return errUsage
+	}
+	if !*yes {
+		return fmt.Errorf("this removes every dead-lettered task, pass -yes to confirm")
+	}
+
+	count, err := ctl.queue.PurgeDeadLetterQueue()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Removed %d dead-lettered tasks\n", count)
+	return nil
+}
+
+func (ctl *controller) jobStatus(args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+
+	task, err := ctl.queue.GetTaskStatus(args[0])
+	if err != nil {
+		return err
+	}
+	return printJSON(task)
+}
+
+func (ctl *controller) jobCancel(args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+
+	failed := 0
+	for _, jobID := range args {
+		task, err := ctl.queue.GetTaskStatus(jobID)
+		if err == nil && (task.Status == "failed" || queue.IsFinalStatus(task.Status)) {
+			err = fmt.Errorf("job is already %s", task.Status)
+		}
+		if err == nil {
+			err = ctl.queue.CancelTask(jobID)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", jobID, err)
+			failed++
+			continue
+		}
+		fmt.Printf("Cancelled %s\n", jobID)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d jobs could not be cancelled", failed, len(args))
+	}
+	return nil
+}
+
+func (ctl *controller) workflowList(args []string) error {
+	limit, err := parseLimit("workflow list", args)
+	if err != nil {
+		return err
+	}
+
+	workflows, err := ctl.workflows.ListWorkflows(limit, 0)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAME\tSTATUS\tSTEPS")
+	for _, workflow := range workflows {
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\n", workflow["id"], workflow["name"], workflow["status"], workflow["step_count"])
+	}
+	return w.Flush()
+}
+
+func (ctl *controller) workflowShow(args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+
+	progress, err := ctl.workflows.GetWorkflowProgress(args[0])
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Workflow %s is %s\n\n", progress.WorkflowID, progress.Status)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "STEP\tJOB TYPE\tSTATUS\tMESSAGE")
+	for _, step := range progress.Steps {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", step.ID, step.JobType, step.Status, truncate(step.ErrorMessage, 60))
+	}
+	return w.Flush()
+}
+
+// parseLimit parses the -limit flag of a listing command, defaulting to 20
+func parseLimit(name string, args []string) (int, error) {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	limit := flags.Int("limit", 20, "number of entries to list")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 0 || *limit <= 0 {
+		return 0, errUsage
+	}
+	return *limit, nil
+}
+
+// printJSON prints a value as indented JSON
+func printJSON(v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
+// truncate shortens a message to at most n characters for table output
+func truncate(message string, n int) string {
+	message = strings.ReplaceAll(message, "\n", " ")
+	if len(message) <= n {
+		return message
+	}
+	return message[:n-3] + "..."
+}
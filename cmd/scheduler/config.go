@@ -0,0 +1,42 @@
+// cmd/scheduler/config.go
+package main
+
+import (
+	"fmt"
+
+	"BoltQ/internal/queue"
+	"BoltQ/pkg/config"
+)
+
+// parsedConfig holds the scheduler's settings that are parsed by the packages that
+// own them
+type parsedConfig struct {
+	serializer     queue.Serializer
+	overflowPolicy queue.OverflowPolicy
+}
+
+// loadConfig reads and validates the scheduler's configuration
+func loadConfig() (*config.Config, *parsedConfig, error) {
+	cfg, err := config.Load("scheduler")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var problems config.Problems
+	s := &parsedConfig{}
+	if s.serializer, err = queue.SerializerByName(cfg.TaskSerializer); err != nil {
+		problems.Add("TASK_SERIALIZER", err)
+	}
+	if s.overflowPolicy, err = queue.ParseOverflowPolicy(cfg.QueueOverflowPolicy); err != nil {
+		problems.Add("QUEUE_OVERFLOW_POLICY", err)
+	}
+	if !queue.IsValidPriority(cfg.TaskAgingTargetPriority) {
+		problems.Add("TASK_AGING_TARGET_PRIORITY",
+			fmt.Errorf("must be between %d and %d", queue.MinPriority, queue.MaxPriority))
+	}
+
+	if err := problems.Err(); err != nil {
+		return nil, nil, err
+	}
+	return cfg, s, nil
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"BoltQ/internal/api"
+	"BoltQ/internal/queue"
+	"BoltQ/internal/worker"
+	"BoltQ/pkg/logger"
+	"BoltQ/pkg/metrics"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/gorilla/mux"
+	"github.com/joho/godotenv"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+func main() {
+	// Initialize logger
+	log := logger.NewLogger("scheduler")
+	log.Info("Starting BoltQ Scheduler Service...")
+
+	if err := godotenv.Load(); err != nil {
+		log.Error("No .env file found or couldn't load it")
+	}
+
+	// Load and validate configuration
+	cfg, parsed, err := loadConfig()
+	if err != nil {
+		log.Error(err.Error())
+		os.Exit(1)
+	}
+
+	// Sample high-volume info logs; errors are always logged
+	log = log.WithSampling(cfg.LogSampleRate, cfg.LogSampleWindow)
+
+	// Tag logs and metrics with the instance name so replicas can be told apart
+	log = log.WithInstance(cfg.InstanceName)
+
+	// Initialize Redis client (single server, Sentinel or Cluster)
+	redisClient, err := queue.NewRedisClient(cfg.Redis)
+	if err != nil {
+		log.Error(fmt.Sprintf("Invalid Redis configuration: %v", err))
+		os.Exit(1)
+	}
+
+	// Ping Redis to make sure it's available
+	ctx := context.Background()
+	if err := redisClient.Ping(ctx).Err(); err != nil {
+		log.Error(fmt.Sprintf("Failed to connect to Redis: %v", err))
+		os.Exit(1)
+	}
+	log.Info(fmt.Sprintf("Connected to Redis at %s", queue.RedisAddrDescription(cfg.Redis)))
+
+	// Initialize metrics collector
+	metricsCollector := metrics.NewMetricsCollector("scheduler")
+	metricsCollector.SetInstanceInfo(cfg.InstanceName, "scheduler")
+
+	// Initialize queue
+	redisQueue := queue.NewRedisQueue(redisClient, log)
+	redisQueue.SetMetrics(metricsCollector)
+	redisQueue.EnableAuditLog(cfg.AuditLogEnabled)
+	redisQueue.SetMaxPayloadSize(cfg.MaxPayloadSize)
+	redisQueue.SetTaskTTL(cfg.TaskTTL, cfg.TerminalTaskTTL)
+
+	// Choose the format new tasks are stored in; every format is still read
+	redisQueue.SetSerializer(parsed.serializer)
+
+	// Optionally bound each priority queue
+	if cfg.MaxQueueLength > 0 {
+		redisQueue.SetMaxQueueLength(cfg.MaxQueueLength, parsed.overflowPolicy)
+	}
+
+	// Initialize delayed job processor. Schedulers are meant to run as several
+	// replicas, so leader election is on by default.
+	delayedProcessor := worker.NewDelayedJobProcessor(redisQueue, log, metricsCollector)
+	delayedProcessor.SetBatchSize(cfg.DelayedProcessorBatchSize)
+	if cfg.DelayedProcessorLeaderElection {
+		delayedProcessor.EnableLeaderElection(cfg.InstanceName, cfg.DelayedProcessorLeaderTTL)
+	}
+
+	// Initialize queue depth sampler
+	queueSampler := worker.NewQueueDepthSampler(redisQueue, log, metricsCollector)
+
+	// Expire old dead-lettered tasks unless retention is disabled
+	var deadLetterSweeper *worker.DeadLetterSweeper
+	if cfg.DeadLetterRetention > 0 {
+		deadLetterSweeper = worker.NewDeadLetterSweeper(redisQueue, log, cfg.DeadLetterRetention)
+	}
+
+	// Recount task records by status so the status counts don't drift
+	var statusCountReconciler *worker.StatusCountReconciler
+	if cfg.StatusCountsReconcileInterval > 0 {
+		statusCountReconciler = worker.NewStatusCountReconciler(redisQueue, log)
+	}
+
+	// Optionally promote tasks that wait too long in a low priority queue
+	var agingSweeper *worker.TaskAgingSweeper
+	if cfg.TaskAgingThreshold > 0 {
+		agingSweeper = worker.NewTaskAgingSweeper(redisQueue, log, metricsCollector, cfg.TaskAgingThreshold, cfg.TaskAgingTargetPriority)
+	}
+
+	// Metrics server
+	metricsRouter := mux.NewRouter()
+	metricsRouter.Use(api.RecoveryMiddleware(log, metricsCollector))
+	metricsRouter.Handle("/metrics", promhttp.Handler())
+	metricsRouter.HandleFunc("/health", healthCheckHandler(redisClient))
+	metricsRouter.HandleFunc("/livez", livenessHandler)
+	metricsRouter.HandleFunc("/readyz", healthCheckHandler(redisClient))
+	metricsRouter.HandleFunc("/stats", statsHandler(delayedProcessor))
+	metricsRouter.HandleFunc("/metrics/summary", summaryHandler(metricsCollector))
+
+	metricsServer := &http.Server{
+		Addr:    ":" + cfg.MetricsPort,
+		Handler: metricsRouter,
+	}
+
+	// Start delayed job processor
+	delayedProcessor.Start(cfg.DelayedProcessorInterval)
+
+	// Start queue depth sampler
+	queueSampler.Start(cfg.QueueSampleInterval)
+
+	// Start dead letter sweeper
+	if deadLetterSweeper != nil {
+		deadLetterSweeper.Start(cfg.DeadLetterSweepInterval)
+	}
+
+	// Start status count reconciler
+	if statusCountReconciler != nil {
+		statusCountReconciler.Start(cfg.StatusCountsReconcileInterval)
+	}
+
+	// Start task aging sweeper
+	if agingSweeper != nil {
+		agingSweeper.Start(cfg.TaskAgingInterval)
+	}
+
+	// Run metrics server in goroutine
+	go func() {
+		log.Info(fmt.Sprintf("Metrics server listening on port %s", cfg.MetricsPort))
+		if err := metricsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Error(fmt.Sprintf("Error starting metrics server: %v", err))
+		}
+	}()
+
+	// Wait for interrupt signal to gracefully shut down
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+	log.Info("Shutting down...")
+
+	// Stop the delayed job processor, handing over leadership
+	delayedProcessor.Stop()
+
+	// Stop the queue depth sampler
+	queueSampler.Stop()
+
+	// Stop the dead letter sweeper
+	if deadLetterSweeper != nil {
+		deadLetterSweeper.Stop()
+	}
+
+	// Stop the status count reconciler
+	if statusCountReconciler != nil {
+		statusCountReconciler.Stop()
+	}
+
+	// Stop the task aging sweeper
+	if agingSweeper != nil {
+		agingSweeper.Stop()
+	}
+
+	// Create shutdown context with timeout
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Shutdown metrics server
+	if err := metricsServer.Shutdown(shutdownCtx); err != nil {
+		log.Error(fmt.Sprintf("Metrics server shutdown error: %v", err))
+	}
+
+	log.Info("Scheduler service stopped")
+}
+
+// Liveness handler; it answers as long as the process is up
+func livenessHandler(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("OK"))
+}
+
+// Health check handler reporting whether Redis is reachable, also used as the
+// readiness probe
+func healthCheckHandler(redisClient redis.UniversalClient) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := redisClient.Ping(r.Context()).Err(); err != nil {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte("Redis unavailable: " + err.Error()))
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("OK"))
+	}
+}
+
+// Stats handler exposing the scheduler's runtime state
+func statsHandler(delayedProcessor *worker.DelayedJobProcessor) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		stats := map[string]interface{}{
+			"delayed_processor_leader": delayedProcessor.IsLeader(),
+			"delayed_tasks_processed":  delayedProcessor.GetProcessCount(),
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(stats)
+	}
+}
+
+// Summary handler exposing this scheduler's aggregated metrics as JSON
+func summaryHandler(metricsCollector *metrics.MetricsCollector) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(metricsCollector.Summary())
+	}
+}
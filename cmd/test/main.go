@@ -113,7 +113,10 @@ func main() {
 	queueServiceFactory := queue.NewQueueServiceFactory(log)
 	queueServiceFactory.InitDefaultFactories()
 
-	q, err := queueServiceFactory.CreateQueue(queue.QueueTypeRedis, map[string]string{
+	// QUEUE_TYPE=memory runs the test without Redis, QUEUE_TYPE=zset on the
+	// sorted set queue
+	queueType := queue.QueueType(config.GetEnv("QUEUE_TYPE", string(queue.QueueTypeRedis)))
+	q, err := queueServiceFactory.CreateQueue(queueType, map[string]string{
 		"addr": redisAddr,
 	})
 
@@ -264,4 +267,30 @@ func createTestJobs(ctx context.Context, q queue.Queue, log LoggerInterface) {
 			"scheduled_at": time.Now().Add(5 * time.Second),
 		})
 	}
+
+	// Add a delayed job with high priority; it must be consumed once promoted
+	delayedCriticalJob := &queue.Job{
+		ID:        "delayed-critical-job",
+		Type:      "notification",
+		Priority:  queue.PriorityHigh,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		Status:    queue.StatusPending,
+		Payload: map[string]interface{}{
+			"message": "DELAYED HIGH PRIORITY ALERT: This should be processed after 3 seconds",
+		},
+	}
+
+	err = q.PublishDelayed(ctx, delayedCriticalJob, 3*time.Second)
+	if err != nil {
+		log.Error("Failed to publish delayed high priority job", map[string]interface{}{
+			"job_id": delayedCriticalJob.ID,
+			"error":  err.Error(),
+		})
+	} else {
+		log.Info("Published delayed high priority job", map[string]interface{}{
+			"job_id":       delayedCriticalJob.ID,
+			"scheduled_at": time.Now().Add(3 * time.Second),
+		})
+	}
 }
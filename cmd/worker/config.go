@@ -0,0 +1,67 @@
+// cmd/worker/config.go
+package main
+
+import (
+	"fmt"
+
+	"BoltQ/internal/queue"
+	"BoltQ/internal/worker"
+	"BoltQ/pkg/config"
+)
+
+// parsedConfig holds the worker's settings that are parsed by the packages that own
+// them
+type parsedConfig struct {
+	serializer           queue.Serializer
+	overflowPolicy       queue.OverflowPolicy
+	retryPolicy          queue.RetryPolicy
+	consumeStrategy      worker.ConsumeStrategy
+	resultOverflowPolicy worker.ResultOverflowPolicy
+}
+
+// loadConfig reads and validates the worker's configuration
+func loadConfig() (*config.Config, *parsedConfig, error) {
+	cfg, err := config.Load("worker")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var problems config.Problems
+	s := &parsedConfig{
+		retryPolicy: queue.RetryPolicy{
+			BaseDelay:  cfg.RetryBaseDelay,
+			Multiplier: cfg.RetryMultiplier,
+			MinDelay:   cfg.RetryMinDelay,
+			MaxDelay:   cfg.RetryMaxDelay,
+		},
+	}
+	if s.serializer, err = queue.SerializerByName(cfg.TaskSerializer); err != nil {
+		problems.Add("TASK_SERIALIZER", err)
+	}
+	if s.overflowPolicy, err = queue.ParseOverflowPolicy(cfg.QueueOverflowPolicy); err != nil {
+		problems.Add("QUEUE_OVERFLOW_POLICY", err)
+	}
+	if err := s.retryPolicy.Validate(); err != nil {
+		problems.Add("RETRY_*", err)
+	}
+	if s.consumeStrategy, err = worker.ParseConsumeStrategy(cfg.ConsumeStrategy); err != nil {
+		problems.Add("WORKER_CONSUME_STRATEGY", err)
+	}
+	if s.resultOverflowPolicy, err = worker.ParseResultOverflowPolicy(cfg.ResultOverflowPolicy); err != nil {
+		problems.Add("RESULT_OVERFLOW_POLICY", err)
+	}
+	for _, tag := range cfg.WorkerTags {
+		if !queue.ValidTag(tag) {
+			problems.Add("WORKER_TAGS", fmt.Errorf("invalid tag %q", tag))
+		}
+	}
+	if !queue.IsValidPriority(cfg.TaskAgingTargetPriority) {
+		problems.Add("TASK_AGING_TARGET_PRIORITY",
+			fmt.Errorf("must be between %d and %d", queue.MinPriority, queue.MaxPriority))
+	}
+
+	if err := problems.Err(); err != nil {
+		return nil, nil, err
+	}
+	return cfg, s, nil
+}
@@ -1,7 +1,9 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"os"
@@ -14,11 +16,11 @@ import (
 	"BoltQ/internal/job"
 	"BoltQ/internal/queue"
 	"BoltQ/internal/worker"
-	"BoltQ/pkg/config"
 	"BoltQ/pkg/logger"
 	"BoltQ/pkg/metrics"
+	"BoltQ/pkg/payload"
+	"BoltQ/pkg/tracing"
 
-	"github.com/go-redis/redis/v8"
 	"github.com/gorilla/mux"
 	"github.com/joho/godotenv"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -33,22 +35,25 @@ func main() {
 		log.Error("No .env file found or couldn't load it")
 	}
 
-	// Load configuration
-	numWorkersStr := config.GetEnv("NUM_WORKERS", "4")
-	metricsPort := config.GetEnv("METRICS_PORT", "9094")
-	redisAddr := config.GetEnv("REDIS_ADDR", "localhost:6379")
-
-	// Parse number of workers
-	numWorkers, err := strconv.Atoi(numWorkersStr)
+	// Load and validate configuration
+	cfg, parsed, err := loadConfig()
 	if err != nil {
-		log.Error(fmt.Sprintf("Invalid NUM_WORKERS value: %v", err))
-		numWorkers = 4
+		log.Error(err.Error())
+		os.Exit(1)
 	}
 
-	// Initialize Redis client
-	redisClient := redis.NewClient(&redis.Options{
-		Addr: redisAddr,
-	})
+	// Sample high-volume info logs; errors are always logged
+	log = log.WithSampling(cfg.LogSampleRate, cfg.LogSampleWindow)
+
+	// Tag logs and metrics with the instance name so replicas can be told apart
+	log = log.WithInstance(cfg.InstanceName)
+
+	// Initialize Redis client (single server, Sentinel or Cluster)
+	redisClient, err := queue.NewRedisClient(cfg.Redis)
+	if err != nil {
+		log.Error(fmt.Sprintf("Invalid Redis configuration: %v", err))
+		os.Exit(1)
+	}
 
 	// Ping Redis to make sure it's available
 	ctx := context.Background()
@@ -56,22 +61,75 @@ func main() {
 		log.Error(fmt.Sprintf("Failed to connect to Redis: %v", err))
 		os.Exit(1)
 	}
-	log.Info(fmt.Sprintf("Connected to Redis at %s", redisAddr))
+	log.Info(fmt.Sprintf("Connected to Redis at %s", queue.RedisAddrDescription(cfg.Redis)))
+
+	// Optionally export traces to an OpenTelemetry collector
+	var shutdownTracer tracing.ShutdownFunc
+	if cfg.TracingEnabled {
+		tracerCtx, cancelTracer := context.WithTimeout(context.Background(), 5*time.Second)
+		shutdownTracer, err = tracing.InitTracer(tracerCtx, "boltq-worker")
+		cancelTracer()
+		if err != nil {
+			log.Error(fmt.Sprintf("Failed to initialize tracing, continuing without it: %v", err))
+		} else {
+			log.Info("Tracing enabled")
+		}
+	}
 
 	// Initialize metrics collector
 	metricsCollector := metrics.NewMetricsCollector("worker")
+	metricsCollector.SetInstanceInfo(cfg.InstanceName, "worker")
 
 	// Initialize queue
 	redisQueue := queue.NewRedisQueue(redisClient, log)
+	redisQueue.SetMetrics(metricsCollector)
+	redisQueue.EnableAuditLog(cfg.AuditLogEnabled)
+	redisQueue.SetMaxPayloadSize(cfg.MaxPayloadSize)
+	redisQueue.SetTaskTTL(cfg.TaskTTL, cfg.TerminalTaskTTL)
+
+	// Choose the format new tasks are stored in; every format is still read
+	redisQueue.SetSerializer(parsed.serializer)
+
+	// Optionally bound each priority queue
+	if cfg.MaxQueueLength > 0 {
+		redisQueue.SetMaxQueueLength(cfg.MaxQueueLength, parsed.overflowPolicy)
+	}
+
+	// Consume the newest task first from the listed queues
+	for _, queueName := range cfg.LIFOQueues {
+		redisQueue.SetQueueOrder(queueName, queue.OrderLIFO)
+	}
+
+	// Move the queues and task records of versions without hash-tagged keys
+	if migrated, err := redisQueue.MigrateUntaggedKeys(); err != nil {
+		log.Error(fmt.Sprintf("Error migrating untagged keys: %v", err))
+	} else if migrated > 0 {
+		log.Info(fmt.Sprintf("Migrated %d tasks and task records to hash-tagged keys", migrated))
+	}
+
+	// Optionally notify an external endpoint of dead-lettered tasks
+	if cfg.DeadLetterWebhookURL != "" {
+		redisQueue.OnDeadLetter(deadLetterWebhook(cfg.DeadLetterWebhookURL, log))
+	}
 
 	// Initialize workflow manager
 	workflowManager := job.NewWorkflowManager(redisClient, log)
+	workflowManager.EnableArchive(cfg.WorkflowArchiveRetention, cfg.WorkflowArchiveMax)
 
 	// Initialize WebSocket handler for publishing job updates
 	websocketManager := api.NewWebSocketManager(redisClient, log)
 
+	// Back off between retries as configured
+	redisQueue.SetRetryPolicy(parsed.retryPolicy)
+
 	// Initialize error handler
 	errorHandler := worker.NewErrorHandler(redisQueue, log, metricsCollector)
+	errorHandler.SetMaxRetryDuration(cfg.MaxRetryDuration)
+
+	// Hide sensitive payload keys when dead-lettered tasks are logged
+	if len(cfg.DeadLetterRedactKeys) > 0 {
+		errorHandler.SetRedactedKeys(cfg.DeadLetterRedactKeys)
+	}
 
 	// Initialize worker pool
 	workerPool := worker.NewWorkerPool(
@@ -81,35 +139,136 @@ func main() {
 		errorHandler,
 		workflowManager,
 		websocketManager,
-		numWorkers,
-		100*time.Millisecond,
+		cfg.NumWorkers,
+		cfg.PollingInterval,
 	)
+	workerPool.SetInstanceID(cfg.InstanceName)
+	workerPool.SetMaxPollingInterval(cfg.MaxPollingInterval)
+	workerPool.SetBlockingConsume(cfg.BlockingConsumeTimeout)
+	workerPool.SetWorkerConcurrency(cfg.WorkerConcurrency)
+	workerPool.SetMaxInFlight(cfg.MaxInFlight)
+	workerPool.SetDependencyWait(cfg.DependencyPollInterval, cfg.DependencyMaxWait)
+
+	// Optionally restrict this pool to a subset of priority queues
+	if len(cfg.WorkerPriorities) > 0 {
+		workerPool.SetAllowedPriorities(cfg.WorkerPriorities...)
+	}
+
+	// Optionally restrict this pool to tasks routed by specific tags
+	if len(cfg.WorkerTags) > 0 {
+		workerPool.SetRequiredTags(cfg.WorkerTags...)
+	}
+
+	// Share workers between tags by strict priority or in turns
+	workerPool.SetConsumeStrategy(parsed.consumeStrategy)
+
+	// Bound the content processors fetch for payload references
+	payload.SetMaxSize(cfg.PayloadRefMaxSize)
+
+	// Optionally keep oversized results out of Redis
+	if cfg.MaxResultSize > 0 {
+		workerPool.SetMaxResultSize(cfg.MaxResultSize, parsed.resultOverflowPolicy)
+	}
+
+	// Optionally give job types their own processing timeout
+	for jobType, timeout := range cfg.ProcessorTimeouts {
+		workerPool.SetProcessorTimeout(jobType, timeout)
+	}
+
+	// Optionally enable the per-job-type circuit breaker
+	if cfg.CircuitBreakerThreshold > 0 {
+		workerPool.SetCircuitBreaker(worker.NewCircuitBreaker(
+			cfg.CircuitBreakerThreshold,
+			cfg.CircuitBreakerWindow,
+			cfg.CircuitBreakerCooldown,
+		))
+	}
+
+	// Deliver HTTP callbacks for jobs submitted with a callback URL
+	workerPool.SetCallbackNotifier(worker.NewCallbackNotifier(cfg.CallbackSecret, cfg.CallbackMaxAttempts, log))
 
 	// Register job processors
 	registerJobProcessors(workerPool)
 
-	// Initialize delayed job processor
+	// Optionally log and complete tasks of unknown job types instead of dead-lettering them
+	if cfg.DefaultProcessorEnabled {
+		workerPool.SetDefaultProcessor(worker.LoggingDefaultProcessor(log))
+	}
+
+	// Initialize delayed job processor, unless a scheduler service runs it
 	delayedProcessor := worker.NewDelayedJobProcessor(redisQueue, log, metricsCollector)
+	delayedProcessor.SetBatchSize(cfg.DelayedProcessorBatchSize)
+
+	// Optionally let only one worker instance at a time run the delayed processor
+	if cfg.DelayedProcessorLeaderElection {
+		delayedProcessor.EnableLeaderElection(workerPool.InstanceID(), cfg.DelayedProcessorLeaderTTL)
+	}
+
+	// Initialize queue depth sampler
+	queueSampler := worker.NewQueueDepthSampler(redisQueue, log, metricsCollector)
+
+	// Expire old dead-lettered tasks unless retention is disabled
+	var deadLetterSweeper *worker.DeadLetterSweeper
+	if cfg.DeadLetterRetention > 0 {
+		deadLetterSweeper = worker.NewDeadLetterSweeper(redisQueue, log, cfg.DeadLetterRetention)
+	}
+
+	// Recount task records by status so the status counts don't drift
+	var statusCountReconciler *worker.StatusCountReconciler
+	if cfg.StatusCountsReconcileInterval > 0 {
+		statusCountReconciler = worker.NewStatusCountReconciler(redisQueue, log)
+	}
+
+	// Optionally promote tasks that wait too long in a low priority queue
+	var agingSweeper *worker.TaskAgingSweeper
+	if cfg.TaskAgingThreshold > 0 {
+		agingSweeper = worker.NewTaskAgingSweeper(redisQueue, log, metricsCollector, cfg.TaskAgingThreshold, cfg.TaskAgingTargetPriority)
+	}
 
 	// Metrics server
 	metricsRouter := mux.NewRouter()
+	metricsRouter.Use(api.RecoveryMiddleware(log, metricsCollector))
 	metricsRouter.Handle("/metrics", promhttp.Handler())
 	metricsRouter.HandleFunc("/health", healthCheckHandler)
+	metricsRouter.HandleFunc("/livez", healthCheckHandler)
+	metricsRouter.HandleFunc("/readyz", readinessHandler(redisQueue, workerPool))
+	metricsRouter.HandleFunc("/stats", statsHandler(workerPool, delayedProcessor, cfg.DelayedProcessorEnabled))
+	metricsRouter.HandleFunc("/metrics/summary", summaryHandler(metricsCollector))
 
 	metricsServer := &http.Server{
-		Addr:    ":" + metricsPort,
+		Addr:    ":" + cfg.MetricsPort,
 		Handler: metricsRouter,
 	}
 
 	// Start delayed job processor
-	delayedProcessor.Start(5 * time.Second)
+	if cfg.DelayedProcessorEnabled {
+		delayedProcessor.Start(cfg.DelayedProcessorInterval)
+	}
+
+	// Start queue depth sampler
+	queueSampler.Start(cfg.QueueSampleInterval)
+
+	// Start dead letter sweeper
+	if deadLetterSweeper != nil {
+		deadLetterSweeper.Start(cfg.DeadLetterSweepInterval)
+	}
+
+	// Start status count reconciler
+	if statusCountReconciler != nil {
+		statusCountReconciler.Start(cfg.StatusCountsReconcileInterval)
+	}
+
+	// Start task aging sweeper
+	if agingSweeper != nil {
+		agingSweeper.Start(cfg.TaskAgingInterval)
+	}
 
 	// Start worker pool
 	workerPool.Start()
 
 	// Run metrics server in goroutine
 	go func() {
-		log.Info(fmt.Sprintf("Metrics server listening on port %s", metricsPort))
+		log.Info(fmt.Sprintf("Metrics server listening on port %s", cfg.MetricsPort))
 		if err := metricsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			log.Error(fmt.Sprintf("Error starting metrics server: %v", err))
 		}
@@ -119,19 +278,67 @@ func main() {
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 
-	<-quit
+	// Reload hot-reloadable settings on SIGHUP
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+
+	for waiting := true; waiting; {
+		select {
+		case <-reload:
+			reloadConfig(workerPool, log)
+		case <-quit:
+			waiting = false
+		}
+	}
 	log.Info("Shutting down...")
 
 	// Stop the worker pool
 	workerPool.Stop()
 
+	// Stop publishing job updates once the last in-flight job has finished
+	websocketManager.Stop()
+
 	// Stop the delayed job processor
-	delayedProcessor.Stop()
+	if cfg.DelayedProcessorEnabled {
+		delayedProcessor.Stop()
+	}
+
+	// Stop the queue depth sampler
+	queueSampler.Stop()
+
+	// Stop the dead letter sweeper
+	if deadLetterSweeper != nil {
+		deadLetterSweeper.Stop()
+	}
+
+	// Stop the status count reconciler
+	if statusCountReconciler != nil {
+		statusCountReconciler.Stop()
+	}
+
+	// Stop the task aging sweeper
+	if agingSweeper != nil {
+		agingSweeper.Stop()
+	}
 
 	// Create shutdown context with timeout
-	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	metricsShutdownDelay := cfg.MetricsShutdownDelay
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second+metricsShutdownDelay)
 	defer cancel()
 
+	// Flush buffered spans before exiting
+	if shutdownTracer != nil {
+		if err := shutdownTracer(shutdownCtx); err != nil {
+			log.Error(fmt.Sprintf("Tracer shutdown error: %v", err))
+		}
+	}
+
+	// Keep the metrics endpoint up long enough for a final scrape
+	if metricsShutdownDelay > 0 {
+		log.Info(fmt.Sprintf("Waiting %s for a final metrics scrape", metricsShutdownDelay))
+		time.Sleep(metricsShutdownDelay)
+	}
+
 	// Shutdown metrics server
 	if err := metricsServer.Shutdown(shutdownCtx); err != nil {
 		log.Error(fmt.Sprintf("Metrics server shutdown error: %v", err))
@@ -140,12 +347,82 @@ func main() {
 	log.Info("Worker service stopped")
 }
 
-// Health check handler
+// Health check handler, also used as the liveness probe: it answers as long
+// as the process is up, whatever the state of Redis
 func healthCheckHandler(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte("OK"))
 }
 
+// Readiness handler reporting whether Redis is reachable and the pool is consuming
+func readinessHandler(redisQueue *queue.RedisQueue, workerPool *worker.WorkerPool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := redisQueue.Ping(); err != nil {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte("Redis unavailable: " + err.Error()))
+			return
+		}
+
+		if !workerPool.Running() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte("Worker pool not running"))
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("OK"))
+	}
+}
+
+// reloadConfig re-reads the .env file and applies the settings that can be
+// changed on a running worker pool without dropping in-flight jobs. If any
+// setting is invalid, the current configuration is kept as a whole.
+func reloadConfig(workerPool *worker.WorkerPool, log *logger.Logger) {
+	log.Info("Received SIGHUP, reloading configuration...")
+
+	if err := godotenv.Overload(); err != nil {
+		log.Error("No .env file found or couldn't load it")
+	}
+
+	cfg, parsed, err := loadConfig()
+	if err != nil {
+		log.Error(fmt.Sprintf("Keeping current configuration: %v", err))
+		return
+	}
+
+	workerPool.SetNumWorkers(cfg.NumWorkers)
+	workerPool.SetWorkerConcurrency(cfg.WorkerConcurrency)
+	workerPool.SetPollingInterval(cfg.PollingInterval)
+	workerPool.SetMaxPollingInterval(cfg.MaxPollingInterval)
+	workerPool.SetBlockingConsume(cfg.BlockingConsumeTimeout)
+	workerPool.SetMaxInFlight(cfg.MaxInFlight)
+	workerPool.SetDependencyWait(cfg.DependencyPollInterval, cfg.DependencyMaxWait)
+	workerPool.SetAllowedPriorities(cfg.WorkerPriorities...)
+	workerPool.SetRequiredTags(cfg.WorkerTags...)
+	workerPool.SetConsumeStrategy(parsed.consumeStrategy)
+
+	log.Info("Configuration reloaded")
+}
+
+// Stats handler exposing worker pool runtime state
+func statsHandler(workerPool *worker.WorkerPool, delayedProcessor *worker.DelayedJobProcessor, delayedProcessorEnabled bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		stats := workerPool.Stats()
+		stats["delayed_processor_leader"] = delayedProcessorEnabled && delayedProcessor.IsLeader()
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(stats)
+	}
+}
+
+// Summary handler exposing this worker's aggregated metrics as JSON
+func summaryHandler(metricsCollector *metrics.MetricsCollector) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(metricsCollector.Summary())
+	}
+}
+
 // Register job processors
 func registerJobProcessors(workerPool *worker.WorkerPool) {
 	// Example processor for "echo" jobs
@@ -201,3 +478,27 @@ func registerJobProcessors(workerPool *worker.WorkerPool) {
 
 	// Add more job processors as needed
 }
+
+// deadLetterWebhook returns a dead letter callback that POSTs the task as JSON to url
+func deadLetterWebhook(url string, log *logger.Logger) func(task *queue.Task) {
+	client := &http.Client{Timeout: 10 * time.Second}
+
+	return func(task *queue.Task) {
+		body, err := json.Marshal(task)
+		if err != nil {
+			log.Error(fmt.Sprintf("Failed to encode dead letter task %s: %v", task.ID, err))
+			return
+		}
+
+		resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+		if err != nil {
+			log.Error(fmt.Sprintf("Dead letter webhook failed for task %s: %v", task.ID, err))
+			return
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode >= 300 {
+			log.Error(fmt.Sprintf("Dead letter webhook returned status %d for task %s", resp.StatusCode, task.ID))
+		}
+	}
+}
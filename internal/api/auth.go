@@ -0,0 +1,89 @@
+// internal/api/auth.go
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+// apiKeyLabelKey is the request context key holding the authenticated key's label
+type apiKeyLabelKey struct{}
+
+// SetAPIKeys enables API key authentication on the /api/v1 routes. Keys map
+// each accepted key to an optional label that is logged on job submission.
+// With no keys configured, authentication is disabled.
+func (h *Handler) SetAPIKeys(keys map[string]string) {
+	h.apiKeys = keys
+}
+
+// ParseAPIKeys parses a comma-separated list of keys, each optionally
+// followed by a colon and a label, e.g. "key1:billing,key2"
+func ParseAPIKeys(value string) map[string]string {
+	keys := make(map[string]string)
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		key, label, _ := strings.Cut(entry, ":")
+		keys[strings.TrimSpace(key)] = strings.TrimSpace(label)
+	}
+	return keys
+}
+
+// APIKeyLabel returns the label of the API key that authenticated the request
+func APIKeyLabel(ctx context.Context) string {
+	label, _ := ctx.Value(apiKeyLabelKey{}).(string)
+	return label
+}
+
+// authMiddleware validates the Authorization header against the configured API keys.
+// Both "Bearer <key>" and a bare key are accepted.
+func (h *Handler) authMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(h.apiKeys) == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		provided := strings.TrimSpace(r.Header.Get("Authorization"))
+		provided = strings.TrimSpace(strings.TrimPrefix(provided, "Bearer "))
+		if provided == "" {
+			h.respondWithError(w, http.StatusUnauthorized, "Missing API key")
+			return
+		}
+
+		label, ok := h.lookupAPIKey(provided)
+		if !ok {
+			h.logger.Info("Rejected request with invalid API key", map[string]interface{}{
+				"path":   r.URL.Path,
+				"remote": r.RemoteAddr,
+			})
+			h.respondWithError(w, http.StatusUnauthorized, "Invalid API key")
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), apiKeyLabelKey{}, label)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// lookupAPIKey compares the provided key against every configured key in constant time
+func (h *Handler) lookupAPIKey(provided string) (string, bool) {
+	var (
+		matchedLabel string
+		matched      bool
+	)
+
+	for key, label := range h.apiKeys {
+		if subtle.ConstantTimeCompare([]byte(key), []byte(provided)) == 1 {
+			matchedLabel = label
+			matched = true
+		}
+	}
+
+	return matchedLabel, matched
+}
@@ -0,0 +1,80 @@
+// internal/api/cors.go
+package api
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// DefaultCORSOrigins is the default list of allowed CORS origins: the local playground
+const DefaultCORSOrigins = "http://localhost:5173"
+
+// ParseCORSOrigins parses a comma-separated list of allowed CORS origins. Each
+// origin is a scheme and host such as "https://app.example.com", optionally with
+// one wildcard such as "https://*.example.com". A lone "*" allows any origin and
+// is meant for development.
+func ParseCORSOrigins(value string) ([]string, error) {
+	var origins []string
+	for _, origin := range strings.Split(value, ",") {
+		origin = strings.TrimSuffix(strings.TrimSpace(origin), "/")
+		if origin == "" {
+			continue
+		}
+
+		if origin != "*" {
+			if err := validateCORSOrigin(origin); err != nil {
+				return nil, err
+			}
+		}
+		origins = append(origins, origin)
+	}
+
+	if len(origins) == 0 {
+		return nil, fmt.Errorf("no CORS origins given")
+	}
+	return origins, nil
+}
+
+// validateCORSOrigin checks that an origin is an http or https scheme and host
+// with at most one wildcard
+func validateCORSOrigin(origin string) error {
+	if strings.Count(origin, "*") > 1 {
+		return fmt.Errorf("invalid CORS origin %q: at most one wildcard is allowed", origin)
+	}
+
+	parsed, err := url.Parse(strings.Replace(origin, "*", "wildcard", 1))
+	if err != nil {
+		return fmt.Errorf("invalid CORS origin %q: %v", origin, err)
+	}
+
+	if (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("invalid CORS origin %q: expected http(s)://host[:port]", origin)
+	}
+
+	if parsed.Path != "" || parsed.RawQuery != "" || parsed.Fragment != "" {
+		return fmt.Errorf("invalid CORS origin %q: origins cannot have a path", origin)
+	}
+
+	return nil
+}
+
+// OriginAllowed reports whether an origin matches one of the allowed origins,
+// given as ParseCORSOrigins returns them. Matching ignores case.
+func OriginAllowed(allowed []string, origin string) bool {
+	origin = strings.ToLower(strings.TrimSuffix(origin, "/"))
+
+	for _, pattern := range allowed {
+		pattern = strings.ToLower(pattern)
+		if pattern == "*" || pattern == origin {
+			return true
+		}
+
+		prefix, suffix, wildcard := strings.Cut(pattern, "*")
+		if wildcard && len(origin) >= len(prefix)+len(suffix) &&
+			strings.HasPrefix(origin, prefix) && strings.HasSuffix(origin, suffix) {
+			return true
+		}
+	}
+	return false
+}
@@ -92,19 +92,30 @@ func (s *DashboardService) DashboardStatsHandler(w http.ResponseWriter, r *http.
 		return
 	}
 
-	// Mock data for job counts by type and status
+	// Mock data for job counts by type
 	jobCountByType := map[string]int{
 		"email":  25,
 		"report": 15,
 		"export": 10,
 	}
 
+	// Status counts are kept by every status update, so no task records are scanned
+	statusCounts, err := s.queue.GetStatusCounts()
+	if err != nil {
+		s.logger.Error("Failed to get job status counts: " + err.Error())
+		writeJSONError(w, "Failed to get job status counts", http.StatusInternalServerError)
+		return
+	}
+
 	jobStatusCounts := map[string]int{
-		StatusPending:   20,
-		StatusRunning:   5,
-		StatusCompleted: 40,
-		StatusFailed:    3,
-		StatusRetrying:  2,
+		StatusPending:   0,
+		StatusRunning:   0,
+		StatusCompleted: 0,
+		StatusFailed:    0,
+		StatusRetrying:  0,
+	}
+	for status, count := range statusCounts {
+		jobStatusCounts[status] = int(count)
 	}
 
 	// Mock data for recent jobs
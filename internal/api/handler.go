@@ -3,26 +3,44 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"net/url"
+	"sort"
 	"strconv"
 	"time"
 
 	"BoltQ/internal/job"
 	"BoltQ/internal/queue"
+	"BoltQ/internal/worker"
 	"BoltQ/pkg/logger"
 	"BoltQ/pkg/metrics"
+	"BoltQ/pkg/payload"
 
-	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 )
 
 // Handler handles HTTP requests for the API
 type Handler struct {
-	queue           *queue.RedisQueue
-	logger          *logger.Logger
-	metrics         *metrics.MetricsCollector
-	workflowManager *job.WorkflowManager
+	queue             *queue.RedisQueue
+	logger            *logger.Logger
+	metrics           *metrics.MetricsCollector
+	workflowManager   *job.WorkflowManager
+	apiKeys           map[string]string
+	maxBodySize       int64
+	submitBuffer      *queue.SubmitBuffer
+	transformers      map[string]SubmitTransformer
+	jobUpdates        *WebSocketManager
+	allowedJobTypes   map[string]bool
+	requireRegistered bool
+	submitRateLimit   queue.RateLimit
+	clientRateLimits  map[string]queue.RateLimit
+	leaseTimeout      time.Duration
+	errorHandler      *worker.ErrorHandler
+	callbacks         *worker.CallbackNotifier
+	readOnly          bool
 }
 
 // NewHandler creates a new API handler
@@ -33,42 +51,144 @@ func NewHandler(queue *queue.RedisQueue, logger *logger.Logger, metrics *metrics
 		logger:          logger,
 		metrics:         metrics,
 		workflowManager: workflowManager,
+		maxBodySize:     queue.MaxPayloadSize(),
 	}
 }
 
+// SetSubmitBuffer enables buffering of job submissions on local disk while
+// Redis is unreachable. Buffered jobs are accepted with 202 and published once
+// Redis recovers.
+func (h *Handler) SetSubmitBuffer(buffer *queue.SubmitBuffer) {
+	h.submitBuffer = buffer
+}
+
+// SetJobUpdates lets clients fetch the last live update of a job from the
+// WebSocket manager, e.g. after missing updates while disconnected
+func (h *Handler) SetJobUpdates(manager *WebSocketManager) {
+	h.jobUpdates = manager
+}
+
+// SetMaxBodySize limits the size of JSON request bodies accepted by the job and
+// workflow submission endpoints; it defaults to the queue's payload limit.
+// A maxSize of 0 or less removes the limit.
+func (h *Handler) SetMaxBodySize(maxSize int64) {
+	h.maxBodySize = maxSize
+}
+
 // Response represents a standard API response
 type Response struct {
-	Success bool        `json:"success"`
-	Data    interface{} `json:"data,omitempty"`
-	Error   string      `json:"error,omitempty"`
+	Success bool                 `json:"success"`
+	Data    interface{}          `json:"data,omitempty"`
+	Error   string               `json:"error,omitempty"`
+	Errors  job.ValidationErrors `json:"errors,omitempty"`
 }
 
 // SubmitJobRequest represents a job submission request
 type SubmitJobRequest struct {
+	ID           string                 `json:"id,omitempty"`
 	Type         string                 `json:"type"`
 	Data         map[string]interface{} `json:"data"`
-	Priority     int                    `json:"priority,omitempty"`
+	Priority     json.RawMessage        `json:"priority,omitempty"`
 	DelaySeconds int                    `json:"delay_seconds,omitempty"`
+	CallbackURL  string                 `json:"callback_url,omitempty"`
+	DependsOn    string                 `json:"depends_on_job_id,omitempty"`
+	Deadline     *time.Time             `json:"deadline,omitempty"`
+	Tags         []string               `json:"tags,omitempty"`
+}
+
+// UpdateJobRequest changes a job that has not started yet
+type UpdateJobRequest struct {
+	Priority json.RawMessage `json:"priority"`
+}
+
+// ReplayJobRequest optionally overrides the payload of a replayed job
+type ReplayJobRequest struct {
+	Data map[string]interface{} `json:"data,omitempty"`
+}
+
+// MaxStatusLookupIDs is the most jobs a bulk status lookup may ask for
+const MaxStatusLookupIDs = 1000
+
+// JobStatusesRequest lists the jobs to look up at once
+type JobStatusesRequest struct {
+	IDs []string `json:"ids"`
+}
+
+// JobStatusResult is the status of one job of a bulk lookup. Task is omitted
+// for jobs that don't exist and for jobs whose record can't be read, which
+// are reported in Error instead.
+type JobStatusResult struct {
+	ID    string      `json:"id"`
+	Found bool        `json:"found"`
+	Task  *queue.Task `json:"task,omitempty"`
+	Error string      `json:"error,omitempty"`
+}
+
+// DrainQueueRequest names the queue to drain and the queue to move its tasks to
+type DrainQueueRequest struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// CreateWorkflowRequest represents a workflow creation or validation request
+type CreateWorkflowRequest struct {
+	Name     string                  `json:"name"`
+	Steps    []job.WorkflowStepInput `json:"steps"`
+	Metadata map[string]interface{}  `json:"metadata,omitempty"`
 }
 
 // RegisterRoutes sets up the API routes
 func (h *Handler) RegisterRoutes(r *mux.Router) {
+	// All versioned API routes share the authentication middleware
+	v1 := r.PathPrefix("/api/v1").Subrouter()
+	v1.Use(h.authMiddleware)
+
+	// Routes that change jobs, queues or workflows are wrapped in h.writable,
+	// which rejects them while the API is read-only
+
 	// Job endpoints
-	r.HandleFunc("/api/v1/jobs", h.SubmitJobHandler).Methods("POST")
-	r.HandleFunc("/api/v1/jobs/{id}", h.GetJobStatusHandler).Methods("GET")
-	r.HandleFunc("/api/v1/jobs/{id}/cancel", h.CancelJobHandler).Methods("POST")
+	v1.HandleFunc("/jobs", h.writable(h.submitRateLimited(h.SubmitJobHandler))).Methods("POST")
+	v1.HandleFunc("/jobs/next", h.writable(h.NextJobHandler)).Methods("GET")
+	v1.HandleFunc("/jobs/status", h.GetJobStatusesHandler).Methods("POST")
+	v1.HandleFunc("/jobs/{id}", h.GetJobStatusHandler).Methods("GET")
+	v1.HandleFunc("/jobs/{id}", h.writable(h.UpdateJobHandler)).Methods("PATCH")
+	v1.HandleFunc("/jobs/{id}/cancel", h.writable(h.CancelJobHandler)).Methods("POST")
+	v1.HandleFunc("/jobs/{id}/history", h.GetJobHistoryHandler).Methods("GET")
+	v1.HandleFunc("/jobs/{id}/updates/latest", h.GetLatestJobUpdateHandler).Methods("GET")
+	v1.HandleFunc("/jobs/{id}/replay", h.writable(h.ReplayJobHandler)).Methods("POST")
+	v1.HandleFunc("/jobs/{id}/retry-now", h.writable(h.RetryJobNowHandler)).Methods("POST")
+	v1.HandleFunc("/jobs/{id}/complete", h.writable(h.CompleteJobHandler)).Methods("POST")
+	v1.HandleFunc("/jobs/{id}/fail", h.writable(h.FailJobHandler)).Methods("POST")
 
 	// Queue endpoints
-	r.HandleFunc("/api/v1/queues/stats", h.GetQueueStatsHandler).Methods("GET")
+	v1.HandleFunc("/queues/stats", h.GetQueueStatsHandler).Methods("GET")
+	v1.HandleFunc("/queues/delayed/peek", h.PeekDelayedHandler).Methods("GET")
+	v1.HandleFunc("/queues/drain", h.writable(h.DrainQueueHandler)).Methods("POST")
+	v1.HandleFunc("/queues/{priority}/peek", h.PeekQueueHandler).Methods("GET")
 
-	// Workflow endpoints
-	r.HandleFunc("/api/v1/workflows", h.CreateWorkflowHandler).Methods("POST")
-	r.HandleFunc("/api/v1/workflows", h.ListWorkflowsHandler).Methods("GET")
-	r.HandleFunc("/api/v1/workflows/{id}", h.GetWorkflowHandler).Methods("GET")
-	r.HandleFunc("/api/v1/workflows/{id}", h.DeleteWorkflowHandler).Methods("DELETE")
+	// Metrics endpoints
+	v1.HandleFunc("/metrics/summary", h.MetricsSummaryHandler).Methods("GET")
+	v1.HandleFunc("/stats/errors", h.GetErrorStatsHandler).Methods("GET")
 
-	// Health endpoint
+	// Workflow endpoints
+	v1.HandleFunc("/workflows", h.writable(h.submitRateLimited(h.CreateWorkflowHandler))).Methods("POST")
+	v1.HandleFunc("/workflows", h.ListWorkflowsHandler).Methods("GET")
+	v1.HandleFunc("/workflows/validate", h.ValidateWorkflowHandler).Methods("POST")
+	v1.HandleFunc("/workflows/archived", h.ListArchivedWorkflowsHandler).Methods("GET")
+	v1.HandleFunc("/workflows/{id}", h.GetWorkflowHandler).Methods("GET")
+	v1.HandleFunc("/workflows/{id}/steps", h.GetWorkflowStepsHandler).Methods("GET")
+	v1.HandleFunc("/workflows/{id}", h.writable(h.DeleteWorkflowHandler)).Methods("DELETE")
+	v1.HandleFunc("/workflows/{id}/cancel", h.writable(h.CancelWorkflowHandler)).Methods("POST")
+
+	// Admin endpoints
+	v1.HandleFunc("/admin/read-only", h.GetReadOnlyHandler).Methods("GET")
+	v1.HandleFunc("/admin/read-only", h.SetReadOnlyHandler).Methods("PUT")
+
+	// Health endpoints
 	r.HandleFunc("/health", h.HealthCheckHandler).Methods("GET")
+	r.HandleFunc("/health/detailed", h.DetailedHealthCheckHandler).Methods("GET")
+	r.HandleFunc("/livez", h.LivenessHandler).Methods("GET")
+	r.HandleFunc("/readyz", h.ReadinessHandler).Methods("GET")
 }
 
 // SubmitJobHandler handles job submission requests
@@ -78,8 +198,13 @@ func (h *Handler) RegisterRoutes(r *mux.Router) {
 // @Accept json
 // @Produce json
 // @Param job body SubmitJobRequest true "Job details"
+// @Param dry_run query bool false "Validate the job and return the task it would create without enqueuing it"
 // @Success 200 {object} Response
 // @Failure 400 {object} Response "Invalid request"
+// @Failure 422 {object} Response "Dry run found validation errors"
+// @Success 202 {object} Response "Buffered locally while Redis is unavailable"
+// @Failure 413 {object} Response "Payload too large"
+// @Failure 429 {object} Response "Queue is full or submission rate limit exceeded"
 // @Failure 500 {object} Response "Server error"
 // @Router /api/v1/jobs [post]
 func (h *Handler) SubmitJobHandler(w http.ResponseWriter, r *http.Request) {
@@ -89,36 +214,110 @@ func (h *Handler) SubmitJobHandler(w http.ResponseWriter, r *http.Request) {
 	}()
 
 	var req SubmitJobRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+	if !h.decodeJSONBody(w, r, &req) {
 		return
 	}
 
-	// Validate request
-	if req.Type == "" {
-		h.respondWithError(w, http.StatusBadRequest, "Job type is required")
+	// Validate request; a dry run reports every problem instead
+	dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dry_run"))
+	priority, validationErrors := validateSubmission(&req)
+
+	// Reject job types outside the allowlist
+	reason, err := h.checkJobType(req.Type)
+	if err != nil {
+		h.logger.Error("Failed to check job type: " + err.Error())
+		h.respondWithError(w, http.StatusInternalServerError, "Failed to validate job")
+		return
+	}
+	if reason != "" {
+		validationErrors = append(validationErrors, job.ValidationError{Field: "type", Message: reason})
+	}
+
+	if len(validationErrors) > 0 && !dryRun {
+		h.respondWithValidationErrors(w, http.StatusBadRequest, validationErrors)
 		return
 	}
 
-	// Create a task
+	// Normalize the payload with the job type's transformer, if any
+	if data, err := h.transformSubmission(req.Type, req.Data); err != nil {
+		validationErrors = append(validationErrors, job.ValidationError{Field: "data", Message: err.Error()})
+		if !dryRun {
+			h.respondWithValidationErrors(w, http.StatusBadRequest, validationErrors)
+			return
+		}
+	} else {
+		req.Data = data
+	}
+
+	taskID := req.ID
+	if taskID == "" {
+		taskID = job.NewID()
+	}
+
+	// Create a task. Its submission time is set here so that time spent in
+	// the submit buffer counts towards its latency.
+	now := time.Now()
 	task := &queue.Task{
-		ID:        uuid.New().String(),
-		Type:      req.Type,
-		Data:      req.Data,
-		Priority:  req.Priority,
-		CreatedAt: time.Now(),
-		Status:    "pending",
+		ID:             taskID,
+		Type:           req.Type,
+		Data:           req.Data,
+		Priority:       priority,
+		CreatedAt:      now,
+		SubmittedAt:    now,
+		Status:         "pending",
+		CallbackURL:    req.CallbackURL,
+		DependsOnJobID: req.DependsOn,
+		Deadline:       req.Deadline,
+		Tags:           req.Tags,
+		PayloadRefs:    payload.RefList(req.Data),
 	}
 
-	var err error
+	if dryRun {
+		h.dryRunSubmission(w, task, req.DelaySeconds, validationErrors)
+		return
+	}
 
-	// Either publish immediately or with delay
-	if req.DelaySeconds > 0 {
+	// Either publish immediately or with delay. Caller-supplied IDs are
+	// published only once so that retried submissions are idempotent.
+	if req.ID != "" {
+		var existing *queue.Task
+		existing, err = h.queue.PublishUnique(task, req.DelaySeconds)
+		if errors.Is(err, queue.ErrTaskExists) {
+			h.logger.Info(fmt.Sprintf("Job %s already exists, returning its current status", existing.ID))
+			h.respondWithJSON(w, http.StatusOK, Response{
+				Success: true,
+				Data: map[string]interface{}{
+					"job_id":      existing.ID,
+					"status":      existing.Status,
+					"duplicate":   true,
+					"status_url":  jobStatusPath(existing.ID),
+					"updates_url": JobUpdatesPath(existing.ID),
+				},
+			})
+			return
+		}
+	} else if req.DelaySeconds > 0 {
 		err = h.queue.PublishDelayed(task, req.DelaySeconds)
 	} else {
 		err = h.queue.Publish(task)
 	}
 
+	// Keep the job on local disk if Redis is down and buffering is enabled
+	if h.submitBuffer != nil && queue.IsRedisUnavailable(err) {
+		h.bufferSubmission(w, task, req.DelaySeconds, req.ID != "", err)
+		return
+	}
+
+	if errors.Is(err, queue.ErrQueueFull) {
+		h.respondWithError(w, http.StatusTooManyRequests, "Queue is full, try again later")
+		return
+	}
+
+	if errors.Is(err, queue.ErrPayloadTooLarge) {
+		h.respondWithError(w, http.StatusRequestEntityTooLarge, "Job payload too large")
+		return
+	}
+
 	if err != nil {
 		h.logger.Error("Failed to publish job: " + err.Error())
 		h.respondWithError(w, http.StatusInternalServerError, "Failed to publish job")
@@ -126,12 +325,129 @@ func (h *Handler) SubmitJobHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	h.metrics.IncrementJobCounter("submitted")
-	h.logger.Info(fmt.Sprintf("Job %s of type %s submitted successfully", task.ID, task.Type))
+	h.logger.Info(fmt.Sprintf("Job %s of type %s submitted successfully", task.ID, task.Type), map[string]interface{}{
+		"api_key":             APIKeyLabel(r.Context()),
+		logger.SampleKeyField: "job_submitted",
+	})
 
 	h.respondWithJSON(w, http.StatusOK, Response{
 		Success: true,
 		Data: map[string]string{
-			"job_id": task.ID,
+			"job_id":      task.ID,
+			"status_url":  jobStatusPath(task.ID),
+			"updates_url": JobUpdatesPath(task.ID),
+		},
+	})
+}
+
+// jobStatusPath returns the API path at which a job's status can be fetched
+func jobStatusPath(jobID string) string {
+	return "/api/v1/jobs/" + url.PathEscape(jobID)
+}
+
+// validateSubmission checks a job submission and resolves its priority. It
+// returns every problem found.
+func validateSubmission(req *SubmitJobRequest) (int, job.ValidationErrors) {
+	var errs job.ValidationErrors
+
+	if req.Type == "" {
+		errs = append(errs, job.ValidationError{Field: "type", Message: "Job type is required"})
+	}
+
+	if req.CallbackURL != "" && !isValidCallbackURL(req.CallbackURL) {
+		errs = append(errs, job.ValidationError{Field: "callback_url",
+			Message: "Callback URL must be an absolute http or https URL"})
+	}
+
+	priority, err := parsePriority(req.Priority)
+	if err != nil {
+		errs = append(errs, job.ValidationError{Field: "priority", Message: err.Error()})
+	}
+
+	if req.ID != "" && !queue.IsValidTaskID(req.ID) {
+		errs = append(errs, job.ValidationError{Field: "id",
+			Message: "Job ID must be 1-128 characters of letters, digits, '.', '_', ':' or '-'"})
+	}
+
+	if req.DependsOn != "" && !queue.IsValidTaskID(req.DependsOn) {
+		errs = append(errs, job.ValidationError{Field: "depends_on_job_id",
+			Message: "Dependency job ID is not a valid job ID"})
+	}
+
+	if req.Deadline != nil && !req.Deadline.After(time.Now()) {
+		errs = append(errs, job.ValidationError{Field: "deadline", Message: "Deadline must be in the future"})
+	}
+
+	for _, tag := range req.Tags {
+		if !queue.ValidTag(tag) {
+			errs = append(errs, job.ValidationError{Field: "tags",
+				Message: fmt.Sprintf("Tag %q must be 1-64 characters of letters, digits, '_' or '-'", tag)})
+		}
+	}
+
+	// Payload references must be URLs that workers can resolve
+	refs := payload.Refs(req.Data)
+	paths := make([]string, 0, len(refs))
+	for path := range refs {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		if _, err := payload.ParseRef(refs[path]); err != nil {
+			errs = append(errs, job.ValidationError{Field: "data." + path, Message: err.Error()})
+		}
+	}
+
+	return priority, errs
+}
+
+// dryRunSubmission responds with the task a submission would create without
+// publishing it. On top of the request validation, it checks that a live
+// worker can process the job type and that the payload fits the size limit.
+func (h *Handler) dryRunSubmission(w http.ResponseWriter, task *queue.Task, delaySeconds int, errs job.ValidationErrors) {
+	// Submission validation already checked this when registration is required
+	if task.Type != "" && !h.requireRegistered {
+		registered, err := h.queue.IsJobTypeRegistered(task.Type, queue.WorkerHeartbeatTTL)
+		if err != nil {
+			h.logger.Error("Failed to check job type: " + err.Error())
+			h.respondWithError(w, http.StatusInternalServerError, "Failed to validate job")
+			return
+		}
+		if !registered {
+			errs = append(errs, job.ValidationError{Field: "type",
+				Message: fmt.Sprintf("No running worker has a processor for job type %s", task.Type)})
+		}
+	}
+
+	if err := h.queue.CheckPayloadSize(task); err != nil {
+		errs = append(errs, job.ValidationError{Field: "data", Message: err.Error()})
+	}
+
+	if len(errs) > 0 {
+		h.respondWithJSON(w, http.StatusUnprocessableEntity, Response{
+			Success: false,
+			Data: map[string]interface{}{
+				"valid":  false,
+				"errors": errs,
+			},
+			Error:  errs.Error(),
+			Errors: errs,
+		})
+		return
+	}
+
+	// Show the task as it would be stored
+	task.Priority = queue.NormalizePriority(task.Priority)
+	if delaySeconds > 0 {
+		task.Status = string(queue.StatusScheduled)
+		task.ScheduledAt = task.CreatedAt.Add(time.Duration(delaySeconds) * time.Second)
+	}
+
+	h.respondWithJSON(w, http.StatusOK, Response{
+		Success: true,
+		Data: map[string]interface{}{
+			"valid": true,
+			"task":  task,
 		},
 	})
 }
@@ -169,6 +485,57 @@ func (h *Handler) GetJobStatusHandler(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// GetJobStatusesHandler handles requests for the status of many jobs at once
+// @Summary Get the status of many jobs
+// @Description Gets the current status of up to 1000 jobs in one request, in the order given. Jobs that don't exist are returned with found set to false, and jobs whose record can't be read with an error
+// @Tags jobs
+// @Accept json
+// @Produce json
+// @Param request body JobStatusesRequest true "Job IDs"
+// @Success 200 {object} Response
+// @Failure 400 {object} Response "Invalid request"
+// @Failure 500 {object} Response "Server error"
+// @Router /api/v1/jobs/status [post]
+func (h *Handler) GetJobStatusesHandler(w http.ResponseWriter, r *http.Request) {
+	var req JobStatusesRequest
+	if !h.decodeJSONBody(w, r, &req) {
+		return
+	}
+	if len(req.IDs) == 0 {
+		h.respondWithError(w, http.StatusBadRequest, "ids must list at least one job ID")
+		return
+	}
+	if len(req.IDs) > MaxStatusLookupIDs {
+		h.respondWithError(w, http.StatusBadRequest,
+			fmt.Sprintf("ids may list at most %d job IDs", MaxStatusLookupIDs))
+		return
+	}
+
+	tasks, decodeErrs, err := h.queue.GetTaskStatuses(req.IDs)
+	if err != nil {
+		h.logger.Error("Failed to get job statuses: " + err.Error())
+		h.respondWithError(w, http.StatusInternalServerError, "Failed to get job statuses")
+		return
+	}
+
+	results := make([]JobStatusResult, len(req.IDs))
+	for i, id := range req.IDs {
+		results[i] = JobStatusResult{ID: id, Found: tasks[i] != nil, Task: tasks[i]}
+
+		// Report unreadable records without failing the whole lookup
+		if decodeErrs[i] != nil {
+			h.logger.Error("Failed to read job status: " + decodeErrs[i].Error())
+			results[i].Found = true
+			results[i].Error = "Failed to read job status"
+		}
+	}
+
+	h.respondWithJSON(w, http.StatusOK, Response{
+		Success: true,
+		Data:    map[string]interface{}{"jobs": results},
+	})
+}
+
 // CancelJobHandler handles job cancellation requests
 // @Summary Cancel a job
 // @Description Cancels a pending job
@@ -223,158 +590,696 @@ func (h *Handler) CancelJobHandler(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// GetQueueStatsHandler handles queue statistics requests
-// @Summary Get queue statistics
-// @Description Gets statistics about all queues
-// @Tags queues
+// UpdateJobHandler handles job reprioritization requests
+// @Summary Reprioritize a job
+// @Description Moves a pending or scheduled job to another priority queue, keeping its ID and history
+// @Tags jobs
+// @Accept json
 // @Produce json
+// @Param id path string true "Job ID"
+// @Param job body UpdateJobRequest true "New priority"
 // @Success 200 {object} Response
+// @Failure 400 {object} Response "Invalid priority"
+// @Failure 404 {object} Response "Job not found"
+// @Failure 409 {object} Response "Job is running or no longer queued"
 // @Failure 500 {object} Response "Server error"
-// @Router /api/v1/queues/stats [get]
-func (h *Handler) GetQueueStatsHandler(w http.ResponseWriter, r *http.Request) {
-	stats, err := h.queue.GetQueueStats()
+// @Router /api/v1/jobs/{id} [patch]
+func (h *Handler) UpdateJobHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	jobID := vars["id"]
+
+	var req UpdateJobRequest
+	if !h.decodeJSONBody(w, r, &req) {
+		return
+	}
 
+	if len(req.Priority) == 0 || string(req.Priority) == "null" {
+		h.respondWithError(w, http.StatusBadRequest, "Priority is required")
+		return
+	}
+
+	priority, err := parsePriority(req.Priority)
 	if err != nil {
-		h.logger.Error("Failed to get queue stats: " + err.Error())
-		h.respondWithError(w, http.StatusInternalServerError, "Failed to get queue statistics")
+		h.respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = h.queue.Reprioritize(jobID, priority)
+	switch {
+	case err == nil:
+	case err.Error() == "task not found":
+		h.respondWithError(w, http.StatusNotFound, "Job not found")
+		return
+	case errors.Is(err, queue.ErrTaskRunning):
+		h.respondWithError(w, http.StatusConflict, "Job is already running")
+		return
+	case errors.Is(err, queue.ErrTaskNotQueued):
+		h.respondWithError(w, http.StatusConflict, "Only pending or scheduled jobs can be reprioritized")
+		return
+	default:
+		h.logger.Error("Failed to reprioritize job: " + err.Error())
+		h.respondWithError(w, http.StatusInternalServerError, "Failed to reprioritize job")
 		return
 	}
 
+	h.logger.Info(fmt.Sprintf("Job %s moved to priority %d", jobID, priority))
+
 	h.respondWithJSON(w, http.StatusOK, Response{
 		Success: true,
-		Data:    stats,
+		Data: map[string]interface{}{
+			"job_id":   jobID,
+			"priority": priority,
+		},
 	})
 }
 
-// CreateWorkflowHandler handles workflow creation requests
-// @Summary Create a new workflow
-// @Description Creates a new job workflow
-// @Tags workflows
+// ReplayJobHandler handles job replay requests
+// @Summary Replay a job
+// @Description Enqueues a copy of a finished job under a new ID, optionally with a different payload
+// @Tags jobs
 // @Accept json
 // @Produce json
-// @Param workflow body object true "Workflow details"
+// @Param id path string true "Job ID"
+// @Param job body ReplayJobRequest false "Payload override"
 // @Success 200 {object} Response
-// @Failure 400 {object} Response "Invalid request"
+// @Failure 400 {object} Response "Job cannot be replayed"
+// @Failure 404 {object} Response "Job not found"
 // @Failure 500 {object} Response "Server error"
-// @Router /api/v1/workflows [post]
-func (h *Handler) CreateWorkflowHandler(w http.ResponseWriter, r *http.Request) {
-	var req struct {
-		Name     string                  `json:"name"`
-		Steps    []job.WorkflowStepInput `json:"steps"`
-		Metadata map[string]interface{}  `json:"metadata,omitempty"`
-	}
+// @Router /api/v1/jobs/{id}/replay [post]
+func (h *Handler) ReplayJobHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	jobID := vars["id"]
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+	// The body is optional; an empty body replays the original payload
+	var req ReplayJobRequest
+	if !h.decodeOptionalJSONBody(w, r, &req) {
 		return
 	}
 
-	// Validate request
-	if req.Name == "" {
-		h.respondWithError(w, http.StatusBadRequest, "Workflow name is required")
+	original, err := h.queue.GetTaskStatus(jobID)
+	if err != nil {
+		if err.Error() == "task not found" {
+			h.respondWithError(w, http.StatusNotFound, "Job not found")
+			return
+		}
+
+		h.logger.Error("Failed to get job status: " + err.Error())
+		h.respondWithError(w, http.StatusInternalServerError, "Failed to get job status")
 		return
 	}
 
-	if len(req.Steps) == 0 {
-		h.respondWithError(w, http.StatusBadRequest, "Workflow must have at least one step")
+	// Only finished jobs can be replayed
+	if original.Status != "completed" && original.Status != "failed" && original.Status != "cancelled" {
+		h.respondWithError(w, http.StatusBadRequest, "Only completed, failed or cancelled jobs can be replayed")
 		return
 	}
 
-	// Create workflow
-	workflow := job.NewWorkflow(req.Name)
+	data := req.Data
+	if data == nil {
+		data = replayData(original.Data)
+	}
 
-	if req.Metadata != nil {
-		workflow.Metadata = req.Metadata
+	task := &queue.Task{
+		ID:          job.NewID(),
+		Type:        original.Type,
+		Data:        data,
+		Priority:    original.Priority,
+		CreatedAt:   time.Now(),
+		Status:      "pending",
+		CallbackURL: original.CallbackURL,
+		Tags:        append([]string(nil), original.Tags...),
+		PayloadRefs: payload.RefList(data),
 	}
 
-	// Add steps
-	for _, stepInput := range req.Steps {
-		workflow.AddStep(stepInput.JobType, stepInput.Params, stepInput.DependsOn)
+	err = h.queue.Publish(task)
+	if errors.Is(err, queue.ErrQueueFull) {
+		h.respondWithError(w, http.StatusTooManyRequests, "Queue is full, try again later")
+		return
 	}
 
-	// Save workflow
-	if err := h.workflowManager.SaveWorkflow(workflow); err != nil {
-		h.logger.Error("Failed to save workflow: " + err.Error())
-		h.respondWithError(w, http.StatusInternalServerError, "Failed to create workflow")
+	if err != nil {
+		h.logger.Error("Failed to publish replayed job: " + err.Error())
+		h.respondWithError(w, http.StatusInternalServerError, "Failed to replay job")
 		return
 	}
 
-	h.logger.Info(fmt.Sprintf("Workflow %s created successfully with %d steps", workflow.ID, len(workflow.Steps)))
+	h.metrics.IncrementJobCounter("submitted")
+	h.logger.Info(fmt.Sprintf("Job %s replayed as %s", jobID, task.ID))
 
 	h.respondWithJSON(w, http.StatusOK, Response{
 		Success: true,
 		Data: map[string]string{
-			"workflow_id": workflow.ID,
+			"job_id":          task.ID,
+			"original_job_id": jobID,
 		},
 	})
 }
 
-// GetWorkflowHandler handles workflow retrieval requests
-// @Summary Get workflow details
-// @Description Gets the details of a workflow
-// @Tags workflows
+// replayData copies a stored task payload, leaving out the result and workflow
+// context that the worker added while processing the original job
+func replayData(stored map[string]interface{}) map[string]interface{} {
+	data := make(map[string]interface{}, len(stored))
+	for k, v := range stored {
+		switch k {
+		case "result", job.WorkflowIDKey, job.WorkflowStepIDKey, job.StepInputsKey:
+			continue
+		}
+		data[k] = v
+	}
+	return data
+}
+
+// RetryJobNowHandler handles requests to retry a job without waiting for its backoff
+// @Summary Retry a job now
+// @Description Moves a job waiting for a retry from the delayed set to its priority queue right away
+// @Tags jobs
 // @Produce json
-// @Param id path string true "Workflow ID"
+// @Param id path string true "Job ID"
 // @Success 200 {object} Response
-// @Failure 404 {object} Response "Workflow not found"
+// @Failure 404 {object} Response "Job not found or not waiting for a retry"
+// @Failure 429 {object} Response "Queue is full"
 // @Failure 500 {object} Response "Server error"
-// @Router /api/v1/workflows/{id} [get]
-func (h *Handler) GetWorkflowHandler(w http.ResponseWriter, r *http.Request) {
+// @Router /api/v1/jobs/{id}/retry-now [post]
+func (h *Handler) RetryJobNowHandler(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
-	workflowID := vars["id"]
-
-	workflow, err := h.workflowManager.GetWorkflow(workflowID)
-
-	if err != nil {
-		if err.Error() == fmt.Sprintf("workflow %s not found", workflowID) {
-			h.respondWithError(w, http.StatusNotFound, "Workflow not found")
-			return
-		}
+	jobID := vars["id"]
 
-		h.logger.Error("Failed to get workflow: " + err.Error())
-		h.respondWithError(w, http.StatusInternalServerError, "Failed to get workflow")
+	task, err := h.queue.RetryNow(jobID)
+	switch {
+	case err == nil:
+	case errors.Is(err, queue.ErrTaskNotRetrying):
+		h.respondWithError(w, http.StatusNotFound, "Job is not waiting for a retry")
+		return
+	case errors.Is(err, queue.ErrQueueFull):
+		h.respondWithError(w, http.StatusTooManyRequests, "Queue is full, try again later")
+		return
+	default:
+		h.logger.Error("Failed to retry job: " + err.Error())
+		h.respondWithError(w, http.StatusInternalServerError, "Failed to retry job")
 		return
 	}
 
+	h.logger.Info(fmt.Sprintf("Job %s requeued for retry %d", jobID, task.Attempts))
+
 	h.respondWithJSON(w, http.StatusOK, Response{
 		Success: true,
-		Data:    workflow,
+		Data: map[string]interface{}{
+			"job_id":   jobID,
+			"status":   task.Status,
+			"attempts": task.Attempts,
+		},
 	})
 }
 
-// ListWorkflowsHandler handles workflow listing requests
-// @Summary List workflows
-// @Description Lists workflows with pagination
-// @Tags workflows
+// GetJobHistoryHandler handles job status history requests
+// @Summary Get job history
+// @Description Gets the audit trail of status transitions for a job
+// @Tags jobs
 // @Produce json
-// @Param limit query int false "Number of workflows to return (default 20)"
-// @Param offset query int false "Offset for pagination (default 0)"
+// @Param id path string true "Job ID"
 // @Success 200 {object} Response
+// @Failure 404 {object} Response "No history found"
 // @Failure 500 {object} Response "Server error"
-// @Router /api/v1/workflows [get]
-func (h *Handler) ListWorkflowsHandler(w http.ResponseWriter, r *http.Request) {
-	// Parse query parameters
-	limitStr := r.URL.Query().Get("limit")
-	offsetStr := r.URL.Query().Get("offset")
-
-	limit := 20
-	offset := 0
+// @Router /api/v1/jobs/{id}/history [get]
+func (h *Handler) GetJobHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	jobID := vars["id"]
 
-	if limitStr != "" {
-		parsedLimit, err := strconv.Atoi(limitStr)
-		if err == nil && parsedLimit > 0 {
-			limit = parsedLimit
-		}
+	events, err := h.queue.GetTaskHistory(jobID)
+	if err != nil {
+		h.logger.Error("Failed to get job history: " + err.Error())
+		h.respondWithError(w, http.StatusInternalServerError, "Failed to get job history")
+		return
 	}
 
-	if offsetStr != "" {
-		parsedOffset, err := strconv.Atoi(offsetStr)
-		if err == nil && parsedOffset >= 0 {
-			offset = parsedOffset
-		}
+	if len(events) == 0 {
+		h.respondWithError(w, http.StatusNotFound, "No history found for job")
+		return
 	}
 
-	workflows, err := h.workflowManager.ListWorkflows(limit, offset)
+	h.respondWithJSON(w, http.StatusOK, Response{
+		Success: true,
+		Data:    events,
+	})
+}
+
+// GetLatestJobUpdateHandler handles requests for the last live update of a job
+// @Summary Get the latest job update
+// @Description Returns the last update published over WebSocket for a job in the past day, for clients that may have missed live updates
+// @Tags jobs
+// @Produce json
+// @Param id path string true "Job ID"
+// @Success 200 {object} Response
+// @Failure 404 {object} Response "No update found"
+// @Failure 500 {object} Response "Server error"
+// @Router /api/v1/jobs/{id}/updates/latest [get]
+func (h *Handler) GetLatestJobUpdateHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	jobID := vars["id"]
+
+	if h.jobUpdates == nil {
+		h.respondWithError(w, http.StatusNotFound, "No update found")
+		return
+	}
+
+	update, err := h.jobUpdates.LatestJobUpdate(jobID)
+	if err != nil {
+		h.logger.Error("Failed to get latest job update: " + err.Error())
+		h.respondWithError(w, http.StatusInternalServerError, "Failed to get latest job update")
+		return
+	}
+	if update == nil {
+		h.respondWithError(w, http.StatusNotFound, "No update found")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, Response{
+		Success: true,
+		Data:    update,
+	})
+}
+
+// GetQueueStatsHandler handles queue statistics requests
+// @Summary Get queue statistics
+// @Description Gets task counts by priority name, delayed and dead-lettered tasks, the raw per-queue counts and the number of suppressed duplicate submissions
+// @Tags queues
+// @Produce json
+// @Success 200 {object} Response
+// @Failure 500 {object} Response "Server error"
+// @Router /api/v1/queues/stats [get]
+func (h *Handler) GetQueueStatsHandler(w http.ResponseWriter, r *http.Request) {
+	stats, err := h.queue.GetQueueSummary()
+
+	if err != nil {
+		h.logger.Error("Failed to get queue stats: " + err.Error())
+		h.respondWithError(w, http.StatusInternalServerError, "Failed to get queue statistics")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, Response{
+		Success: true,
+		Data:    stats,
+	})
+}
+
+// PeekQueueHandler handles requests to inspect the next task of a priority queue
+// @Summary Peek at a priority queue
+// @Description Returns the task that will be consumed next from a priority queue without removing it. Data is null if the queue is empty
+// @Tags queues
+// @Produce json
+// @Param priority path string true "Priority as 0-3 or low, normal, high, critical"
+// @Success 200 {object} Response
+// @Failure 400 {object} Response "Invalid priority"
+// @Failure 500 {object} Response "Server error"
+// @Router /api/v1/queues/{priority}/peek [get]
+func (h *Handler) PeekQueueHandler(w http.ResponseWriter, r *http.Request) {
+	value := mux.Vars(r)["priority"]
+
+	priority, ok := queue.PriorityFromName(value)
+	if !ok {
+		var err error
+		priority, err = strconv.Atoi(value)
+		if err != nil || !queue.IsValidPriority(priority) {
+			h.respondWithError(w, http.StatusBadRequest,
+				fmt.Sprintf("Priority must be %d-%d or one of low, normal, high, critical", queue.MinPriority, queue.MaxPriority))
+			return
+		}
+	}
+
+	task, err := h.queue.PeekNext(priority)
+	if err != nil {
+		h.logger.Error("Failed to peek queue: " + err.Error())
+		h.respondWithError(w, http.StatusInternalServerError, "Failed to peek queue")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, Response{
+		Success: true,
+		Data:    task,
+	})
+}
+
+// DrainQueueHandler handles requests to move all tasks of one queue to another
+// @Summary Drain a queue
+// @Description Moves every task of one queue to the back of another, oldest first. Queues are given by priority or by name as in the queue stats. Only available with API key authentication enabled
+// @Tags queues
+// @Accept json
+// @Produce json
+// @Param request body DrainQueueRequest true "Source and target queue"
+// @Success 200 {object} Response
+// @Failure 400 {object} Response "Invalid queue"
+// @Failure 403 {object} Response "API key authentication is disabled"
+// @Failure 500 {object} Response "Server error"
+// @Router /api/v1/queues/drain [post]
+func (h *Handler) DrainQueueHandler(w http.ResponseWriter, r *http.Request) {
+	if len(h.apiKeys) == 0 {
+		h.respondWithError(w, http.StatusForbidden, "Draining queues requires API key authentication")
+		return
+	}
+
+	var req DrainQueueRequest
+	if !h.decodeJSONBody(w, r, &req) {
+		return
+	}
+
+	from, err := queue.ParseQueueName(req.From)
+	if err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "from: "+err.Error())
+		return
+	}
+	to, err := queue.ParseQueueName(req.To)
+	if err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "to: "+err.Error())
+		return
+	}
+	if from == to {
+		h.respondWithError(w, http.StatusBadRequest, "from and to must be different queues")
+		return
+	}
+
+	moved, err := h.queue.DrainQueue(from, to)
+	if err != nil {
+		h.logger.Error(fmt.Sprintf("Failed to drain queue %s after moving %d tasks: %v", from, moved, err))
+		h.respondWithError(w, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to drain queue after moving %d tasks", moved))
+		return
+	}
+
+	h.logger.Info(fmt.Sprintf("Queue %s drained to %s", from, to), map[string]interface{}{
+		"api_key": APIKeyLabel(r.Context()),
+		"moved":   moved,
+	})
+
+	h.respondWithJSON(w, http.StatusOK, Response{
+		Success: true,
+		Data: map[string]interface{}{
+			"from":  from,
+			"to":    to,
+			"moved": moved,
+		},
+	})
+}
+
+// PeekDelayedHandler handles requests to list upcoming delayed tasks
+// @Summary Peek at delayed tasks
+// @Description Lists the delayed tasks that are due next, in order, with the time each will be moved to its queue
+// @Tags queues
+// @Produce json
+// @Param limit query int false "Number of tasks to return (default 20)"
+// @Success 200 {object} Response
+// @Failure 500 {object} Response "Server error"
+// @Router /api/v1/queues/delayed/peek [get]
+func (h *Handler) PeekDelayedHandler(w http.ResponseWriter, r *http.Request) {
+	limit := 20
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		parsedLimit, err := strconv.Atoi(limitStr)
+		if err == nil && parsedLimit > 0 {
+			limit = parsedLimit
+		}
+	}
+
+	tasks, err := h.queue.PeekDelayed(limit)
+	if err != nil {
+		h.logger.Error("Failed to peek delayed tasks: " + err.Error())
+		h.respondWithError(w, http.StatusInternalServerError, "Failed to peek delayed tasks")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, Response{
+		Success: true,
+		Data:    tasks,
+	})
+}
+
+// GetErrorStatsHandler handles requests for the breakdown of task failures
+// @Summary Get error statistics
+// @Description Counts failed task attempts by error category, overall and per job type, over a window rounded up to whole hours
+// @Tags metrics
+// @Produce json
+// @Param window query string false "Window as a duration, at most 168h (default 24h)"
+// @Success 200 {object} Response
+// @Failure 400 {object} Response "Invalid window"
+// @Failure 500 {object} Response "Server error"
+// @Router /api/v1/stats/errors [get]
+func (h *Handler) GetErrorStatsHandler(w http.ResponseWriter, r *http.Request) {
+	window := 24 * time.Hour
+	if windowStr := r.URL.Query().Get("window"); windowStr != "" {
+		parsedWindow, err := time.ParseDuration(windowStr)
+		if err != nil || parsedWindow <= 0 || parsedWindow > queue.ErrorStatsRetention {
+			h.respondWithError(w, http.StatusBadRequest,
+				fmt.Sprintf("Window must be a duration of at most %s", queue.ErrorStatsRetention))
+			return
+		}
+		window = parsedWindow
+	}
+
+	stats, err := h.queue.GetErrorStats(window)
+	if err != nil {
+		h.logger.Error("Failed to get error stats: " + err.Error())
+		h.respondWithError(w, http.StatusInternalServerError, "Failed to get error statistics")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, Response{
+		Success: true,
+		Data:    stats,
+	})
+}
+
+// MetricsSummaryHandler handles aggregated metrics requests
+// @Summary Get metrics summary
+// @Description Gets job counters, queue depths and live workers across all instances as JSON
+// @Tags metrics
+// @Produce json
+// @Success 200 {object} Response
+// @Failure 500 {object} Response "Server error"
+// @Router /api/v1/metrics/summary [get]
+func (h *Handler) MetricsSummaryHandler(w http.ResponseWriter, r *http.Request) {
+	queueDepths, err := h.queue.GetQueueStats()
+	if err != nil {
+		h.logger.Error("Failed to get queue stats: " + err.Error())
+		h.respondWithError(w, http.StatusInternalServerError, "Failed to get metrics summary")
+		return
+	}
+
+	// Workers run in other processes, so their counts are read from Redis
+	// rather than from this process's collector
+	statusCounts, err := h.queue.GetStatusCounts()
+	if err != nil {
+		h.logger.Error("Failed to get job status counts: " + err.Error())
+		h.respondWithError(w, http.StatusInternalServerError, "Failed to get metrics summary")
+		return
+	}
+
+	liveWorkers, err := h.queue.LiveWorkers(queue.WorkerHeartbeatTTL)
+	if err != nil {
+		h.logger.Error("Failed to count live workers: " + err.Error())
+		h.respondWithError(w, http.StatusInternalServerError, "Failed to get metrics summary")
+		return
+	}
+
+	completed := statusCounts[string(queue.StatusCompleted)]
+	failed := statusCounts[string(queue.StatusFailed)]
+
+	h.respondWithJSON(w, http.StatusOK, Response{
+		Success: true,
+		Data: map[string]interface{}{
+			"submitted":      h.metrics.Summary().Submitted,
+			"processed":      completed + failed,
+			"completed":      completed,
+			"failed":         failed,
+			"active_workers": liveWorkers,
+			"queue_depths":   queueDepths,
+		},
+	})
+}
+
+// CreateWorkflowHandler handles workflow creation requests
+// @Summary Create a new workflow
+// @Description Creates a new job workflow
+// @Tags workflows
+// @Accept json
+// @Produce json
+// @Param workflow body object true "Workflow details"
+// @Success 200 {object} Response
+// @Failure 400 {object} Response "Invalid request"
+// @Failure 413 {object} Response "Payload too large"
+// @Failure 429 {object} Response "Submission rate limit exceeded"
+// @Failure 500 {object} Response "Server error"
+// @Router /api/v1/workflows [post]
+func (h *Handler) CreateWorkflowHandler(w http.ResponseWriter, r *http.Request) {
+	var req CreateWorkflowRequest
+	if !h.decodeJSONBody(w, r, &req) {
+		return
+	}
+
+	// Build and validate workflow
+	workflow := buildWorkflow(&req)
+	if err := workflow.Validate(); err != nil {
+		var validationErrors job.ValidationErrors
+		if errors.As(err, &validationErrors) {
+			h.respondWithValidationErrors(w, http.StatusBadRequest, validationErrors)
+		} else {
+			h.respondWithError(w, http.StatusBadRequest, err.Error())
+		}
+		return
+	}
+
+	// Save workflow
+	if err := h.workflowManager.SaveWorkflow(workflow); err != nil {
+		h.logger.Error("Failed to save workflow: " + err.Error())
+		h.respondWithError(w, http.StatusInternalServerError, "Failed to create workflow")
+		return
+	}
+
+	h.logger.Info(fmt.Sprintf("Workflow %s created successfully with %d steps", workflow.ID, len(workflow.Steps)))
+
+	h.respondWithJSON(w, http.StatusOK, Response{
+		Success: true,
+		Data: map[string]string{
+			"workflow_id": workflow.ID,
+		},
+	})
+}
+
+// ValidateWorkflowHandler handles workflow dry-run validation requests
+// @Summary Validate a workflow
+// @Description Runs the same validation as workflow creation without saving anything
+// @Tags workflows
+// @Accept json
+// @Produce json
+// @Param workflow body object true "Workflow details"
+// @Success 200 {object} Response
+// @Failure 400 {object} Response "Invalid request"
+// @Failure 413 {object} Response "Payload too large"
+// @Router /api/v1/workflows/validate [post]
+func (h *Handler) ValidateWorkflowHandler(w http.ResponseWriter, r *http.Request) {
+	var req CreateWorkflowRequest
+	if !h.decodeJSONBody(w, r, &req) {
+		return
+	}
+
+	workflow := buildWorkflow(&req)
+
+	validationErrors := job.ValidationErrors{}
+	if err := workflow.Validate(); err != nil {
+		if !errors.As(err, &validationErrors) {
+			h.respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	h.respondWithJSON(w, http.StatusOK, Response{
+		Success: true,
+		Data: map[string]interface{}{
+			"valid":  len(validationErrors) == 0,
+			"errors": validationErrors,
+		},
+	})
+}
+
+// buildWorkflow creates an unsaved workflow from a creation request
+func buildWorkflow(req *CreateWorkflowRequest) *job.Workflow {
+	workflow := job.NewWorkflow(req.Name)
+
+	if req.Metadata != nil {
+		workflow.Metadata = req.Metadata
+	}
+
+	// Add steps, keeping client-supplied IDs so DependsOn can reference them
+	for _, stepInput := range req.Steps {
+		var stepID string
+		if stepInput.ID != "" {
+			stepID = workflow.AddStepWithID(stepInput.ID, stepInput.JobType, stepInput.Params, stepInput.DependsOn)
+		} else {
+			stepID = workflow.AddStep(stepInput.JobType, stepInput.Params, stepInput.DependsOn)
+		}
+		workflow.Steps[stepID].Condition = stepInput.Condition
+	}
+
+	return workflow
+}
+
+// GetWorkflowHandler handles workflow retrieval requests
+// @Summary Get workflow details
+// @Description Gets the details of a workflow
+// @Tags workflows
+// @Produce json
+// @Param id path string true "Workflow ID"
+// @Success 200 {object} Response
+// @Failure 404 {object} Response "Workflow not found"
+// @Failure 500 {object} Response "Server error"
+// @Router /api/v1/workflows/{id} [get]
+func (h *Handler) GetWorkflowHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	workflowID := vars["id"]
+
+	workflow, err := h.workflowManager.GetWorkflow(workflowID)
+
+	if err != nil {
+		if err.Error() == fmt.Sprintf("workflow %s not found", workflowID) {
+			h.respondWithError(w, http.StatusNotFound, "Workflow not found")
+			return
+		}
+
+		h.logger.Error("Failed to get workflow: " + err.Error())
+		h.respondWithError(w, http.StatusInternalServerError, "Failed to get workflow")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, Response{
+		Success: true,
+		Data:    workflow,
+	})
+}
+
+// GetWorkflowStepsHandler handles workflow progress requests
+// @Summary Get workflow step statuses
+// @Description Gets the status of a workflow and of each of its steps, without params or results, for polling progress
+// @Tags workflows
+// @Produce json
+// @Param id path string true "Workflow ID"
+// @Success 200 {object} Response
+// @Failure 404 {object} Response "Workflow not found"
+// @Failure 500 {object} Response "Server error"
+// @Router /api/v1/workflows/{id}/steps [get]
+func (h *Handler) GetWorkflowStepsHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	workflowID := vars["id"]
+
+	progress, err := h.workflowManager.GetWorkflowProgress(workflowID)
+
+	if err != nil {
+		if err.Error() == fmt.Sprintf("workflow %s not found", workflowID) {
+			h.respondWithError(w, http.StatusNotFound, "Workflow not found")
+			return
+		}
+
+		h.logger.Error("Failed to get workflow steps: " + err.Error())
+		h.respondWithError(w, http.StatusInternalServerError, "Failed to get workflow steps")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, Response{
+		Success: true,
+		Data:    progress,
+	})
+}
+
+// ListWorkflowsHandler handles workflow listing requests
+// @Summary List workflows
+// @Description Lists workflows with pagination
+// @Tags workflows
+// @Produce json
+// @Param limit query int false "Number of workflows to return (default 20)"
+// @Param offset query int false "Offset for pagination (default 0)"
+// @Success 200 {object} Response
+// @Failure 500 {object} Response "Server error"
+// @Router /api/v1/workflows [get]
+func (h *Handler) ListWorkflowsHandler(w http.ResponseWriter, r *http.Request) {
+	limit, offset := parsePagination(r)
+
+	workflows, err := h.workflowManager.ListWorkflows(limit, offset)
 
 	if err != nil {
 		h.logger.Error("Failed to list workflows: " + err.Error())
@@ -388,6 +1293,112 @@ func (h *Handler) ListWorkflowsHandler(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// ListArchivedWorkflowsHandler handles archived workflow listing requests
+// @Summary List archived workflows
+// @Description Lists finished workflows kept in the archive, most recently finished first
+// @Tags workflows
+// @Produce json
+// @Param limit query int false "Number of workflows to return (default 20)"
+// @Param offset query int false "Offset for pagination (default 0)"
+// @Success 200 {object} Response
+// @Failure 500 {object} Response "Server error"
+// @Router /api/v1/workflows/archived [get]
+func (h *Handler) ListArchivedWorkflowsHandler(w http.ResponseWriter, r *http.Request) {
+	limit, offset := parsePagination(r)
+
+	workflows, err := h.workflowManager.ListArchivedWorkflows(limit, offset)
+	if err != nil {
+		h.logger.Error("Failed to list archived workflows: " + err.Error())
+		h.respondWithError(w, http.StatusInternalServerError, "Failed to list archived workflows")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, Response{
+		Success: true,
+		Data:    workflows,
+	})
+}
+
+// parsePagination reads the limit and offset query parameters, defaulting to
+// the first 20 items
+func parsePagination(r *http.Request) (int, int) {
+	limit := 20
+	offset := 0
+
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		parsedLimit, err := strconv.Atoi(limitStr)
+		if err == nil && parsedLimit > 0 {
+			limit = parsedLimit
+		}
+	}
+
+	if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
+		parsedOffset, err := strconv.Atoi(offsetStr)
+		if err == nil && parsedOffset >= 0 {
+			offset = parsedOffset
+		}
+	}
+
+	return limit, offset
+}
+
+// CancelWorkflowHandler handles workflow cancellation requests
+// @Summary Cancel a workflow
+// @Description Cancels a pending or running workflow. Pending steps are skipped and running step tasks are signalled to stop
+// @Tags workflows
+// @Produce json
+// @Param id path string true "Workflow ID"
+// @Success 200 {object} Response
+// @Failure 400 {object} Response "Workflow already finished"
+// @Failure 404 {object} Response "Workflow not found"
+// @Failure 500 {object} Response "Server error"
+// @Router /api/v1/workflows/{id}/cancel [post]
+func (h *Handler) CancelWorkflowHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	workflowID := vars["id"]
+
+	workflow, err := h.workflowManager.GetWorkflow(workflowID)
+	if err != nil {
+		if err.Error() == fmt.Sprintf("workflow %s not found", workflowID) {
+			h.respondWithError(w, http.StatusNotFound, "Workflow not found")
+			return
+		}
+
+		h.logger.Error("Failed to get workflow: " + err.Error())
+		h.respondWithError(w, http.StatusInternalServerError, "Failed to cancel workflow")
+		return
+	}
+
+	if workflow.IsTerminal() {
+		h.respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Workflow is already %s", workflow.Status))
+		return
+	}
+
+	taskIDs, err := h.workflowManager.CancelWorkflow(workflowID)
+	if err != nil {
+		h.logger.Error("Failed to cancel workflow: " + err.Error())
+		h.respondWithError(w, http.StatusInternalServerError, "Failed to cancel workflow")
+		return
+	}
+
+	// Signal running step tasks to stop
+	for _, taskID := range taskIDs {
+		if err := h.queue.CancelTask(taskID); err != nil {
+			h.logger.Error(fmt.Sprintf("Failed to cancel task %s of workflow %s: %v", taskID, workflowID, err))
+		}
+	}
+
+	h.logger.Info(fmt.Sprintf("Workflow %s cancelled successfully", workflowID))
+
+	h.respondWithJSON(w, http.StatusOK, Response{
+		Success: true,
+		Data: map[string]interface{}{
+			"status":          job.WorkflowStatusCancelled,
+			"cancelled_tasks": taskIDs,
+		},
+	})
+}
+
 // DeleteWorkflowHandler handles workflow deletion requests
 // @Summary Delete a workflow
 // @Description Deletes a workflow and its data
@@ -463,6 +1474,134 @@ func (h *Handler) HealthCheckHandler(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// LivenessHandler handles liveness probes
+// @Summary Liveness probe
+// @Description Reports that the API process is up and serving requests. It does not check Redis, so a Redis outage does not get the pod restarted
+// @Tags health
+// @Produce json
+// @Success 200 {object} Response
+// @Router /livez [get]
+func (h *Handler) LivenessHandler(w http.ResponseWriter, r *http.Request) {
+	h.respondWithJSON(w, http.StatusOK, Response{
+		Success: true,
+		Data: map[string]string{
+			"status": "alive",
+		},
+	})
+}
+
+// ReadinessHandler handles readiness probes
+// @Summary Readiness probe
+// @Description Checks that Redis is reachable, so the API can serve traffic. Returns 503 otherwise
+// @Tags health
+// @Produce json
+// @Success 200 {object} Response
+// @Failure 503 {object} Response "Not ready"
+// @Router /readyz [get]
+func (h *Handler) ReadinessHandler(w http.ResponseWriter, r *http.Request) {
+	if err := h.queue.Ping(); err != nil {
+		h.logger.Error("Readiness check failed: " + err.Error())
+		h.respondWithJSON(w, http.StatusServiceUnavailable, Response{
+			Success: false,
+			Error:   "Redis unavailable: " + err.Error(),
+		})
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, Response{
+		Success: true,
+		Data: map[string]string{
+			"status": "ready",
+			"redis":  "ok",
+		},
+	})
+}
+
+// delayedProcessorStaleAfter is how long without a delayed processor run before
+// the detailed health check reports it as stale
+const delayedProcessorStaleAfter = time.Minute
+
+// DetailedHealthCheckHandler handles readiness checks that also cover the workers
+// @Summary Detailed health check
+// @Description Checks Redis, live workers (from heartbeats), the delayed job processor and the queue backlog. Returns 503 when no workers are alive
+// @Tags health
+// @Produce json
+// @Success 200 {object} Response
+// @Failure 503 {object} Response "Service unhealthy"
+// @Router /health/detailed [get]
+func (h *Handler) DetailedHealthCheckHandler(w http.ResponseWriter, r *http.Request) {
+	unhealthy := func(err error) {
+		h.logger.Error("Detailed health check failed: " + err.Error())
+		h.respondWithJSON(w, http.StatusServiceUnavailable, Response{
+			Success: false,
+			Error:   "Service unhealthy: " + err.Error(),
+		})
+	}
+
+	stats, err := h.queue.GetQueueStats()
+	if err != nil {
+		unhealthy(err)
+		return
+	}
+
+	var backlog int64
+	for name, value := range stats {
+		if depth, ok := value.(int64); ok && name != queue.DeadLetterQueue && name != queue.DelayedTasksKey {
+			backlog += depth
+		}
+	}
+
+	liveWorkers, err := h.queue.LiveWorkers(queue.WorkerHeartbeatTTL)
+	if err != nil {
+		unhealthy(err)
+		return
+	}
+
+	oldestTaskAge, err := h.queue.OldestTaskAge()
+	if err != nil {
+		unhealthy(err)
+		return
+	}
+
+	lastRun, err := h.queue.DelayedProcessorLastRun()
+	if err != nil {
+		unhealthy(err)
+		return
+	}
+
+	delayedProcessorHealthy := !lastRun.IsZero() && time.Since(lastRun) < delayedProcessorStaleAfter
+
+	status := "healthy"
+	code := http.StatusOK
+	switch {
+	case liveWorkers == 0:
+		// Nothing drains the queue, so any backlog only grows
+		status = "unhealthy"
+		code = http.StatusServiceUnavailable
+	case !delayedProcessorHealthy:
+		status = "degraded"
+	}
+
+	data := map[string]interface{}{
+		"status":                    status,
+		"redis":                     "ok",
+		"live_workers":              liveWorkers,
+		"delayed_processor_healthy": delayedProcessorHealthy,
+		"backlog":                   backlog,
+		"oldest_task_age_seconds":   oldestTaskAge.Seconds(),
+	}
+	if !lastRun.IsZero() {
+		data["delayed_processor_last_run"] = lastRun
+	}
+
+	response := Response{Success: code == http.StatusOK, Data: data}
+	if code != http.StatusOK {
+		response.Error = "No live workers"
+	}
+
+	h.respondWithJSON(w, code, response)
+}
+
 // Helper to respond with JSON
 func (h *Handler) respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
 	response, _ := json.Marshal(payload)
@@ -472,6 +1611,95 @@ func (h *Handler) respondWithJSON(w http.ResponseWriter, code int, payload inter
 	w.Write(response)
 }
 
+// bufferSubmission stores a job that could not be published in the submit buffer
+func (h *Handler) bufferSubmission(w http.ResponseWriter, task *queue.Task, delaySeconds int, unique bool, publishErr error) {
+	if err := h.submitBuffer.Add(task, delaySeconds, unique); err != nil {
+		h.logger.Error(fmt.Sprintf("Failed to buffer job %s after publish error %v: %v", task.ID, publishErr, err))
+		h.respondWithError(w, http.StatusServiceUnavailable, "Queue unavailable, try again later")
+		return
+	}
+
+	h.metrics.IncrementJobCounter("buffered")
+	h.logger.Info(fmt.Sprintf("Redis unavailable (%v), buffered job %s locally", publishErr, task.ID))
+
+	h.respondWithJSON(w, http.StatusAccepted, Response{
+		Success: true,
+		Data: map[string]string{
+			"job_id":      task.ID,
+			"status":      "buffered",
+			"status_url":  jobStatusPath(task.ID),
+			"updates_url": JobUpdatesPath(task.ID),
+		},
+	})
+}
+
+// parsePriority parses a submitted priority, which may be a number in the
+// supported range or a name ("low", "normal", "high", "critical"). An omitted
+// priority defaults to normal.
+func parsePriority(raw json.RawMessage) (int, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return queue.PriorityNormal, nil
+	}
+
+	var name string
+	if err := json.Unmarshal(raw, &name); err == nil {
+		priority, ok := queue.PriorityFromName(name)
+		if !ok {
+			return 0, fmt.Errorf("Unknown priority %q, use low, normal, high or critical", name)
+		}
+		return priority, nil
+	}
+
+	var priority int
+	if err := json.Unmarshal(raw, &priority); err != nil || !queue.IsValidPriority(priority) {
+		return 0, fmt.Errorf("Priority must be between %d and %d or a priority name", queue.MinPriority, queue.MaxPriority)
+	}
+	return priority, nil
+}
+
+// isValidCallbackURL reports whether a callback URL is an absolute http(s) URL
+func isValidCallbackURL(callbackURL string) bool {
+	parsed, err := url.Parse(callbackURL)
+	if err != nil {
+		return false
+	}
+	return (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
+
+// decodeJSONBody decodes a size-limited JSON request body into v. On failure it
+// writes a 413 or 400 response and returns false.
+func (h *Handler) decodeJSONBody(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	return h.decodeBody(w, r, v, false)
+}
+
+// decodeOptionalJSONBody is like decodeJSONBody, but leaves v unchanged if the
+// body is empty
+func (h *Handler) decodeOptionalJSONBody(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	return h.decodeBody(w, r, v, true)
+}
+
+func (h *Handler) decodeBody(w http.ResponseWriter, r *http.Request, v interface{}, optional bool) bool {
+	if h.maxBodySize > 0 {
+		r.Body = http.MaxBytesReader(w, r.Body, h.maxBodySize)
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		if optional && err == io.EOF {
+			return true
+		}
+
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			h.respondWithError(w, http.StatusRequestEntityTooLarge,
+				fmt.Sprintf("Request body exceeds %d bytes", maxBytesErr.Limit))
+			return false
+		}
+		h.respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return false
+	}
+	return true
+}
+
 // Helper to respond with an error
 func (h *Handler) respondWithError(w http.ResponseWriter, code int, message string) {
 	h.metrics.IncrementErrorCounter(fmt.Sprintf("api_%d", code))
@@ -480,3 +1708,14 @@ func (h *Handler) respondWithError(w http.ResponseWriter, code int, message stri
 		Error:   message,
 	})
 }
+
+// respondWithValidationErrors sends validation problems both as one message in
+// error, for existing clients, and field by field in errors
+func (h *Handler) respondWithValidationErrors(w http.ResponseWriter, code int, errs job.ValidationErrors) {
+	h.metrics.IncrementErrorCounter(fmt.Sprintf("api_%d", code))
+	h.respondWithJSON(w, code, Response{
+		Success: false,
+		Error:   errs.Error(),
+		Errors:  errs,
+	})
+}
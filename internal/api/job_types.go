@@ -0,0 +1,53 @@
+// internal/api/job_types.go
+package api
+
+import (
+	"fmt"
+
+	"BoltQ/internal/queue"
+)
+
+// SetAllowedJobTypes restricts job submissions to the given job types, so that
+// jobs nobody can process are rejected instead of being dead-lettered later.
+// With no job types, submissions of any type are accepted.
+func (h *Handler) SetAllowedJobTypes(jobTypes ...string) {
+	if len(jobTypes) == 0 {
+		h.allowedJobTypes = nil
+		return
+	}
+
+	h.allowedJobTypes = make(map[string]bool, len(jobTypes))
+	for _, jobType := range jobTypes {
+		h.allowedJobTypes[jobType] = true
+	}
+}
+
+// RequireRegisteredJobTypes restricts job submissions to the job types that a
+// live worker pool has a processor for, as published with its heartbeat. Jobs
+// are then rejected while no worker of their type is running.
+func (h *Handler) RequireRegisteredJobTypes(require bool) {
+	h.requireRegistered = require
+}
+
+// checkJobType returns why a job type may not be submitted, or "" if it may
+func (h *Handler) checkJobType(jobType string) (string, error) {
+	if jobType == "" {
+		return "", nil
+	}
+
+	if h.allowedJobTypes != nil && !h.allowedJobTypes[jobType] {
+		return fmt.Sprintf("Job type %s is not accepted", jobType), nil
+	}
+
+	if h.requireRegistered {
+		registered, err := h.queue.IsJobTypeRegistered(jobType, queue.WorkerHeartbeatTTL)
+		if err != nil {
+			return "", err
+		}
+		if !registered {
+			return fmt.Sprintf("No running worker has a processor for job type %s", jobType), nil
+		}
+	}
+
+	return "", nil
+}
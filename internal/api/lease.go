@@ -0,0 +1,329 @@
+// internal/api/lease.go
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"BoltQ/internal/job"
+	"BoltQ/internal/queue"
+	"BoltQ/internal/worker"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/gorilla/mux"
+)
+
+// MaxLeaseWait is the longest a consumer may wait for a job in one request
+const MaxLeaseWait = time.Minute
+
+// leasePollInterval is how often a waiting consumer's request checks the queues
+const leasePollInterval = 500 * time.Millisecond
+
+// LeasedJob is a job handed out to a consumer over HTTP
+type LeasedJob struct {
+	Job            *queue.Task `json:"job"`
+	LeaseExpiresAt time.Time   `json:"lease_expires_at"`
+	CompleteURL    string      `json:"complete_url"`
+	FailURL        string      `json:"fail_url"`
+}
+
+// CompleteJobRequest reports that a leased job succeeded
+type CompleteJobRequest struct {
+	Result map[string]interface{} `json:"result,omitempty"`
+}
+
+// FailJobRequest reports that a leased job failed. Setting Retry to false
+// dead-letters the job right away instead of retrying it.
+type FailJobRequest struct {
+	Error string `json:"error"`
+	Retry *bool  `json:"retry,omitempty"`
+}
+
+// consumerError is a job failure reported by a consumer over HTTP
+type consumerError struct {
+	message string
+	final   bool
+}
+
+func (e *consumerError) Error() string {
+	return e.message
+}
+
+// EnableHTTPConsumers lets processes that can't talk to Redis consume jobs
+// over HTTP. Each job handed out is leased for leaseTimeout; a job that is
+// neither completed nor failed in time is requeued by the delayed job
+// processor. Failures are retried or dead-lettered by errorHandler, and
+// callbacks are sent by callbacks, as they would be by a worker.
+func (h *Handler) EnableHTTPConsumers(leaseTimeout time.Duration, errorHandler *worker.ErrorHandler,
+	callbacks *worker.CallbackNotifier) {
+	errorHandler.AddErrorRule(func(err error) bool {
+		var consumerErr *consumerError
+		return errors.As(err, &consumerErr) && consumerErr.final
+	}, worker.DataError)
+
+	h.leaseTimeout = leaseTimeout
+	h.errorHandler = errorHandler
+	h.callbacks = callbacks
+}
+
+// NextJobHandler hands the next job to a consumer over HTTP
+// @Summary Lease the next job
+// @Description Waits up to wait for the next untagged job of one of the given types and leases it to the caller, who must complete or fail it before the lease expires. Data is null if no job became available
+// @Tags jobs
+// @Produce json
+// @Param types query string false "Comma-separated job types, any type if omitted"
+// @Param wait query string false "How long to wait for a job, e.g. 30s, at most 1m"
+// @Success 200 {object} Response
+// @Failure 400 {object} Response "Invalid wait"
+// @Failure 404 {object} Response "HTTP consumers are disabled"
+// @Failure 500 {object} Response "Server error"
+// @Router /api/v1/jobs/next [get]
+func (h *Handler) NextJobHandler(w http.ResponseWriter, r *http.Request) {
+	if h.leaseTimeout <= 0 {
+		h.respondWithError(w, http.StatusNotFound, "HTTP consumers are disabled")
+		return
+	}
+
+	var jobTypes []string
+	for _, jobType := range strings.Split(r.URL.Query().Get("types"), ",") {
+		if jobType = strings.TrimSpace(jobType); jobType != "" {
+			jobTypes = append(jobTypes, jobType)
+		}
+	}
+
+	var wait time.Duration
+	if value := r.URL.Query().Get("wait"); value != "" {
+		var err error
+		wait, err = time.ParseDuration(value)
+		if err != nil || wait < 0 || wait > MaxLeaseWait {
+			h.respondWithError(w, http.StatusBadRequest,
+				fmt.Sprintf("wait must be a duration of at most %s, e.g. 30s", MaxLeaseWait))
+			return
+		}
+	}
+
+	// Keep the response writable past the server's write timeout while waiting
+	if wait > 0 {
+		if err := http.NewResponseController(w).SetWriteDeadline(time.Now().Add(wait + 15*time.Second)); err != nil {
+			h.logger.Debug("Failed to extend write deadline of long poll: " + err.Error())
+		}
+	}
+
+	deadline := time.Now().Add(wait)
+	for {
+		task, err := h.queue.Lease(jobTypes, h.leaseTimeout)
+		if err == nil {
+			h.logger.Info(fmt.Sprintf("Job %s of type %s leased over HTTP", task.ID, task.Type), map[string]interface{}{
+				"api_key": APIKeyLabel(r.Context()),
+			})
+			h.publishJobUpdate(task.ID, "running", map[string]interface{}{"consumer": "http"})
+			h.respondWithJSON(w, http.StatusOK, Response{
+				Success: true,
+				Data: LeasedJob{
+					Job:            task,
+					LeaseExpiresAt: time.Now().Add(h.leaseTimeout),
+					CompleteURL:    jobStatusPath(task.ID) + "/complete",
+					FailURL:        jobStatusPath(task.ID) + "/fail",
+				},
+			})
+			return
+		}
+		if err != redis.Nil {
+			h.logger.Error("Failed to lease job: " + err.Error())
+			h.respondWithError(w, http.StatusInternalServerError, "Failed to lease job")
+			return
+		}
+
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			h.respondWithJSON(w, http.StatusOK, Response{Success: true})
+			return
+		}
+		if remaining > leasePollInterval {
+			remaining = leasePollInterval
+		}
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-time.After(remaining):
+		}
+	}
+}
+
+// CompleteJobHandler records the result of a job leased over HTTP
+// @Summary Complete a leased job
+// @Description Completes a job leased with /jobs/next, storing its result
+// @Tags jobs
+// @Accept json
+// @Produce json
+// @Param id path string true "Job ID"
+// @Param request body CompleteJobRequest false "Job result"
+// @Success 200 {object} Response
+// @Failure 404 {object} Response "HTTP consumers are disabled or job not found"
+// @Failure 409 {object} Response "Job is not leased"
+// @Failure 500 {object} Response "Server error"
+// @Router /api/v1/jobs/{id}/complete [post]
+func (h *Handler) CompleteJobHandler(w http.ResponseWriter, r *http.Request) {
+	if h.leaseTimeout <= 0 {
+		h.respondWithError(w, http.StatusNotFound, "HTTP consumers are disabled")
+		return
+	}
+
+	var req CompleteJobRequest
+	if r.ContentLength != 0 && !h.decodeJSONBody(w, r, &req) {
+		return
+	}
+
+	task, ok := h.releaseLeasedJob(w, mux.Vars(r)["id"])
+	if !ok {
+		return
+	}
+
+	task.Status = "completed"
+	if req.Result != nil {
+		if task.Data == nil {
+			task.Data = make(map[string]interface{})
+		}
+		task.Data["result"] = req.Result
+	}
+
+	if err := h.queue.UpdateStatus(task); err != nil {
+		if errors.Is(err, queue.ErrInvalidTransition) {
+			h.respondWithError(w, http.StatusConflict, "Job can no longer be completed")
+			return
+		}
+		h.logger.Error(fmt.Sprintf("Failed to complete job %s: %v", task.ID, err))
+		h.respondWithError(w, http.StatusInternalServerError, "Failed to complete job")
+		return
+	}
+
+	h.completeWorkflowStep(task, req.Result, nil)
+	if h.callbacks != nil {
+		h.callbacks.Notify(task, req.Result, nil)
+	}
+	h.metrics.IncrementJobCounter("completed")
+	h.metrics.RecordJobLatency(task.Type, task.Latency().Seconds())
+	h.publishJobUpdate(task.ID, "completed", map[string]interface{}{"result": req.Result})
+
+	h.respondWithJSON(w, http.StatusOK, Response{
+		Success: true,
+		Data: map[string]string{
+			"job_id": task.ID,
+			"status": task.Status,
+		},
+	})
+}
+
+// FailJobHandler records the failure of a job leased over HTTP
+// @Summary Fail a leased job
+// @Description Fails a job leased with /jobs/next. It is retried or dead-lettered like a job failed by a worker, or dead-lettered right away if retry is false
+// @Tags jobs
+// @Accept json
+// @Produce json
+// @Param id path string true "Job ID"
+// @Param request body FailJobRequest true "Job error"
+// @Success 200 {object} Response
+// @Failure 400 {object} Response "Missing error"
+// @Failure 404 {object} Response "HTTP consumers are disabled or job not found"
+// @Failure 409 {object} Response "Job is not leased"
+// @Failure 500 {object} Response "Server error"
+// @Router /api/v1/jobs/{id}/fail [post]
+func (h *Handler) FailJobHandler(w http.ResponseWriter, r *http.Request) {
+	if h.leaseTimeout <= 0 {
+		h.respondWithError(w, http.StatusNotFound, "HTTP consumers are disabled")
+		return
+	}
+
+	var req FailJobRequest
+	if !h.decodeJSONBody(w, r, &req) {
+		return
+	}
+	if strings.TrimSpace(req.Error) == "" {
+		h.respondWithError(w, http.StatusBadRequest, "error is required")
+		return
+	}
+
+	task, ok := h.releaseLeasedJob(w, mux.Vars(r)["id"])
+	if !ok {
+		return
+	}
+
+	jobErr := &consumerError{message: req.Error, final: req.Retry != nil && !*req.Retry}
+	if err := h.errorHandler.HandleJobError(task, jobErr); err != nil {
+		h.logger.Error(fmt.Sprintf("Failed to handle failure of job %s: %v", task.ID, err))
+		h.respondWithError(w, http.StatusInternalServerError, "Failed to fail job")
+		return
+	}
+	h.metrics.IncrementJobCounter("failed")
+
+	// A dead-lettered job has failed for good
+	if task.Status == "failed" {
+		h.completeWorkflowStep(task, nil, jobErr)
+		if h.callbacks != nil {
+			h.callbacks.Notify(task, nil, jobErr)
+		}
+	}
+	h.publishJobUpdate(task.ID, "failed", map[string]interface{}{"error": req.Error})
+
+	h.respondWithJSON(w, http.StatusOK, Response{
+		Success: true,
+		Data: map[string]string{
+			"job_id": task.ID,
+			"status": task.Status,
+		},
+	})
+}
+
+// releaseLeasedJob ends the lease of a job and returns the job. If the job is
+// not leased or can't be loaded, it writes an error response and returns false.
+func (h *Handler) releaseLeasedJob(w http.ResponseWriter, jobID string) (*queue.Task, bool) {
+	if err := h.queue.ReleaseLease(jobID); err != nil {
+		if errors.Is(err, queue.ErrLeaseNotHeld) {
+			h.respondWithError(w, http.StatusConflict, "Job is not leased, its lease may have expired")
+			return nil, false
+		}
+		h.logger.Error(fmt.Sprintf("Failed to release lease of job %s: %v", jobID, err))
+		h.respondWithError(w, http.StatusInternalServerError, "Failed to release job lease")
+		return nil, false
+	}
+
+	task, err := h.queue.GetTaskStatus(jobID)
+	if err != nil {
+		if err.Error() == "task not found" {
+			h.respondWithError(w, http.StatusNotFound, "Job not found")
+			return nil, false
+		}
+		h.logger.Error(fmt.Sprintf("Failed to get leased job %s: %v", jobID, err))
+		h.respondWithError(w, http.StatusInternalServerError, "Failed to get job")
+		return nil, false
+	}
+
+	return task, true
+}
+
+// completeWorkflowStep reports the outcome of a job to its workflow, if it belongs to one
+func (h *Handler) completeWorkflowStep(task *queue.Task, result map[string]interface{}, jobErr error) {
+	workflowID, _ := task.Data[job.WorkflowIDKey].(string)
+	stepID, _ := task.Data[job.WorkflowStepIDKey].(string)
+	if workflowID == "" || stepID == "" {
+		return
+	}
+
+	if err := h.workflowManager.CompleteStep(workflowID, stepID, result, jobErr); err != nil {
+		h.logger.Error(fmt.Sprintf("Error completing step %s of workflow %s: %v", stepID, workflowID, err))
+	}
+}
+
+// publishJobUpdate tells WebSocket clients about a job, if updates are enabled
+func (h *Handler) publishJobUpdate(jobID, status string, data map[string]interface{}) {
+	if h.jobUpdates == nil {
+		return
+	}
+	if err := h.jobUpdates.PublishJobUpdate(jobID, status, data); err != nil {
+		h.logger.Error(fmt.Sprintf("Failed to publish update for job %s: %v", jobID, err))
+	}
+}
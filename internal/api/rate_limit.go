@@ -0,0 +1,107 @@
+// internal/api/rate_limit.go
+package api
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"BoltQ/internal/queue"
+)
+
+// SetSubmitRateLimits limits how fast each client may submit jobs and
+// workflows. Clients are identified by the label of their API key, or by IP
+// address when their key has no label or authentication is disabled. Limits
+// map labels or IP addresses to their own limit; other clients get the
+// default limit. Buckets are kept in Redis, so the limits hold across API
+// instances.
+func (h *Handler) SetSubmitRateLimits(defaultLimit queue.RateLimit, limits map[string]queue.RateLimit) {
+	h.submitRateLimit = defaultLimit
+	h.clientRateLimits = limits
+}
+
+// ParseClientRateLimits parses a comma-separated list of client=limit pairs,
+// where the client is an API key label or an IP address and the limit is
+// written as ParseRateLimit accepts it, e.g. "billing=5/10,10.0.0.7=1"
+func ParseClientRateLimits(value string) (map[string]queue.RateLimit, error) {
+	limits := make(map[string]queue.RateLimit)
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		client, limitStr, ok := strings.Cut(entry, "=")
+		client = strings.TrimSpace(client)
+		if !ok || client == "" {
+			return nil, fmt.Errorf("invalid client rate limit %q, expected client=limit", entry)
+		}
+
+		limit, err := queue.ParseRateLimit(limitStr)
+		if err != nil {
+			return nil, err
+		}
+		limits[client] = limit
+	}
+	return limits, nil
+}
+
+// submitRateLimited rejects submissions from clients that have exceeded their
+// rate limit with 429 and a Retry-After header. Submissions are let through if
+// Redis can't be asked.
+func (h *Handler) submitRateLimited(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		client, name := rateLimitClient(r)
+
+		limit, ok := h.clientRateLimits[name]
+		if !ok {
+			limit = h.submitRateLimit
+		}
+		if limit.Unlimited() {
+			next(w, r)
+			return
+		}
+
+		allowed, retryAfter, err := h.queue.TakeRateLimitToken(client, limit)
+		if err != nil {
+			h.logger.Error("Failed to check submission rate limit: " + err.Error())
+			next(w, r)
+			return
+		}
+
+		if !allowed {
+			seconds := int(math.Ceil(retryAfter.Seconds()))
+			if seconds < 1 {
+				seconds = 1
+			}
+			h.logger.Info("Rejected submission over rate limit", map[string]interface{}{
+				"client": name,
+				"path":   r.URL.Path,
+				"limit":  limit.String(),
+			})
+			w.Header().Set("Retry-After", strconv.Itoa(seconds))
+			h.respondWithError(w, http.StatusTooManyRequests,
+				fmt.Sprintf("Submission rate limit exceeded, retry in %d seconds", seconds))
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// rateLimitClient returns the bucket name of the client making a request and
+// the label or IP address its limit is configured under
+func rateLimitClient(r *http.Request) (string, string) {
+	if label := APIKeyLabel(r.Context()); label != "" {
+		return "key:" + label, label
+	}
+
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+	return "ip:" + ip, ip
+}
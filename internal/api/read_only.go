@@ -0,0 +1,109 @@
+// internal/api/read_only.go
+package api
+
+import (
+	"net/http"
+)
+
+// SetReadOnly sets whether the API starts out in read-only mode. While read
+// only, reads are served but requests that change jobs, queues or workflows
+// are rejected with 503. The mode can be switched at runtime for all API
+// instances through PUT /api/v1/admin/read-only, which overrides this default.
+func (h *Handler) SetReadOnly(enabled bool) {
+	h.readOnly = enabled
+}
+
+// ReadOnlyRequest is the request body for switching read-only mode
+type ReadOnlyRequest struct {
+	Enabled *bool `json:"enabled"`
+}
+
+// isReadOnly reports whether the API is in read-only mode. The configured
+// default applies if the mode wasn't set at runtime or Redis can't be asked.
+func (h *Handler) isReadOnly() bool {
+	enabled, ok, err := h.queue.GetReadOnly()
+	if err != nil {
+		h.logger.Error("Failed to check read-only mode: " + err.Error())
+		return h.readOnly
+	}
+	if !ok {
+		return h.readOnly
+	}
+	return enabled
+}
+
+// writable rejects requests to a mutating route with 503 while the API is in
+// read-only mode
+func (h *Handler) writable(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if h.isReadOnly() {
+			h.logger.Info("Rejected write in read-only mode", map[string]interface{}{
+				"method":  r.Method,
+				"path":    r.URL.Path,
+				"api_key": APIKeyLabel(r.Context()),
+				"remote":  r.RemoteAddr,
+			})
+			h.respondWithError(w, http.StatusServiceUnavailable, "API is in read-only mode")
+			return
+		}
+		next(w, r)
+	}
+}
+
+// GetReadOnlyHandler handles requests for the current read-only mode
+// @Summary Get read-only mode
+// @Description Reports whether the API rejects writes. Reads are served either way
+// @Tags admin
+// @Produce json
+// @Success 200 {object} Response
+// @Router /api/v1/admin/read-only [get]
+func (h *Handler) GetReadOnlyHandler(w http.ResponseWriter, r *http.Request) {
+	h.respondWithJSON(w, http.StatusOK, Response{
+		Success: true,
+		Data:    map[string]interface{}{"enabled": h.isReadOnly()},
+	})
+}
+
+// SetReadOnlyHandler handles requests to switch read-only mode
+// @Summary Switch read-only mode
+// @Description Turns read-only mode on or off for all API instances. While on, submitting, changing, cancelling and leasing jobs and creating or deleting workflows fail with 503. Only available with API key authentication enabled
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body ReadOnlyRequest true "Whether writes are rejected"
+// @Success 200 {object} Response
+// @Failure 400 {object} Response "Invalid request"
+// @Failure 403 {object} Response "API key authentication is disabled"
+// @Failure 500 {object} Response "Server error"
+// @Router /api/v1/admin/read-only [put]
+func (h *Handler) SetReadOnlyHandler(w http.ResponseWriter, r *http.Request) {
+	if len(h.apiKeys) == 0 {
+		h.respondWithError(w, http.StatusForbidden, "Switching read-only mode requires API key authentication")
+		return
+	}
+
+	var req ReadOnlyRequest
+	if !h.decodeJSONBody(w, r, &req) {
+		return
+	}
+	if req.Enabled == nil {
+		h.respondWithError(w, http.StatusBadRequest, "enabled is required")
+		return
+	}
+
+	if err := h.queue.SetReadOnly(*req.Enabled); err != nil {
+		h.logger.Error("Failed to set read-only mode: " + err.Error())
+		h.respondWithError(w, http.StatusInternalServerError, "Failed to set read-only mode")
+		return
+	}
+
+	h.logger.Info("Read-only mode switched", map[string]interface{}{
+		"enabled": *req.Enabled,
+		"api_key": APIKeyLabel(r.Context()),
+	})
+
+	h.respondWithJSON(w, http.StatusOK, Response{
+		Success: true,
+		Data:    map[string]interface{}{"enabled": *req.Enabled},
+	})
+}
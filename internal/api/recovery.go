@@ -0,0 +1,90 @@
+// internal/api/recovery.go
+package api
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"runtime/debug"
+
+	"BoltQ/pkg/logger"
+	"BoltQ/pkg/metrics"
+
+	"github.com/gorilla/mux"
+)
+
+// RecoveryMiddleware keeps a panicking handler from dropping the connection.
+// The panic is logged with its stack trace and counted in
+// boltq_http_panics_total, and the client gets a 500 with the usual error body
+// unless the handler had already started its response.
+func RecoveryMiddleware(log *logger.Logger, metricsCollector *metrics.MetricsCollector) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rw := &recoveryWriter{ResponseWriter: w}
+			defer func() {
+				recovered := recover()
+				if recovered == nil {
+					return
+				}
+				// The server aborts the response itself, without logging
+				if recovered == http.ErrAbortHandler {
+					panic(recovered)
+				}
+
+				log.Error(fmt.Sprintf("Panic in handler for %s %s: %v", r.Method, r.URL.Path, recovered), map[string]interface{}{
+					"remote": r.RemoteAddr,
+					"stack":  string(debug.Stack()),
+				})
+				metricsCollector.RecordHandlerPanic()
+
+				if rw.wroteHeader {
+					return
+				}
+				response, _ := json.Marshal(Response{
+					Success: false,
+					Error:   "Internal server error",
+				})
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusInternalServerError)
+				w.Write(response)
+			}()
+
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
+
+// recoveryWriter remembers whether a response was started
+type recoveryWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (w *recoveryWriter) WriteHeader(code int) {
+	w.wroteHeader = true
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *recoveryWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(b)
+}
+
+// Hijack hands the connection over, e.g. to a WebSocket, after which the
+// response counts as started
+func (w *recoveryWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response does not implement http.Hijacker")
+	}
+	w.wroteHeader = true
+	return hijacker.Hijack()
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (w *recoveryWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,38 @@
+// internal/api/transform.go
+package api
+
+import "fmt"
+
+// SubmitTransformer rewrites the payload of a submitted job before it is
+// stored, e.g. to inject defaults or normalize field names. An error rejects
+// the submission.
+type SubmitTransformer func(data map[string]interface{}) (map[string]interface{}, error)
+
+// RegisterSubmitTransformer registers a payload transformer for a job type. It
+// runs on every submission of that type, after the request is validated. It
+// must be called before the handler serves requests.
+func (h *Handler) RegisterSubmitTransformer(jobType string, transformer SubmitTransformer) {
+	if h.transformers == nil {
+		h.transformers = make(map[string]SubmitTransformer)
+	}
+	h.transformers[jobType] = transformer
+}
+
+// transformSubmission applies the transformer registered for a job type to a
+// submitted payload. Payloads of other job types are returned unchanged.
+func (h *Handler) transformSubmission(jobType string, data map[string]interface{}) (map[string]interface{}, error) {
+	transformer, ok := h.transformers[jobType]
+	if !ok {
+		return data, nil
+	}
+
+	if data == nil {
+		data = make(map[string]interface{})
+	}
+
+	transformed, err := transformer(data)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid %s job data: %v", jobType, err)
+	}
+	return transformed, nil
+}
@@ -7,10 +7,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
+	"strings"
 	"sync"
 	"time"
 
+	"BoltQ/internal/queue"
 	"BoltQ/pkg/logger"
+	"BoltQ/pkg/metrics"
 
 	"github.com/go-redis/redis/v8"
 	"github.com/gorilla/websocket"
@@ -28,62 +32,144 @@ const (
 
 	// Maximum message size allowed from peer
 	maxMessageSize = 512
+
+	// Number of messages buffered per client before it is considered too slow
+	clientSendBuffer = 256
+
+	// Attempts and initial backoff for publishing an update to Redis
+	publishAttempts = 3
+	publishBackoff  = 100 * time.Millisecond
+
+	// Initial and maximum backoff between attempts to restore a lost Redis
+	// subscription
+	resubscribeBackoff    = time.Second
+	maxResubscribeBackoff = 30 * time.Second
+
+	// latestJobUpdatePrefix keys the last update published for each job, which
+	// clients can fetch when they may have missed live updates
+	latestJobUpdatePrefix = "job_update:"
+	latestJobUpdateTTL    = 24 * time.Hour
 )
 
-var upgrader = websocket.Upgrader{
-	ReadBufferSize:  1024,
-	WriteBufferSize: 1024,
-	// Allow all origins for development; in production, restrict this
-	CheckOrigin: func(r *http.Request) bool { return true },
+// wsClient is a connected WebSocket client with its own outbound buffer.
+// A dedicated writer goroutine drains the buffer so a slow client never
+// blocks delivery to the others. A client that subscribed to a single job
+// only receives that job's updates, and a batching client receives its
+// updates collected into one message per batch window.
+type wsClient struct {
+	conn  *websocket.Conn
+	send  chan wsMessage
+	jobID string
+	batch bool
+}
+
+// wsMessage is an update to broadcast, with the job it is about if any and
+// whether it carries a final status
+type wsMessage struct {
+	jobID   string
+	final   bool
+	payload []byte
+}
+
+// wants reports whether a client subscribed to a message
+func (c *wsClient) wants(message wsMessage) bool {
+	return c.jobID == "" || c.jobID == message.jobID
+}
+
+// JobUpdatesPath returns the WebSocket path streaming the updates of a single job
+func JobUpdatesPath(jobID string) string {
+	return "/ws/jobs?job_id=" + url.QueryEscape(jobID)
 }
 
 // WebSocketManager handles WebSocket connections and real-time updates
 type WebSocketManager struct {
-	redisClient     *redis.Client
+	redisClient     queue.RedisClient
 	logger          *logger.Logger
-	clients         map[*websocket.Conn]bool
-	broadcast       chan []byte
-	register        chan *websocket.Conn
-	unregister      chan *websocket.Conn
+	clients         map[*wsClient]bool
+	broadcast       chan wsMessage
+	register        chan *wsClient
+	unregister      chan *wsClient
 	ctx             context.Context
 	cancel          context.CancelFunc
 	jobChannel      string
 	workflowChannel string
+	batchWindow     time.Duration
+	allowedOrigins  []string
+	upgrader        websocket.Upgrader
+	subscriber      sync.WaitGroup
 	mu              sync.Mutex
 }
 
 // NewWebSocketManager creates a new WebSocket manager
-func NewWebSocketManager(client *redis.Client, logger *logger.Logger) *WebSocketManager {
+func NewWebSocketManager(client queue.RedisClient, logger *logger.Logger) *WebSocketManager {
 	ctx, cancel := context.WithCancel(context.Background())
 
-	return &WebSocketManager{
+	wm := &WebSocketManager{
 		redisClient:     client,
 		logger:          logger,
-		clients:         make(map[*websocket.Conn]bool),
-		broadcast:       make(chan []byte),
-		register:        make(chan *websocket.Conn),
-		unregister:      make(chan *websocket.Conn),
+		clients:         make(map[*wsClient]bool),
+		broadcast:       make(chan wsMessage),
+		register:        make(chan *wsClient),
+		unregister:      make(chan *wsClient),
 		ctx:             ctx,
 		cancel:          cancel,
 		jobChannel:      "job_updates",
 		workflowChannel: "workflow_updates",
+		batchWindow:     DefaultBatchWindow,
+	}
+	wm.upgrader = websocket.Upgrader{
+		ReadBufferSize:  1024,
+		WriteBufferSize: 1024,
+		CheckOrigin:     wm.checkOrigin,
 	}
+	return wm
+}
+
+// SetAllowedOrigins sets the origins browsers may open WebSocket connections
+// from, written as ParseCORSOrigins accepts them. A lone "*" allows any origin
+// and is meant for development. Same-origin connections and clients that send
+// no Origin header are always accepted.
+func (wm *WebSocketManager) SetAllowedOrigins(origins []string) {
+	wm.allowedOrigins = origins
+}
+
+// checkOrigin reports whether a WebSocket connection may be accepted from the
+// origin of a request, logging the origins it rejects
+func (wm *WebSocketManager) checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" || OriginAllowed(wm.allowedOrigins, origin) {
+		return true
+	}
+
+	if parsed, err := url.Parse(origin); err == nil && strings.EqualFold(parsed.Host, r.Host) {
+		return true
+	}
+
+	wm.logger.Warn("Rejected WebSocket connection from a disallowed origin", map[string]interface{}{
+		"origin":      origin,
+		"remote_addr": r.RemoteAddr,
+	})
+	return false
 }
 
 // Start begins the WebSocket manager
 func (wm *WebSocketManager) Start() {
 	go wm.run()
+
+	wm.subscriber.Add(1)
 	go wm.subscribeToRedis()
 }
 
-// Stop gracefully shuts down the WebSocket manager
+// Stop gracefully shuts down the WebSocket manager. It waits for the Redis
+// subscription to be closed, so updates can no longer be published afterwards.
 func (wm *WebSocketManager) Stop() {
 	wm.cancel()
+	wm.subscriber.Wait()
 
 	// Close all client connections
 	wm.mu.Lock()
 	for client := range wm.clients {
-		client.Close()
+		client.conn.Close()
 	}
 	wm.mu.Unlock()
 }
@@ -95,22 +181,42 @@ func (wm *WebSocketManager) run() {
 		case client := <-wm.register:
 			wm.mu.Lock()
 			wm.clients[client] = true
+			connections := len(wm.clients)
 			wm.mu.Unlock()
-			wm.logger.Info("New WebSocket client connected")
+
+			metrics.WebSocketConnects.Inc()
+			metrics.WebSocketConnections.Set(float64(connections))
+			wm.logger.Info("New WebSocket client connected", map[string]interface{}{"connections": connections})
 
 		case client := <-wm.unregister:
 			wm.mu.Lock()
-			if _, ok := wm.clients[client]; ok {
-				delete(wm.clients, client)
-				client.Close()
+			_, ok := wm.clients[client]
+			if ok {
+				wm.removeClientLocked(client, "closed")
 			}
+			connections := len(wm.clients)
 			wm.mu.Unlock()
-			wm.logger.Info("WebSocket client disconnected")
+
+			if ok {
+				wm.logger.Info("WebSocket client disconnected", map[string]interface{}{"connections": connections})
+			}
 
 		case message := <-wm.broadcast:
+			metrics.WebSocketMessagesBroadcast.Inc()
+
 			wm.mu.Lock()
 			for client := range wm.clients {
-				wm.sendMessage(client, message)
+				if !client.wants(message) {
+					continue
+				}
+
+				select {
+				case client.send <- message:
+				default:
+					// The client's buffer is full, evict it rather than block everyone else
+					wm.removeClientLocked(client, "evicted")
+					wm.logger.Info("Evicted slow WebSocket client", map[string]interface{}{"connections": len(wm.clients)})
+				}
 			}
 			wm.mu.Unlock()
 
@@ -120,55 +226,207 @@ func (wm *WebSocketManager) run() {
 	}
 }
 
-// sendMessage sends a message to a specific client
-func (wm *WebSocketManager) sendMessage(client *websocket.Conn, message []byte) {
-	client.SetWriteDeadline(time.Now().Add(writeWait))
+// removeClientLocked removes a client and closes its send buffer, which stops
+// its writer goroutine and closes the connection. The reason labels the
+// disconnect metric. Callers must hold wm.mu.
+func (wm *WebSocketManager) removeClientLocked(client *wsClient, reason string) {
+	delete(wm.clients, client)
+	close(client.send)
 
-	w, err := client.NextWriter(websocket.TextMessage)
-	if err != nil {
-		client.Close()
-		return
-	}
+	metrics.WebSocketDisconnects.WithLabelValues(reason).Inc()
+	metrics.WebSocketConnections.Set(float64(len(wm.clients)))
+}
 
-	w.Write(message)
+// writePump writes buffered messages and periodic pings to a client. For a
+// batching client, it collects the messages of each batch window and writes
+// them as one.
+func (wm *WebSocketManager) writePump(client *wsClient) {
+	ticker := time.NewTicker(pingPeriod)
+	var batch wsBatch
+	var flush <-chan time.Time
+	defer func() {
+		ticker.Stop()
+		client.conn.Close()
+	}()
 
-	if err := w.Close(); err != nil {
-		client.Close()
-		return
+	for {
+		select {
+		case message, ok := <-client.send:
+			if !ok {
+				// The manager closed the buffer; send what is left of the batch
+				client.conn.SetWriteDeadline(time.Now().Add(writeWait))
+				if !batch.empty() {
+					if payload, err := batch.encode(); err == nil {
+						client.conn.WriteMessage(websocket.TextMessage, payload)
+					}
+				}
+				client.conn.WriteMessage(websocket.CloseMessage, []byte{})
+				return
+			}
+
+			if client.batch {
+				// Start a window with the first update of a batch
+				if batch.empty() {
+					flush = time.After(wm.batchWindow)
+				}
+				batch.add(message)
+				continue
+			}
+
+			client.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := client.conn.WriteMessage(websocket.TextMessage, message.payload); err != nil {
+				return
+			}
+
+		case <-flush:
+			flush = nil
+			payload, err := batch.encode()
+			if err != nil {
+				wm.logger.Error(fmt.Sprintf("Error encoding WebSocket batch: %v", err))
+				continue
+			}
+
+			client.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := client.conn.WriteMessage(websocket.TextMessage, payload); err != nil {
+				return
+			}
+
+		case <-ticker.C:
+			client.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := client.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
 	}
 }
 
-// subscribeToRedis subscribes to Redis PubSub channels for updates
+// subscribeToRedis subscribes to Redis PubSub channels for updates. When the
+// subscription is lost, e.g. because the Redis connection dropped, it
+// subscribes again with exponential backoff until the manager is stopped.
 func (wm *WebSocketManager) subscribeToRedis() {
+	defer wm.subscriber.Done()
+
+	backoff := resubscribeBackoff
+	attempt := 0
+
+	for {
+		if wm.listen(attempt > 0) {
+			backoff = resubscribeBackoff
+			attempt = 0
+		}
+		if wm.ctx.Err() != nil {
+			return
+		}
+
+		attempt++
+		wm.logger.Error(fmt.Sprintf("Redis subscription lost, resubscribing in %s (attempt %d)", backoff, attempt))
+
+		select {
+		case <-time.After(backoff):
+		case <-wm.ctx.Done():
+			return
+		}
+
+		backoff *= 2
+		if backoff > maxResubscribeBackoff {
+			backoff = maxResubscribeBackoff
+		}
+	}
+}
+
+// listen subscribes to the update channels and broadcasts their messages
+// until the subscription closes or the manager is stopped. It reports whether
+// the subscription was established.
+func (wm *WebSocketManager) listen(reconnect bool) bool {
 	pubsub := wm.redisClient.Subscribe(wm.ctx, wm.jobChannel, wm.workflowChannel)
-	defer pubsub.Close()
+	defer func() {
+		if err := pubsub.Close(); err != nil {
+			wm.logger.Error(fmt.Sprintf("Error closing Redis subscription: %v", err))
+		}
+		wm.logger.Info("Redis subscription closed")
+	}()
+
+	// Wait for Redis to confirm the subscription so that a failure is retried
+	if _, err := pubsub.Receive(wm.ctx); err != nil {
+		if wm.ctx.Err() == nil {
+			wm.logger.Error(fmt.Sprintf("Error subscribing to Redis: %v", err))
+		}
+		return false
+	}
+	if reconnect {
+		wm.logger.Info("Redis subscription re-established")
+	}
 
 	ch := pubsub.Channel()
 
 	for {
 		select {
-		case msg := <-ch:
-			wm.broadcast <- []byte(msg.Payload)
+		case msg, ok := <-ch:
+			if !ok || msg == nil {
+				return true
+			}
+
+			// Job updates are routed to the clients subscribed to their job
+			var update struct {
+				JobID  string `json:"job_id"`
+				Status string `json:"status"`
+			}
+			if msg.Channel == wm.jobChannel {
+				json.Unmarshal([]byte(msg.Payload), &update)
+			}
+
+			message := wsMessage{
+				jobID:   update.JobID,
+				final:   update.Status == "failed" || queue.IsFinalStatus(update.Status),
+				payload: []byte(msg.Payload),
+			}
+
+			// The run loop exits on shutdown, so don't block on it
+			select {
+			case wm.broadcast <- message:
+			case <-wm.ctx.Done():
+				return true
+			}
 		case <-wm.ctx.Done():
-			return
+			return true
 		}
 	}
 }
 
-// HandleJobUpdatesWebSocket handles WebSocket connections for job updates
+// HandleJobUpdatesWebSocket handles WebSocket connections for job updates.
+// With a job_id query parameter the connection only receives the updates of
+// that job; otherwise it receives all job and workflow updates. With
+// batch=true the updates are sent in batches.
 func (wm *WebSocketManager) HandleJobUpdatesWebSocket(w http.ResponseWriter, r *http.Request) {
-	conn, err := upgrader.Upgrade(w, r, nil)
+	conn, err := wm.upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		wm.logger.Error(fmt.Sprintf("Error upgrading connection to WebSocket: %v", err))
 		return
 	}
 
+	client := &wsClient{
+		conn:  conn,
+		send:  make(chan wsMessage, clientSendBuffer),
+		jobID: r.URL.Query().Get("job_id"),
+		batch: r.URL.Query().Get("batch") == "true",
+	}
+
 	// Register the client
-	wm.register <- conn
+	select {
+	case wm.register <- client:
+	case <-wm.ctx.Done():
+		conn.Close()
+		return
+	}
+
+	go wm.writePump(client)
 
 	// Unregister client when the function returns
 	defer func() {
-		wm.unregister <- conn
+		select {
+		case wm.unregister <- client:
+		case <-wm.ctx.Done():
+		}
 	}()
 
 	// Set up connection parameters
@@ -206,7 +464,12 @@ func (wm *WebSocketManager) PublishJobUpdate(jobID, status string, data map[stri
 		return err
 	}
 
-	return wm.redisClient.Publish(wm.ctx, wm.jobChannel, string(jsonMessage)).Err()
+	// Keep the latest update even if publishing fails
+	if err := wm.redisClient.Set(wm.ctx, latestJobUpdatePrefix+jobID, jsonMessage, latestJobUpdateTTL).Err(); err != nil {
+		wm.logger.Error(fmt.Sprintf("Error storing latest update for job %s: %v", jobID, err))
+	}
+
+	return wm.publish(wm.jobChannel, jsonMessage)
 }
 
 // PublishWorkflowUpdate publishes a workflow update to all connected clients
@@ -224,5 +487,46 @@ func (wm *WebSocketManager) PublishWorkflowUpdate(workflowID string, status job.
 		return err
 	}
 
-	return wm.redisClient.Publish(wm.ctx, wm.workflowChannel, string(jsonMessage)).Err()
+	return wm.publish(wm.workflowChannel, jsonMessage)
+}
+
+// publish sends a message to a Redis channel, retrying with exponential
+// backoff. An update that still can't be published is logged and counted.
+func (wm *WebSocketManager) publish(channel string, message []byte) error {
+	var err error
+	backoff := publishBackoff
+
+retry:
+	for attempt := 1; ; attempt++ {
+		if err = wm.redisClient.Publish(wm.ctx, channel, string(message)).Err(); err == nil {
+			return nil
+		}
+		if attempt == publishAttempts {
+			break
+		}
+
+		select {
+		case <-time.After(backoff):
+			backoff *= 2
+		case <-wm.ctx.Done():
+			break retry
+		}
+	}
+
+	wm.logger.Error(fmt.Sprintf("Failed to publish update to %s after %d attempts: %v", channel, publishAttempts, err))
+	metrics.WebSocketPublishFailures.WithLabelValues(channel).Inc()
+	return err
+}
+
+// LatestJobUpdate returns the last update published for a job, or nil if
+// there was none in the last day
+func (wm *WebSocketManager) LatestJobUpdate(jobID string) (json.RawMessage, error) {
+	message, err := wm.redisClient.Get(wm.ctx, latestJobUpdatePrefix+jobID).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return json.RawMessage(message), nil
 }
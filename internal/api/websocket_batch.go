@@ -0,0 +1,69 @@
+// internal/api/websocket_batch.go
+package api
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// DefaultBatchWindow is how long updates for a batching client are collected
+// before they are sent as one message
+const DefaultBatchWindow = 100 * time.Millisecond
+
+// wsBatch collects the updates for a batching client during one window.
+// Successive non-final updates of a job are coalesced into the latest one;
+// final updates, such as completed or failed, are always kept.
+type wsBatch struct {
+	updates []wsMessage
+	pending map[string]int // index of each job's latest non-final update
+}
+
+// add appends an update to the batch, replacing the previous non-final
+// update of the same job if there is one
+func (b *wsBatch) add(message wsMessage) {
+	if message.jobID != "" {
+		if i, ok := b.pending[message.jobID]; ok {
+			if message.final {
+				// The update it replaces can be dropped, the final one can't
+				delete(b.pending, message.jobID)
+			}
+			b.updates[i] = message
+			return
+		}
+	}
+
+	if message.jobID != "" && !message.final {
+		if b.pending == nil {
+			b.pending = make(map[string]int)
+		}
+		b.pending[message.jobID] = len(b.updates)
+	}
+	b.updates = append(b.updates, message)
+}
+
+// empty reports whether the batch holds no updates
+func (b *wsBatch) empty() bool {
+	return len(b.updates) == 0
+}
+
+// encode returns the batch as a single message and clears it
+func (b *wsBatch) encode() ([]byte, error) {
+	updates := make([]json.RawMessage, len(b.updates))
+	for i, update := range b.updates {
+		updates[i] = update.payload
+	}
+
+	b.updates = b.updates[:0]
+	b.pending = nil
+
+	return json.Marshal(map[string]interface{}{
+		"type":    "batch",
+		"updates": updates,
+	})
+}
+
+// SetBatchWindow sets how long updates for clients that connected with
+// batch=true are collected before they are sent as one message
+func (wm *WebSocketManager) SetBatchWindow(window time.Duration) {
+	wm.batchWindow = window
+}
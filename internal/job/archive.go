@@ -0,0 +1,127 @@
+// internal/job/archive.go
+package job
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	// Redis keys of the workflow archive. They share a hash tag so that the
+	// archive script can update both in Redis Cluster.
+	workflowArchiveIndexKey = "{workflow_archive}:index"
+	workflowArchiveDataKey  = "{workflow_archive}:data"
+
+	// DefaultArchiveMaxWorkflows bounds the archive when no limit is given
+	DefaultArchiveMaxWorkflows = 10000
+)
+
+// archiveWorkflowScript stores a finished workflow in the archive, indexed by
+// its finish time, then drops entries older than the cutoff and the oldest
+// entries beyond the maximum size
+var archiveWorkflowScript = redis.NewScript(`
+redis.call("ZADD", KEYS[1], ARGV[3], ARGV[1])
+redis.call("HSET", KEYS[2], ARGV[1], ARGV[2])
+
+local expired = redis.call("ZRANGEBYSCORE", KEYS[1], "-inf", "(" .. ARGV[4])
+for _, id in ipairs(expired) do
+	redis.call("HDEL", KEYS[2], id)
+end
+redis.call("ZREMRANGEBYSCORE", KEYS[1], "-inf", "(" .. ARGV[4])
+
+local excess = redis.call("ZCARD", KEYS[1]) - tonumber(ARGV[5])
+if excess > 0 then
+	local oldest = redis.call("ZRANGE", KEYS[1], 0, excess - 1)
+	for _, id in ipairs(oldest) do
+		redis.call("HDEL", KEYS[2], id)
+	end
+	redis.call("ZREMRANGEBYRANK", KEYS[1], 0, excess - 1)
+end
+return 1
+`)
+
+// EnableArchive copies workflows into a separate archive when they reach a
+// terminal state, where they outlive the regular workflow TTL. Archived
+// workflows are kept for retention, and at most maxWorkflows of the most
+// recently finished ones are kept. A retention of 0 disables the archive.
+func (wm *WorkflowManager) EnableArchive(retention time.Duration, maxWorkflows int) {
+	if maxWorkflows <= 0 {
+		maxWorkflows = DefaultArchiveMaxWorkflows
+	}
+
+	wm.mu.Lock()
+	defer wm.mu.Unlock()
+
+	wm.archiveRetention = retention
+	wm.archiveMax = maxWorkflows
+}
+
+// archiveLocked copies a terminal workflow to the archive if it is enabled.
+// Archiving the same workflow again replaces its entry. Callers must hold wm.mu.
+func (wm *WorkflowManager) archiveLocked(workflow *Workflow) {
+	if wm.archiveRetention <= 0 || !workflow.IsTerminal() {
+		return
+	}
+
+	workflowJSON, err := workflow.ToJSON()
+	if err != nil {
+		wm.logger.Error(fmt.Sprintf("Error serializing workflow %s for the archive: %v", workflow.ID, err))
+		return
+	}
+
+	finishedAt := time.Now()
+	if workflow.FinishedAt != nil {
+		finishedAt = *workflow.FinishedAt
+	}
+	cutoff := time.Now().Add(-wm.archiveRetention)
+
+	err = archiveWorkflowScript.Run(wm.ctx, wm.redisClient,
+		[]string{workflowArchiveIndexKey, workflowArchiveDataKey},
+		workflow.ID, workflowJSON, finishedAt.Unix(), cutoff.Unix(), wm.archiveMax,
+	).Err()
+	if err != nil {
+		wm.logger.Error(fmt.Sprintf("Error archiving workflow %s: %v", workflow.ID, err))
+		return
+	}
+
+	wm.logger.Info(fmt.Sprintf("Archived workflow %s with status %s", workflow.ID, workflow.Status))
+}
+
+// ListArchivedWorkflows returns summaries of archived workflows, most recently
+// finished first
+func (wm *WorkflowManager) ListArchivedWorkflows(limit, offset int) ([]map[string]interface{}, error) {
+	ids, err := wm.redisClient.ZRevRange(wm.ctx, workflowArchiveIndexKey, int64(offset), int64(offset+limit-1)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("error listing archived workflows: %v", err)
+	}
+
+	workflows := make([]map[string]interface{}, 0, len(ids))
+	if len(ids) == 0 {
+		return workflows, nil
+	}
+
+	values, err := wm.redisClient.HMGet(wm.ctx, workflowArchiveDataKey, ids...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving archived workflows: %v", err)
+	}
+
+	for i, value := range values {
+		workflowJSON, ok := value.(string)
+		if !ok {
+			// Trimmed between the two reads
+			continue
+		}
+
+		workflow, err := WorkflowFromJSON(workflowJSON)
+		if err != nil {
+			wm.logger.Error(fmt.Sprintf("Error deserializing archived workflow %s: %v", ids[i], err))
+			continue
+		}
+
+		workflows = append(workflows, workflowSummary(workflow))
+	}
+
+	return workflows, nil
+}
@@ -0,0 +1,442 @@
+// internal/job/condition.go
+package job
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Condition is a parsed step condition, a boolean expression over the results
+// of earlier steps:
+//
+//	analyze.anomalies > 0 && analyze.status == "done"
+//	!(fetch.empty) || fetch.rows >= 100
+//
+// A path such as analyze.summary.count starts with the ID of a step the
+// conditioned step depends on, followed by keys into that step's result. Paths
+// that don't resolve, including those into the result of a skipped step, are
+// null. Literals are numbers, strings in single or double quotes, true, false
+// and null. Operators, by increasing precedence, are ||, &&, the comparisons
+// == != < <= > >= and !. Values compared with < <= > >= must both be numbers or
+// both strings, or the comparison is false. An operand without a comparison
+// is true unless it is null, false, 0, "" or an empty list or object.
+type Condition struct {
+	root conditionNode
+}
+
+// conditionNode is a node of a parsed condition
+type conditionNode interface {
+	eval(results func(stepID string) map[string]interface{}) interface{}
+}
+
+type (
+	literalNode struct{ value interface{} }
+	notNode     struct{ operand conditionNode }
+	pathNode    struct {
+		stepID string
+		keys   []string
+	}
+	binaryNode struct {
+		op          string
+		left, right conditionNode
+	}
+)
+
+// ParseCondition parses a step condition
+func ParseCondition(expr string) (*Condition, error) {
+	tokens, err := tokenizeCondition(expr)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("condition is empty")
+	}
+
+	p := &conditionParser{tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q", p.tokens[p.pos].text)
+	}
+
+	return &Condition{root: root}, nil
+}
+
+// Eval reports whether the condition holds, given the results of steps by ID
+func (c *Condition) Eval(results func(stepID string) map[string]interface{}) bool {
+	return truthy(c.root.eval(results))
+}
+
+// StepIDs returns the IDs of the steps whose results the condition refers to
+func (c *Condition) StepIDs() []string {
+	var stepIDs []string
+	seen := make(map[string]bool)
+
+	var walk func(node conditionNode)
+	walk = func(node conditionNode) {
+		switch n := node.(type) {
+		case *pathNode:
+			if !seen[n.stepID] {
+				seen[n.stepID] = true
+				stepIDs = append(stepIDs, n.stepID)
+			}
+		case *notNode:
+			walk(n.operand)
+		case *binaryNode:
+			walk(n.left)
+			walk(n.right)
+		}
+	}
+	walk(c.root)
+
+	return stepIDs
+}
+
+func (n *literalNode) eval(func(string) map[string]interface{}) interface{} {
+	return n.value
+}
+
+func (n *pathNode) eval(results func(string) map[string]interface{}) interface{} {
+	var value interface{} = results(n.stepID)
+	for _, key := range n.keys {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = object[key]
+	}
+	return value
+}
+
+func (n *notNode) eval(results func(string) map[string]interface{}) interface{} {
+	return !truthy(n.operand.eval(results))
+}
+
+func (n *binaryNode) eval(results func(string) map[string]interface{}) interface{} {
+	switch n.op {
+	case "&&":
+		return truthy(n.left.eval(results)) && truthy(n.right.eval(results))
+	case "||":
+		return truthy(n.left.eval(results)) || truthy(n.right.eval(results))
+	}
+
+	left, right := n.left.eval(results), n.right.eval(results)
+	switch n.op {
+	case "==":
+		return conditionEqual(left, right)
+	case "!=":
+		return !conditionEqual(left, right)
+	}
+
+	cmp, ok := conditionCompare(left, right)
+	if !ok {
+		return false
+	}
+	switch n.op {
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	default:
+		return cmp >= 0
+	}
+}
+
+// truthy reports whether a value counts as true in a condition
+func truthy(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case string:
+		return v != ""
+	case []interface{}:
+		return len(v) > 0
+	case map[string]interface{}:
+		return len(v) > 0
+	}
+
+	if number, ok := conditionNumber(value); ok {
+		return number != 0
+	}
+	return true
+}
+
+// conditionNumber returns a value as a number, if it is one
+func conditionNumber(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	default:
+		return 0, false
+	}
+}
+
+// conditionEqual compares two values for equality. Numbers are equal by value
+// whatever their Go type; lists and objects are never equal to anything.
+func conditionEqual(left, right interface{}) bool {
+	if l, ok := conditionNumber(left); ok {
+		r, ok := conditionNumber(right)
+		return ok && l == r
+	}
+
+	switch l := left.(type) {
+	case nil:
+		return right == nil
+	case bool:
+		r, ok := right.(bool)
+		return ok && l == r
+	case string:
+		r, ok := right.(string)
+		return ok && l == r
+	default:
+		return false
+	}
+}
+
+// conditionCompare orders two numbers or two strings
+func conditionCompare(left, right interface{}) (int, bool) {
+	if l, ok := conditionNumber(left); ok {
+		r, ok := conditionNumber(right)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case l < r:
+			return -1, true
+		case l > r:
+			return 1, true
+		default:
+			return 0, true
+		}
+	}
+
+	l, ok := left.(string)
+	if !ok {
+		return 0, false
+	}
+	r, ok := right.(string)
+	if !ok {
+		return 0, false
+	}
+	return strings.Compare(l, r), true
+}
+
+// conditionToken is a token of a condition. Kind is "path", "number", "string"
+// or "op"; text is the token as written, or the unquoted value of a string.
+type conditionToken struct {
+	kind string
+	text string
+}
+
+// conditionOperators are the operators of a condition, two-character ones first
+var conditionOperators = []string{"&&", "||", "==", "!=", "<=", ">=", "<", ">", "!", "(", ")"}
+
+// tokenizeCondition splits a condition into tokens
+func tokenizeCondition(expr string) ([]conditionToken, error) {
+	var tokens []conditionToken
+
+	for i := 0; i < len(expr); {
+		c := rune(expr[i])
+
+		switch {
+		case unicode.IsSpace(c):
+			i++
+
+		case c == '"' || c == '\'':
+			end := strings.IndexRune(expr[i+1:], c)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated string at position %d", i)
+			}
+			tokens = append(tokens, conditionToken{kind: "string", text: expr[i+1 : i+1+end]})
+			i += end + 2
+
+		case unicode.IsDigit(c) || (c == '-' && i+1 < len(expr) && unicode.IsDigit(rune(expr[i+1]))):
+			start := i
+			i++
+			for i < len(expr) && (unicode.IsDigit(rune(expr[i])) || expr[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, conditionToken{kind: "number", text: expr[start:i]})
+
+		case unicode.IsLetter(c) || c == '_':
+			start := i
+			for i < len(expr) && isConditionPathChar(rune(expr[i])) {
+				i++
+			}
+			tokens = append(tokens, conditionToken{kind: "path", text: expr[start:i]})
+
+		default:
+			matched := false
+			for _, op := range conditionOperators {
+				if strings.HasPrefix(expr[i:], op) {
+					tokens = append(tokens, conditionToken{kind: "op", text: op})
+					i += len(op)
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, fmt.Errorf("unexpected character %q at position %d", c, i)
+			}
+		}
+	}
+
+	return tokens, nil
+}
+
+// isConditionPathChar reports whether c may appear in a path after its first character
+func isConditionPathChar(c rune) bool {
+	return unicode.IsLetter(c) || unicode.IsDigit(c) || c == '_' || c == '-' || c == '.'
+}
+
+// conditionParser is a recursive descent parser over the tokens of a condition
+type conditionParser struct {
+	tokens []conditionToken
+	pos    int
+}
+
+// peekOp returns the next token if it is one of the given operators
+func (p *conditionParser) peekOp(ops ...string) (string, bool) {
+	if p.pos >= len(p.tokens) || p.tokens[p.pos].kind != "op" {
+		return "", false
+	}
+	for _, op := range ops {
+		if p.tokens[p.pos].text == op {
+			return op, true
+		}
+	}
+	return "", false
+}
+
+func (p *conditionParser) parseOr() (conditionNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if _, ok := p.peekOp("||"); !ok {
+			return left, nil
+		}
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{op: "||", left: left, right: right}
+	}
+}
+
+func (p *conditionParser) parseAnd() (conditionNode, error) {
+	left, err := p.parseComparison()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if _, ok := p.peekOp("&&"); !ok {
+			return left, nil
+		}
+		p.pos++
+		right, err := p.parseComparison()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{op: "&&", left: left, right: right}
+	}
+}
+
+func (p *conditionParser) parseComparison() (conditionNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	op, ok := p.peekOp("==", "!=", "<", "<=", ">", ">=")
+	if !ok {
+		return left, nil
+	}
+	p.pos++
+
+	right, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	return &binaryNode{op: op, left: left, right: right}, nil
+}
+
+func (p *conditionParser) parseUnary() (conditionNode, error) {
+	if _, ok := p.peekOp("!"); ok {
+		p.pos++
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &notNode{operand: operand}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *conditionParser) parsePrimary() (conditionNode, error) {
+	if p.pos >= len(p.tokens) {
+		return nil, fmt.Errorf("condition ends unexpectedly")
+	}
+	token := p.tokens[p.pos]
+	p.pos++
+
+	switch token.kind {
+	case "string":
+		return &literalNode{value: token.text}, nil
+
+	case "number":
+		number, err := strconv.ParseFloat(token.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q", token.text)
+		}
+		return &literalNode{value: number}, nil
+
+	case "path":
+		switch token.text {
+		case "true":
+			return &literalNode{value: true}, nil
+		case "false":
+			return &literalNode{value: false}, nil
+		case "null":
+			return &literalNode{value: nil}, nil
+		}
+
+		parts := strings.Split(token.text, ".")
+		for _, part := range parts {
+			if part == "" {
+				return nil, fmt.Errorf("invalid path %q", token.text)
+			}
+		}
+		return &pathNode{stepID: parts[0], keys: parts[1:]}, nil
+	}
+
+	if token.text == "(" {
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := p.peekOp(")"); !ok {
+			return nil, fmt.Errorf("missing closing parenthesis")
+		}
+		p.pos++
+		return node, nil
+	}
+
+	return nil, fmt.Errorf("unexpected %q", token.text)
+}
@@ -0,0 +1,55 @@
+// internal/job/id.go
+package job
+
+import (
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// IDGenerator mints the IDs of jobs, tasks, workflows and workflow steps
+type IDGenerator interface {
+	NewID() string
+}
+
+// IDGeneratorFunc adapts a function to the IDGenerator interface
+type IDGeneratorFunc func() string
+
+// NewID calls f
+func (f IDGeneratorFunc) NewID() string {
+	return f()
+}
+
+// UUIDGenerator generates random UUIDv4 IDs. It is the default.
+type UUIDGenerator struct{}
+
+// NewID returns a new UUIDv4
+func (UUIDGenerator) NewID() string {
+	return uuid.New().String()
+}
+
+var (
+	idGenerator   IDGenerator = UUIDGenerator{}
+	idGeneratorMu sync.RWMutex
+)
+
+// SetIDGenerator replaces the generator used for all new IDs, e.g. with one
+// returning deterministic IDs in tests. A nil generator restores the default.
+func SetIDGenerator(generator IDGenerator) {
+	if generator == nil {
+		generator = UUIDGenerator{}
+	}
+
+	idGeneratorMu.Lock()
+	defer idGeneratorMu.Unlock()
+
+	idGenerator = generator
+}
+
+// NewID returns a new ID from the configured generator
+func NewID() string {
+	idGeneratorMu.RLock()
+	defer idGeneratorMu.RUnlock()
+
+	return idGenerator.NewID()
+}
@@ -0,0 +1,53 @@
+package job
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestNewIDConcurrent(t *testing.T) {
+	const goroutines = 50
+	const idsPerGoroutine = 1000
+
+	ids := make([][]string, goroutines)
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < idsPerGoroutine; i++ {
+				ids[g] = append(ids[g], NewID())
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	seen := make(map[string]bool, goroutines*idsPerGoroutine)
+	for _, batch := range ids {
+		for _, id := range batch {
+			if seen[id] {
+				t.Fatalf("duplicate ID %s", id)
+			}
+			seen[id] = true
+		}
+	}
+}
+
+func TestSetIDGenerator(t *testing.T) {
+	var next atomic.Int64
+	SetIDGenerator(IDGeneratorFunc(func() string {
+		return fmt.Sprintf("id-%d", next.Add(1))
+	}))
+	defer SetIDGenerator(nil)
+
+	if id := NewID(); id != "id-1" {
+		t.Errorf("NewID = %s, want id-1", id)
+	}
+
+	SetIDGenerator(nil)
+	if id := NewID(); len(id) != 36 {
+		t.Errorf("NewID after reset = %s, want a UUID", id)
+	}
+}
@@ -3,8 +3,9 @@ package job
 
 import (
 	"encoding/json"
-	"math"
 	"time"
+
+	"BoltQ/internal/queue"
 )
 
 // Status represents the status of a job
@@ -51,8 +52,19 @@ type Job struct {
 	Error       string                 `json:"error,omitempty"`
 }
 
-func (j *Job) SetPriority(low Priority) {
-	panic("unimplemented")
+// SetPriority sets the priority of the job
+func (j *Job) SetPriority(priority Priority) {
+	j.Priority = priority
+	j.UpdatedAt = time.Now()
+}
+
+// QueuePriority maps the job's named priority to the canonical queue priority,
+// defaulting to normal for unknown names
+func (p Priority) QueuePriority() int {
+	if priority, ok := queue.PriorityFromName(string(p)); ok {
+		return priority
+	}
+	return queue.PriorityNormal
 }
 
 // String returns a string representation of the job
@@ -104,17 +116,10 @@ func (j *Job) MarkCancelled() {
 	j.UpdatedAt = time.Now()
 }
 
-// GetBackoffSeconds calculates the backoff duration in seconds
-func (j *Job) GetBackoffSeconds() int {
-	// Exponential backoff: 2^attempts seconds
-	backoff := int(math.Pow(2, float64(j.Attempts)))
-
-	// Cap at 5 minutes
-	if backoff > 300 {
-		backoff = 300
-	}
-
-	return backoff
+// GetBackoffSeconds calculates the backoff duration in seconds under a retry
+// policy, normally the queue's RetryPolicy
+func (j *Job) GetBackoffSeconds(policy queue.RetryPolicy) int {
+	return policy.DelaySeconds(j.Attempts)
 }
 
 // IsExpired checks if the job has expired based on timeout
@@ -0,0 +1,196 @@
+// internal/job/validation.go
+package job
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// ValidationError describes a single problem found while validating a job
+// submission or a workflow. Field names the offending request field.
+type ValidationError struct {
+	StepID  string `json:"step_id,omitempty"`
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationErrors is a list of validation problems that satisfies the error interface
+type ValidationErrors []ValidationError
+
+// Error joins all validation messages into a single string
+func (e ValidationErrors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, ve := range e {
+		if ve.StepID != "" {
+			messages = append(messages, fmt.Sprintf("step %s: %s", ve.StepID, ve.Message))
+		} else {
+			messages = append(messages, ve.Message)
+		}
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Validate checks that the workflow is well formed: it must have a name, at least
+// one step, every step must have a job type, every dependency must reference an
+// existing step, conditions must only refer to dependencies, a completion
+// webhook must be an http(s) URL and the dependency graph must not contain cycles.
+// It returns ValidationErrors when any problem is found and nil otherwise.
+func (w *Workflow) Validate() error {
+	var errs ValidationErrors
+
+	if w.Name == "" {
+		errs = append(errs, ValidationError{Field: "name", Message: "Workflow name is required"})
+	}
+
+	if len(w.StepOrder) == 0 {
+		errs = append(errs, ValidationError{Field: "steps", Message: "Workflow must have at least one step"})
+		return errs
+	}
+
+	seen := make(map[string]bool, len(w.StepOrder))
+	for _, stepID := range w.StepOrder {
+		if seen[stepID] {
+			errs = append(errs, ValidationError{StepID: stepID, Field: "id", Message: "duplicate step ID"})
+			continue
+		}
+		seen[stepID] = true
+
+		step := w.Steps[stepID]
+		if step.JobType == "" {
+			errs = append(errs, ValidationError{StepID: stepID, Field: "job_type", Message: "job type is required"})
+		}
+
+		for _, depID := range step.DependsOn {
+			if depID == stepID {
+				errs = append(errs, ValidationError{StepID: stepID, Field: "depends_on", Message: "step cannot depend on itself"})
+				continue
+			}
+			if _, exists := w.Steps[depID]; !exists {
+				errs = append(errs, ValidationError{StepID: stepID, Field: "depends_on",
+					Message: fmt.Sprintf("dependency %s does not exist", depID)})
+			}
+		}
+
+		errs = append(errs, validateCondition(step)...)
+	}
+
+	for _, stepID := range w.ResultSteps() {
+		if _, exists := w.Steps[stepID]; !exists {
+			errs = append(errs, ValidationError{Field: "metadata.result_steps",
+				Message: fmt.Sprintf("result step %s does not exist", stepID)})
+		}
+	}
+
+	if webhook, exists := w.Metadata[CompletionWebhookKey]; exists {
+		if value, ok := webhook.(string); !ok || !isHTTPURL(value) {
+			errs = append(errs, ValidationError{Field: "metadata.completion_webhook",
+				Message: "completion webhook must be an absolute http or https URL"})
+		}
+	}
+
+	for _, stepID := range w.findCycle() {
+		errs = append(errs, ValidationError{StepID: stepID, Field: "depends_on", Message: "step is part of a dependency cycle"})
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// isHTTPURL reports whether value is an absolute http(s) URL
+func isHTTPURL(value string) bool {
+	parsed, err := url.Parse(value)
+	if err != nil {
+		return false
+	}
+	return (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
+
+// validateCondition checks that the condition of a step parses and only refers
+// to the results of steps it depends on, which are known when it becomes ready
+func validateCondition(step *WorkflowStep) ValidationErrors {
+	if step.Condition == "" {
+		return nil
+	}
+
+	condition, err := ParseCondition(step.Condition)
+	if err != nil {
+		return ValidationErrors{{StepID: step.ID, Field: "condition", Message: fmt.Sprintf("invalid condition: %v", err)}}
+	}
+
+	var errs ValidationErrors
+	for _, refID := range condition.StepIDs() {
+		dependency := false
+		for _, depID := range step.DependsOn {
+			if depID == refID {
+				dependency = true
+				break
+			}
+		}
+		if !dependency {
+			errs = append(errs, ValidationError{StepID: step.ID, Field: "condition",
+				Message: fmt.Sprintf("condition refers to %s, which is not a dependency", refID)})
+		}
+	}
+	return errs
+}
+
+// findCycle returns the IDs of the steps forming the first dependency cycle found,
+// or nil if the dependency graph is acyclic. Self and missing dependencies are
+// reported separately by Validate and ignored here.
+func (w *Workflow) findCycle() []string {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+
+	state := make(map[string]int, len(w.Steps))
+	var path []string
+	var cycle []string
+
+	var visit func(stepID string) bool
+	visit = func(stepID string) bool {
+		state[stepID] = visiting
+		path = append(path, stepID)
+
+		for _, depID := range w.Steps[stepID].DependsOn {
+			if depID == stepID {
+				continue
+			}
+			if _, exists := w.Steps[depID]; !exists {
+				continue
+			}
+
+			switch state[depID] {
+			case visiting:
+				// Extract the cycle from the current path
+				for i := len(path) - 1; i >= 0; i-- {
+					if path[i] == depID {
+						cycle = append([]string(nil), path[i:]...)
+						break
+					}
+				}
+				return true
+			case unvisited:
+				if visit(depID) {
+					return true
+				}
+			}
+		}
+
+		path = path[:len(path)-1]
+		state[stepID] = visited
+		return false
+	}
+
+	for _, stepID := range w.StepOrder {
+		if state[stepID] == unvisited && visit(stepID) {
+			return cycle
+		}
+	}
+
+	return nil
+}
@@ -4,9 +4,8 @@ package job
 import (
 	"encoding/json"
 	"fmt"
+	"math/rand"
 	"time"
-
-	"github.com/google/uuid"
 )
 
 // WorkflowStatus represents the current state of a workflow
@@ -17,6 +16,37 @@ const (
 	WorkflowStatusRunning   WorkflowStatus = "running"
 	WorkflowStatusCompleted WorkflowStatus = "completed"
 	WorkflowStatusFailed    WorkflowStatus = "failed"
+	WorkflowStatusCancelled WorkflowStatus = "cancelled"
+)
+
+const (
+	// StepInputsKey is the task data key under which a step receives the
+	// results of the steps it depends on, keyed by dependency step ID
+	StepInputsKey = "inputs"
+
+	// WorkflowIDKey and WorkflowStepIDKey identify the workflow step a task belongs to
+	WorkflowIDKey     = "workflow_id"
+	WorkflowStepIDKey = "workflow_step_id"
+
+	// StepStaggerKey is the workflow metadata key enabling staggered step
+	// scheduling: each ready step is delayed this many seconds (plus jitter)
+	// more than the previous one
+	StepStaggerKey = "step_stagger_seconds"
+
+	// MaxStaggerKey is the workflow metadata key capping the delay of a staggered step
+	MaxStaggerKey = "max_stagger_seconds"
+
+	// MaxParallelStepsKey is the workflow metadata key capping how many of
+	// the workflow's steps run at the same time
+	MaxParallelStepsKey = "max_parallel_steps"
+
+	// ResultStepsKey is the workflow metadata key listing the steps whose
+	// output makes up the workflow result, as a step ID or a list of step IDs
+	ResultStepsKey = "result_steps"
+
+	// CompletionWebhookKey is the workflow metadata key holding a URL that is
+	// POSTed the final state of the workflow when it finishes
+	CompletionWebhookKey = "completion_webhook"
 )
 
 // WorkflowStepStatus represents the current state of a workflow step
@@ -28,6 +58,7 @@ const (
 	StepStatusCompleted WorkflowStepStatus = "completed"
 	StepStatusFailed    WorkflowStepStatus = "failed"
 	StepStatusSkipped   WorkflowStepStatus = "skipped"
+	StepStatusCancelled WorkflowStepStatus = "cancelled"
 )
 
 // WorkflowStep represents a single job in a workflow
@@ -36,6 +67,7 @@ type WorkflowStep struct {
 	JobType      string                 `json:"job_type"`
 	Params       map[string]interface{} `json:"params"`
 	DependsOn    []string               `json:"depends_on,omitempty"`
+	Condition    string                 `json:"condition,omitempty"`
 	Status       WorkflowStepStatus     `json:"status"`
 	ErrorMessage string                 `json:"error_message,omitempty"`
 	Result       map[string]interface{} `json:"result,omitempty"`
@@ -45,9 +77,29 @@ type WorkflowStep struct {
 
 // WorkflowStepInput represents input for a workflow step
 type WorkflowStepInput struct {
+	ID        string                 `json:"id,omitempty" example:"step-1"`
 	JobType   string                 `json:"job_type" example:"process_data"`
 	Params    map[string]interface{} `json:"params" example:"{\"input_file\":\"data.csv\"}"`
 	DependsOn []string               `json:"depends_on,omitempty" example:"[\"step-1\",\"step-2\"]"`
+	Condition string                 `json:"condition,omitempty" example:"step-1.anomalies > 0"`
+}
+
+// WorkflowStepSummary is the progress of a workflow step, without its params
+// and result
+type WorkflowStepSummary struct {
+	ID           string             `json:"id"`
+	JobType      string             `json:"job_type"`
+	Status       WorkflowStepStatus `json:"status"`
+	ErrorMessage string             `json:"error_message,omitempty"`
+	StartedAt    *time.Time         `json:"started_at,omitempty"`
+	CompletedAt  *time.Time         `json:"completed_at,omitempty"`
+}
+
+// WorkflowProgress is a compact view of a workflow's steps for progress displays
+type WorkflowProgress struct {
+	WorkflowID string                `json:"workflow_id"`
+	Status     WorkflowStatus        `json:"status"`
+	Steps      []WorkflowStepSummary `json:"steps"`
 }
 
 // Workflow represents a collection of jobs that have dependencies between them
@@ -61,12 +113,16 @@ type Workflow struct {
 	StartedAt  *time.Time               `json:"started_at,omitempty"`
 	FinishedAt *time.Time               `json:"finished_at,omitempty"`
 	Metadata   map[string]interface{}   `json:"metadata,omitempty"`
+	Result     map[string]interface{}   `json:"result,omitempty"`
+
+	// CompletionWebhookError is why the completion webhook could not be delivered
+	CompletionWebhookError string `json:"completion_webhook_error,omitempty"`
 }
 
 // NewWorkflow creates a new workflow with the given name
 func NewWorkflow(name string) *Workflow {
 	return &Workflow{
-		ID:        uuid.New().String(),
+		ID:        NewID(),
 		Name:      name,
 		Status:    WorkflowStatusPending,
 		Steps:     make(map[string]*WorkflowStep),
@@ -78,8 +134,12 @@ func NewWorkflow(name string) *Workflow {
 
 // AddStep adds a new step to the workflow
 func (w *Workflow) AddStep(jobType string, params map[string]interface{}, dependsOn []string) string {
-	stepID := uuid.New().String()
+	return w.AddStepWithID(NewID(), jobType, params, dependsOn)
+}
 
+// AddStepWithID adds a new step to the workflow using a caller-supplied step ID
+// so that other steps can reference it in DependsOn
+func (w *Workflow) AddStepWithID(stepID, jobType string, params map[string]interface{}, dependsOn []string) string {
 	step := &WorkflowStep{
 		ID:        stepID,
 		JobType:   jobType,
@@ -94,7 +154,9 @@ func (w *Workflow) AddStep(jobType string, params map[string]interface{}, depend
 	return stepID
 }
 
-// GetReadySteps returns all steps that are ready to be executed
+// GetReadySteps returns all steps that are ready to be executed: pending steps
+// whose dependencies have completed or were skipped by their condition, and
+// whose own condition, if any, holds
 func (w *Workflow) GetReadySteps() []*WorkflowStep {
 	readySteps := make([]*WorkflowStep, 0)
 
@@ -106,24 +168,90 @@ func (w *Workflow) GetReadySteps() []*WorkflowStep {
 			continue
 		}
 
-		// Check if all dependencies are satisfied
-		allDependenciesSatisfied := true
-		for _, depID := range step.DependsOn {
-			depStep, exists := w.Steps[depID]
-			if !exists || depStep.Status != StepStatusCompleted {
-				allDependenciesSatisfied = false
-				break
-			}
+		if !w.dependenciesSatisfied(step) {
+			continue
 		}
 
-		if allDependenciesSatisfied {
-			readySteps = append(readySteps, step)
+		if holds, err := w.conditionHolds(step); err != nil || !holds {
+			continue
 		}
+
+		readySteps = append(readySteps, step)
 	}
 
 	return readySteps
 }
 
+// dependenciesSatisfied reports whether every dependency of a step has
+// completed or was skipped. Steps are only skipped without failing the
+// workflow when their condition does not hold.
+func (w *Workflow) dependenciesSatisfied(step *WorkflowStep) bool {
+	for _, depID := range step.DependsOn {
+		depStep, exists := w.Steps[depID]
+		if !exists || (depStep.Status != StepStatusCompleted && depStep.Status != StepStatusSkipped) {
+			return false
+		}
+	}
+	return true
+}
+
+// conditionHolds evaluates the condition of a step against the results of the
+// steps before it. A step without a condition always runs.
+func (w *Workflow) conditionHolds(step *WorkflowStep) (bool, error) {
+	if step.Condition == "" {
+		return true, nil
+	}
+
+	condition, err := ParseCondition(step.Condition)
+	if err != nil {
+		return false, err
+	}
+
+	return condition.Eval(func(stepID string) map[string]interface{} {
+		if depStep, exists := w.Steps[stepID]; exists {
+			return depStep.Result
+		}
+		return nil
+	}), nil
+}
+
+// SkipUnmetConditions marks pending steps whose dependencies are satisfied but
+// whose condition does not hold as skipped, which in turn may settle the
+// condition of steps depending on them. A step with a condition that can't be
+// parsed fails, failing the workflow. It returns the IDs of the skipped steps.
+func (w *Workflow) SkipUnmetConditions() []string {
+	var skipped []string
+
+	for changed := true; changed; {
+		changed = false
+
+		for _, stepID := range w.StepOrder {
+			step := w.Steps[stepID]
+			if step.Status != StepStatusPending || step.Condition == "" || !w.dependenciesSatisfied(step) {
+				continue
+			}
+
+			holds, err := w.conditionHolds(step)
+			if err != nil {
+				w.UpdateStepStatus(stepID, StepStatusFailed, fmt.Sprintf("Invalid condition: %v", err), nil)
+				return skipped
+			}
+			if holds {
+				continue
+			}
+
+			now := time.Now()
+			step.Status = StepStatusSkipped
+			step.ErrorMessage = fmt.Sprintf("Skipped because condition %s does not hold", step.Condition)
+			step.CompletedAt = &now
+			skipped = append(skipped, stepID)
+			changed = true
+		}
+	}
+
+	return skipped
+}
+
 // UpdateStepStatus updates the status of a step and potentially the workflow itself
 func (w *Workflow) UpdateStepStatus(stepID string, status WorkflowStepStatus, errorMsg string, result map[string]interface{}) error {
 	step, exists := w.Steps[stepID]
@@ -158,8 +286,7 @@ func (w *Workflow) UpdateStepStatus(stepID string, status WorkflowStepStatus, er
 		}
 
 		if allComplete {
-			w.Status = WorkflowStatusCompleted
-			w.FinishedAt = &now
+			w.Complete()
 		}
 
 	case StepStatusFailed:
@@ -177,6 +304,70 @@ func (w *Workflow) UpdateStepStatus(stepID string, status WorkflowStepStatus, er
 	return nil
 }
 
+// Complete marks the workflow as completed and builds its final result
+func (w *Workflow) Complete() {
+	now := time.Now()
+	w.Status = WorkflowStatusCompleted
+	w.FinishedAt = &now
+	w.Result = w.buildResult()
+}
+
+// buildResult collects the workflow result from the step outputs. With a single
+// step listed under result_steps in the metadata, that step's output is the
+// result. Otherwise the result maps the ID of each listed step, or of every
+// step that produced output if none are listed, to its output.
+func (w *Workflow) buildResult() map[string]interface{} {
+	stepIDs := w.ResultSteps()
+	if len(stepIDs) == 1 {
+		if step, exists := w.Steps[stepIDs[0]]; exists {
+			return step.Result
+		}
+		return nil
+	}
+
+	if len(stepIDs) == 0 {
+		stepIDs = w.StepOrder
+	}
+
+	result := make(map[string]interface{})
+	for _, stepID := range stepIDs {
+		if step, exists := w.Steps[stepID]; exists && step.Result != nil {
+			result[stepID] = step.Result
+		}
+	}
+
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
+
+// CompletionWebhook returns the URL listed under completion_webhook in the metadata
+func (w *Workflow) CompletionWebhook() string {
+	webhook, _ := w.Metadata[CompletionWebhookKey].(string)
+	return webhook
+}
+
+// ResultSteps returns the step IDs listed under result_steps in the metadata
+func (w *Workflow) ResultSteps() []string {
+	switch v := w.Metadata[ResultStepsKey].(type) {
+	case string:
+		return []string{v}
+	case []string:
+		return v
+	case []interface{}:
+		stepIDs := make([]string, 0, len(v))
+		for _, item := range v {
+			if stepID, ok := item.(string); ok {
+				stepIDs = append(stepIDs, stepID)
+			}
+		}
+		return stepIDs
+	default:
+		return nil
+	}
+}
+
 // skipDependentSteps marks all steps that depend on the given step as skipped
 func (w *Workflow) skipDependentSteps(failedStepID string) {
 	for _, stepID := range w.StepOrder {
@@ -201,6 +392,109 @@ func (w *Workflow) skipDependentSteps(failedStepID string) {
 	}
 }
 
+// Cancel stops the workflow: running steps are marked cancelled and pending
+// steps skipped, so no further steps will be scheduled. It returns the IDs of
+// the steps that were running when the workflow was cancelled.
+func (w *Workflow) Cancel() []string {
+	now := time.Now()
+	cancelledSteps := make([]string, 0)
+
+	for _, stepID := range w.StepOrder {
+		step := w.Steps[stepID]
+
+		switch step.Status {
+		case StepStatusRunning:
+			step.Status = StepStatusCancelled
+			step.ErrorMessage = "Cancelled because the workflow was cancelled"
+			step.CompletedAt = &now
+			cancelledSteps = append(cancelledSteps, stepID)
+
+		case StepStatusPending:
+			step.Status = StepStatusSkipped
+			step.ErrorMessage = "Skipped because the workflow was cancelled"
+		}
+	}
+
+	w.Status = WorkflowStatusCancelled
+	w.FinishedAt = &now
+
+	return cancelledSteps
+}
+
+// StepDelays returns the delay in seconds for each of n steps that became ready
+// together. Without a positive step_stagger_seconds in the metadata all delays
+// are zero. Otherwise step i is delayed by i*stagger plus a random jitter of up
+// to stagger seconds, capped at max_stagger_seconds when that is set.
+func (w *Workflow) StepDelays(n int) []int {
+	delays := make([]int, n)
+
+	stagger := w.metadataInt(StepStaggerKey)
+	if stagger <= 0 {
+		return delays
+	}
+	maxDelay := w.metadataInt(MaxStaggerKey)
+
+	for i := 1; i < n; i++ {
+		delay := i*stagger + rand.Intn(stagger+1)
+		if maxDelay > 0 && delay > maxDelay {
+			delay = maxDelay
+		}
+		delays[i] = delay
+	}
+
+	return delays
+}
+
+// LimitParallelSteps returns the ready steps that may start now. With a
+// positive max_parallel_steps in the metadata, steps only start while fewer
+// than that many are running, in the order given, and the others wait for a
+// running step to finish. Without it every ready step may start.
+func (w *Workflow) LimitParallelSteps(ready []*WorkflowStep) []*WorkflowStep {
+	limit := w.metadataInt(MaxParallelStepsKey)
+	if limit <= 0 {
+		return ready
+	}
+
+	running := 0
+	for _, step := range w.Steps {
+		if step.Status == StepStatusRunning {
+			running++
+		}
+	}
+
+	slots := limit - running
+	if slots <= 0 {
+		return nil
+	}
+	if len(ready) > slots {
+		return ready[:slots]
+	}
+	return ready
+}
+
+// metadataInt returns a numeric metadata value, or 0 if it is missing or not a number
+func (w *Workflow) metadataInt(key string) int {
+	switch v := w.Metadata[key].(type) {
+	case float64:
+		return int(v)
+	case int:
+		return v
+	default:
+		return 0
+	}
+}
+
+// IsTerminal reports whether the workflow has finished, successfully or not
+func (w *Workflow) IsTerminal() bool {
+	return w.Status == WorkflowStatusCompleted || w.Status == WorkflowStatusFailed ||
+		w.Status == WorkflowStatusCancelled
+}
+
+// StepTaskID returns the ID of the task that runs a workflow step
+func StepTaskID(workflowID, stepID string) string {
+	return fmt.Sprintf("%s-%s", workflowID, stepID)
+}
+
 // ToJSON serializes the workflow to JSON
 func (w *Workflow) ToJSON() (string, error) {
 	bytes, err := json.Marshal(w)
@@ -8,6 +8,7 @@ import (
 	"sync"
 	"time"
 
+	"BoltQ/internal/queue"
 	"BoltQ/pkg/logger"
 
 	"github.com/go-redis/redis/v8"
@@ -20,19 +21,22 @@ const (
 	workflowStatusKey  = "workflow_status"
 	workflowStepKey    = "workflow_step:"
 	workflowResultsKey = "workflow_results:"
+	workflowWebhookKey = "workflow_webhook:"
 	workflowTTL        = 72 * time.Hour
 )
 
 // WorkflowManager handles workflow operations and persistence
 type WorkflowManager struct {
-	redisClient *redis.Client
-	logger      *logger.Logger
-	ctx         context.Context
-	mu          sync.Mutex
+	redisClient      queue.RedisClient
+	logger           *logger.Logger
+	ctx              context.Context
+	archiveRetention time.Duration
+	archiveMax       int
+	mu               sync.Mutex
 }
 
 // NewWorkflowManager creates a new workflow manager
-func NewWorkflowManager(client *redis.Client, logger *logger.Logger) *WorkflowManager {
+func NewWorkflowManager(client queue.RedisClient, logger *logger.Logger) *WorkflowManager {
 	return &WorkflowManager{
 		redisClient: client,
 		logger:      logger,
@@ -45,6 +49,11 @@ func (wm *WorkflowManager) SaveWorkflow(workflow *Workflow) error {
 	wm.mu.Lock()
 	defer wm.mu.Unlock()
 
+	return wm.saveWorkflowLocked(workflow)
+}
+
+// saveWorkflowLocked stores a workflow in Redis; callers must hold wm.mu
+func (wm *WorkflowManager) saveWorkflowLocked(workflow *Workflow) error {
 	// Convert workflow to JSON
 	workflowJSON, err := workflow.ToJSON()
 	if err != nil {
@@ -74,6 +83,9 @@ func (wm *WorkflowManager) SaveWorkflow(workflow *Workflow) error {
 	}
 
 	wm.logger.Info(fmt.Sprintf("Saved workflow %s with status %s", workflow.ID, workflow.Status))
+
+	// Keep finished workflows beyond the workflow TTL if the archive is enabled
+	wm.archiveLocked(workflow)
 	return nil
 }
 
@@ -98,6 +110,37 @@ func (wm *WorkflowManager) GetWorkflow(workflowID string) (*Workflow, error) {
 	return workflow, nil
 }
 
+// GetWorkflowProgress returns the status of a workflow and of each of its
+// steps, in step order, leaving out params and results
+func (wm *WorkflowManager) GetWorkflowProgress(workflowID string) (*WorkflowProgress, error) {
+	workflow, err := wm.GetWorkflow(workflowID)
+	if err != nil {
+		return nil, err
+	}
+
+	progress := &WorkflowProgress{
+		WorkflowID: workflow.ID,
+		Status:     workflow.Status,
+		Steps:      make([]WorkflowStepSummary, 0, len(workflow.StepOrder)),
+	}
+	for _, stepID := range workflow.StepOrder {
+		step, exists := workflow.Steps[stepID]
+		if !exists {
+			continue
+		}
+		progress.Steps = append(progress.Steps, WorkflowStepSummary{
+			ID:           step.ID,
+			JobType:      step.JobType,
+			Status:       step.Status,
+			ErrorMessage: step.ErrorMessage,
+			StartedAt:    step.StartedAt,
+			CompletedAt:  step.CompletedAt,
+		})
+	}
+
+	return progress, nil
+}
+
 // GetNextWorkflow gets the next pending workflow from the queue
 func (wm *WorkflowManager) GetNextWorkflow() (*Workflow, error) {
 	wm.mu.Lock()
@@ -118,6 +161,116 @@ func (wm *WorkflowManager) GetNextWorkflow() (*Workflow, error) {
 	return wm.GetWorkflow(workflowID)
 }
 
+// CompleteStep records the outcome of a workflow step's task. On success the
+// step result is stored so dependent steps can consume it. The workflow is
+// then queued again so the workflow processor can start newly ready steps or
+// finish the workflow.
+func (wm *WorkflowManager) CompleteStep(workflowID, stepID string, result map[string]interface{}, stepErr error) error {
+	wm.mu.Lock()
+	defer wm.mu.Unlock()
+
+	workflow, err := wm.GetWorkflow(workflowID)
+	if err != nil {
+		return err
+	}
+
+	// Steps still finishing after a cancellation keep their cancelled status
+	if workflow.Status == WorkflowStatusCancelled {
+		wm.logger.Info(fmt.Sprintf("Ignoring completion of step %s of cancelled workflow %s", stepID, workflowID))
+		return nil
+	}
+
+	if stepErr != nil {
+		err = workflow.UpdateStepStatus(stepID, StepStatusFailed, stepErr.Error(), nil)
+	} else {
+		err = workflow.UpdateStepStatus(stepID, StepStatusCompleted, "", result)
+	}
+	if err != nil {
+		return err
+	}
+
+	if stepErr == nil && result != nil {
+		if err := wm.SaveStepResult(workflowID, stepID, result); err != nil {
+			return err
+		}
+	}
+
+	if err := wm.saveWorkflowLocked(workflow); err != nil {
+		return err
+	}
+
+	// Queue the workflow again so its next steps get scheduled
+	if err := wm.redisClient.LPush(wm.ctx, workflowQueueKey, workflowID).Err(); err != nil {
+		return fmt.Errorf("error adding workflow to queue: %v", err)
+	}
+
+	return nil
+}
+
+// CancelWorkflow cancels a workflow that has not finished yet. Pending steps are
+// skipped and running steps marked cancelled, and the workflow processor will
+// not enqueue any further steps. It returns the IDs of the tasks of steps that
+// were running so the caller can signal them to stop.
+func (wm *WorkflowManager) CancelWorkflow(workflowID string) ([]string, error) {
+	wm.mu.Lock()
+	defer wm.mu.Unlock()
+
+	workflow, err := wm.GetWorkflow(workflowID)
+	if err != nil {
+		return nil, err
+	}
+
+	if workflow.IsTerminal() {
+		return nil, fmt.Errorf("workflow %s is already %s", workflowID, workflow.Status)
+	}
+
+	cancelledSteps := workflow.Cancel()
+
+	if err := wm.saveWorkflowLocked(workflow); err != nil {
+		return nil, err
+	}
+
+	// Queue the workflow so the workflow processor reports its final state
+	if err := wm.redisClient.LPush(wm.ctx, workflowQueueKey, workflowID).Err(); err != nil {
+		wm.logger.Error(fmt.Sprintf("Error adding cancelled workflow %s to queue: %v", workflowID, err))
+	}
+
+	taskIDs := make([]string, 0, len(cancelledSteps))
+	for _, stepID := range cancelledSteps {
+		taskIDs = append(taskIDs, StepTaskID(workflowID, stepID))
+	}
+
+	wm.logger.Info(fmt.Sprintf("Cancelled workflow %s with %d running steps", workflowID, len(taskIDs)))
+	return taskIDs, nil
+}
+
+// ClaimCompletionWebhook reports whether the caller is the first to deliver
+// the completion webhook of a workflow. A finished workflow may be processed
+// more than once, e.g. when steps still running after a failure report back.
+func (wm *WorkflowManager) ClaimCompletionWebhook(workflowID string) (bool, error) {
+	key := fmt.Sprintf("%s%s", workflowWebhookKey, workflowID)
+	claimed, err := wm.redisClient.SetNX(wm.ctx, key, time.Now().Unix(), workflowTTL).Result()
+	if err != nil {
+		return false, fmt.Errorf("error claiming completion webhook: %v", err)
+	}
+	return claimed, nil
+}
+
+// RecordCompletionWebhookFailure stores why the completion webhook of a
+// workflow could not be delivered, so it shows in the workflow's status
+func (wm *WorkflowManager) RecordCompletionWebhookFailure(workflowID string, webhookErr error) error {
+	wm.mu.Lock()
+	defer wm.mu.Unlock()
+
+	workflow, err := wm.GetWorkflow(workflowID)
+	if err != nil {
+		return err
+	}
+
+	workflow.CompletionWebhookError = webhookErr.Error()
+	return wm.saveWorkflowLocked(workflow)
+}
+
 // SaveStepResult stores a step's result in Redis
 func (wm *WorkflowManager) SaveStepResult(workflowID, stepID string, result map[string]interface{}) error {
 	resultKey := fmt.Sprintf("%s%s:%s", workflowResultsKey, workflowID, stepID)
@@ -181,27 +334,31 @@ func (wm *WorkflowManager) ListWorkflows(limit, offset int) ([]map[string]interf
 			continue
 		}
 
-		// Create summarized info
-		summary := map[string]interface{}{
-			"id":         workflow.ID,
-			"name":       workflow.Name,
-			"status":     workflow.Status,
-			"created_at": workflow.CreatedAt,
-			"step_count": len(workflow.Steps),
-		}
+		workflows = append(workflows, workflowSummary(workflow))
+	}
 
-		if workflow.StartedAt != nil {
-			summary["started_at"] = workflow.StartedAt
-		}
+	return workflows, nil
+}
 
-		if workflow.FinishedAt != nil {
-			summary["finished_at"] = workflow.FinishedAt
-		}
+// workflowSummary returns the fields of a workflow shown in listings
+func workflowSummary(workflow *Workflow) map[string]interface{} {
+	summary := map[string]interface{}{
+		"id":         workflow.ID,
+		"name":       workflow.Name,
+		"status":     workflow.Status,
+		"created_at": workflow.CreatedAt,
+		"step_count": len(workflow.Steps),
+	}
 
-		workflows = append(workflows, summary)
+	if workflow.StartedAt != nil {
+		summary["started_at"] = workflow.StartedAt
 	}
 
-	return workflows, nil
+	if workflow.FinishedAt != nil {
+		summary["finished_at"] = workflow.FinishedAt
+	}
+
+	return summary
 }
 
 // DeleteWorkflow removes a workflow and its data from Redis
@@ -238,6 +395,12 @@ func (wm *WorkflowManager) DeleteWorkflow(workflowID string) error {
 		}
 	}
 
+	// Delete the completion webhook marker
+	webhookKey := fmt.Sprintf("%s%s", workflowWebhookKey, workflowID)
+	if err := wm.redisClient.Del(wm.ctx, webhookKey).Err(); err != nil {
+		wm.logger.Error(fmt.Sprintf("Error deleting completion webhook marker: %v", err))
+	}
+
 	wm.logger.Info(fmt.Sprintf("Deleted workflow %s", workflowID))
 	return nil
 }
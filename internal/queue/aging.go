@@ -0,0 +1,107 @@
+// internal/queue/aging.go
+package queue
+
+import (
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// DefaultAgingBatchSize is how many of the oldest tasks per queue a promotion sweep looks at
+const DefaultAgingBatchSize = 100
+
+// moveTaskScript moves a serialized task from one queue to the publishing end
+// of another, replacing it with its updated form, so that it queues behind the
+// tasks already waiting there. It returns 0 if the task was already consumed
+// or moved by someone else.
+var moveTaskScript = redis.NewScript(`
+if redis.call("LREM", KEYS[1], 1, ARGV[1]) == 0 then
+	return 0
+end
+redis.call("LPUSH", KEYS[2], ARGV[2])
+return 1
+`)
+
+// PromoteAgedTasks moves tasks that have waited longer than maxAge in a queue
+// below targetPriority to the targetPriority queue, behind the tasks already
+// waiting there. Tag queues are promoted within their tag for every tag a
+// live worker pool consumes. At most batchSize of the oldest tasks of each
+// queue are examined. It returns the number of promoted tasks by their
+// original priority.
+func (q *RedisQueue) PromoteAgedTasks(maxAge time.Duration, targetPriority, batchSize int) (map[int]int, error) {
+	targetPriority = NormalizePriority(targetPriority)
+	cutoff := time.Now().Add(-maxAge)
+	promoted := make(map[int]int)
+
+	tags, err := q.ConsumedTags(WorkerHeartbeatTTL)
+	if err != nil {
+		return promoted, err
+	}
+
+	for priority := MinPriority; priority < targetPriority; priority++ {
+		queueNames := map[string]string{getQueueName(priority): getQueueName(targetPriority)}
+		for _, tag := range tags {
+			queueNames[getTagQueueName(tag, priority)] = getTagQueueName(tag, targetPriority)
+		}
+
+		for source, target := range queueNames {
+			count, err := q.promoteAgedTasks(source, target, targetPriority, cutoff, batchSize)
+			promoted[priority] += count
+			if err != nil {
+				return promoted, err
+			}
+		}
+	}
+
+	return promoted, nil
+}
+
+// promoteAgedTasks moves the tasks created before cutoff from the source queue
+// to the target queue, oldest first, and returns how many it moved
+func (q *RedisQueue) promoteAgedTasks(source, target string, targetPriority int, cutoff time.Time, batchSize int) (int, error) {
+	sourceQueue, targetQueue := queueKey(source), queueKey(target)
+	promoted := 0
+
+	// Tasks are pushed on the left and consumed from the right, so the
+	// oldest tasks sit at the right end of the list
+	taskJSONs, err := q.client.LRange(ctx, sourceQueue, int64(-batchSize), -1).Result()
+	if err != nil {
+		return promoted, err
+	}
+
+	for i := len(taskJSONs) - 1; i >= 0; i-- {
+		var task Task
+		if err := q.decode([]byte(taskJSONs[i]), &task); err != nil {
+			q.logger.Error("Error decoding queued task during aging: " + err.Error())
+			continue
+		}
+
+		if task.CreatedAt.After(cutoff) {
+			break
+		}
+
+		fromPriority := task.Priority
+		task.Priority = targetPriority
+		promotedJSON, err := q.encode(&task)
+		if err != nil {
+			return promoted, err
+		}
+
+		moved, err := moveTaskScript.Run(ctx, q.client, []string{sourceQueue, targetQueue}, taskJSONs[i], string(promotedJSON)).Int()
+		if err != nil {
+			return promoted, err
+		}
+
+		if moved == 1 {
+			promoted++
+			q.logger.Info("Promoted aged task", map[string]interface{}{
+				"task_id":       task.ID,
+				"queue":         source,
+				"from_priority": fromPriority,
+				"to_priority":   targetPriority,
+			})
+		}
+	}
+
+	return promoted, nil
+}
@@ -0,0 +1,55 @@
+package queue
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPromoteAgedTasks(t *testing.T) {
+	q, _ := newTestQueue(t)
+
+	publish := func(id string, priority int, tags ...string) {
+		t.Helper()
+		if err := q.Publish(&Task{ID: id, Type: "test", Priority: priority, Tags: tags}); err != nil {
+			t.Fatalf("Publish(%s): %v", id, err)
+		}
+	}
+
+	publish("high", PriorityHigh)
+	publish("old-low", PriorityLow)
+	publish("old-gpu", PriorityLow, "gpu")
+	if err := q.RecordWorkerTags([]string{"gpu"}); err != nil {
+		t.Fatalf("RecordWorkerTags: %v", err)
+	}
+
+	// Only tasks created before the cutoff are promoted
+	time.Sleep(20 * time.Millisecond)
+	publish("new-low", PriorityLow)
+
+	promoted, err := q.PromoteAgedTasks(10*time.Millisecond, PriorityHigh, DefaultAgingBatchSize)
+	if err != nil {
+		t.Fatalf("PromoteAgedTasks: %v", err)
+	}
+	if promoted[PriorityLow] != 2 {
+		t.Errorf("promoted = %v, want 2 low priority tasks", promoted)
+	}
+
+	// Promoted tasks queue behind the tasks already at the target priority
+	for _, want := range []string{"high", "old-low", "new-low"} {
+		task, err := q.Consume()
+		if err != nil {
+			t.Fatalf("Consume: %v", err)
+		}
+		if task.ID != want {
+			t.Fatalf("Consume = %s, want %s", task.ID, want)
+		}
+	}
+
+	task, err := q.ConsumeTag("worker-1", "gpu", []int{PriorityHigh})
+	if err != nil {
+		t.Fatalf("ConsumeTag: %v", err)
+	}
+	if task.ID != "old-gpu" || task.Priority != PriorityHigh {
+		t.Errorf("ConsumeTag = %s at priority %d, want old-gpu at %d", task.ID, task.Priority, PriorityHigh)
+	}
+}
@@ -0,0 +1,31 @@
+// internal/queue/attempts.go
+package queue
+
+import "time"
+
+// MaxAttemptHistory is the number of failed attempts kept per task; older
+// records are dropped first
+const MaxAttemptHistory = 10
+
+// AttemptRecord describes a single failed processing attempt of a task
+type AttemptRecord struct {
+	Attempt   int       `json:"attempt"`
+	Timestamp time.Time `json:"timestamp"`
+	Error     string    `json:"error"`
+	WorkerID  string    `json:"worker_id,omitempty"`
+}
+
+// RecordAttempt appends a failed attempt to the task's history, keeping only
+// the most recent MaxAttemptHistory records
+func (t *Task) RecordAttempt(err error) {
+	t.AttemptHistory = append(t.AttemptHistory, AttemptRecord{
+		Attempt:   t.Attempts + 1,
+		Timestamp: time.Now(),
+		Error:     err.Error(),
+		WorkerID:  t.WorkerID,
+	})
+
+	if len(t.AttemptHistory) > MaxAttemptHistory {
+		t.AttemptHistory = t.AttemptHistory[len(t.AttemptHistory)-MaxAttemptHistory:]
+	}
+}
@@ -0,0 +1,98 @@
+// internal/queue/audit.go
+package queue
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	// AuditKeyPrefix is the prefix of the per-task audit streams
+	AuditKeyPrefix = "audit"
+
+	// auditRetention is how long an audit stream is kept after its last event
+	auditRetention = 7 * 24 * time.Hour
+)
+
+// AuditEvent records a single status transition of a task
+type AuditEvent struct {
+	ID        string    `json:"id"`
+	TaskID    string    `json:"task_id"`
+	OldStatus string    `json:"old_status,omitempty"`
+	NewStatus string    `json:"new_status"`
+	WorkerID  string    `json:"worker_id,omitempty"`
+	Attempts  int       `json:"attempts"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// AuditLog appends task status transitions to a Redis stream per task
+type AuditLog struct {
+	client RedisClient
+}
+
+// NewAuditLog creates a new audit log
+func NewAuditLog(client RedisClient) *AuditLog {
+	return &AuditLog{client: client}
+}
+
+// Record appends a transition event to the task's audit stream
+func (a *AuditLog) Record(task *Task, oldStatus string) error {
+	key := getAuditKey(task.ID)
+
+	pipe := a.client.TxPipeline()
+	pipe.XAdd(ctx, &redis.XAddArgs{
+		Stream: key,
+		Values: map[string]interface{}{
+			"old_status": oldStatus,
+			"new_status": task.Status,
+			"worker_id":  task.WorkerID,
+			"attempts":   task.Attempts,
+			"timestamp":  time.Now().UTC().Format(time.RFC3339Nano),
+		},
+	})
+	pipe.Expire(ctx, key, auditRetention)
+
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// History returns all recorded events for a task, oldest first
+func (a *AuditLog) History(taskID string) ([]AuditEvent, error) {
+	messages, err := a.client.XRange(ctx, getAuditKey(taskID), "-", "+").Result()
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]AuditEvent, 0, len(messages))
+	for _, msg := range messages {
+		event := AuditEvent{
+			ID:        msg.ID,
+			TaskID:    taskID,
+			OldStatus: streamString(msg.Values, "old_status"),
+			NewStatus: streamString(msg.Values, "new_status"),
+			WorkerID:  streamString(msg.Values, "worker_id"),
+		}
+		event.Attempts, _ = strconv.Atoi(streamString(msg.Values, "attempts"))
+		event.Timestamp, _ = time.Parse(time.RFC3339Nano, streamString(msg.Values, "timestamp"))
+
+		events = append(events, event)
+	}
+
+	return events, nil
+}
+
+// Helper function to get the audit stream key for a task
+func getAuditKey(taskID string) string {
+	return fmt.Sprintf("%s:%s", AuditKeyPrefix, taskID)
+}
+
+// streamString reads a string field from a stream message
+func streamString(values map[string]interface{}, field string) string {
+	if v, ok := values[field].(string); ok {
+		return v
+	}
+	return ""
+}
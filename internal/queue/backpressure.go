@@ -0,0 +1,80 @@
+// internal/queue/backpressure.go
+package queue
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// ErrQueueFull is returned by Publish when a priority queue has reached its
+// maximum length and the overflow policy is OverflowReject
+var ErrQueueFull = errors.New("queue is full")
+
+// OverflowPolicy decides what happens when a queue reaches its maximum length
+type OverflowPolicy string
+
+const (
+	// OverflowReject rejects new tasks with ErrQueueFull
+	OverflowReject OverflowPolicy = "reject"
+
+	// OverflowDropOldest accepts new tasks and drops the oldest queued ones
+	OverflowDropOldest OverflowPolicy = "drop_oldest"
+)
+
+// ParseOverflowPolicy parses an overflow policy name
+func ParseOverflowPolicy(name string) (OverflowPolicy, error) {
+	switch OverflowPolicy(name) {
+	case OverflowReject, OverflowDropOldest:
+		return OverflowPolicy(name), nil
+	default:
+		return "", fmt.Errorf("unknown overflow policy: %s", name)
+	}
+}
+
+// pushIfNotFullScript pushes a task only if the list is below the maximum length.
+// It returns the new length, or -1 if the list is full.
+var pushIfNotFullScript = redis.NewScript(`
+local len = redis.call("LLEN", KEYS[1])
+if len >= tonumber(ARGV[2]) then
+	return -1
+end
+return redis.call("LPUSH", KEYS[1], ARGV[1])
+`)
+
+// pushWithBackpressure pushes a serialized task onto a priority queue while
+// enforcing the configured maximum length
+func (q *RedisQueue) pushWithBackpressure(queueName, taskJSON string) error {
+	switch q.overflowPolicy {
+	case OverflowDropOldest:
+		pipe := q.client.TxPipeline()
+		lengthCmd := pipe.LPush(ctx, queueKey(queueName), taskJSON)
+		pipe.LTrim(ctx, queueKey(queueName), 0, q.maxQueueLength-1)
+		if _, err := pipe.Exec(ctx); err != nil {
+			return err
+		}
+
+		if dropped := lengthCmd.Val() - q.maxQueueLength; dropped > 0 {
+			if q.metrics != nil {
+				q.metrics.RecordBackpressure(queueName, string(OverflowDropOldest), int(dropped))
+			}
+			q.logger.Info(fmt.Sprintf("Queue %s is full, dropped %d oldest tasks", queueName, dropped))
+		}
+		return nil
+
+	default:
+		length, err := pushIfNotFullScript.Run(ctx, q.client, []string{queueKey(queueName)}, taskJSON, q.maxQueueLength).Int64()
+		if err != nil {
+			return err
+		}
+
+		if length < 0 {
+			if q.metrics != nil {
+				q.metrics.RecordBackpressure(queueName, string(OverflowReject), 1)
+			}
+			return fmt.Errorf("%w: %s has reached %d tasks", ErrQueueFull, queueName, q.maxQueueLength)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,102 @@
+// internal/queue/batch.go
+package queue
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// batchPollInterval is how often FillBatch looks for more tasks while waiting
+const batchPollInterval = 50 * time.Millisecond
+
+// popIfNextScript pops the next task of a queue only if it is still the given
+// serialized task. ARGV[2] is the queue order. It returns nil if another
+// consumer got there first.
+var popIfNextScript = redis.NewScript(`
+local index, pop = -1, "RPOP"
+if ARGV[2] == "lifo" then
+	index, pop = 0, "LPOP"
+end
+if redis.call("LINDEX", KEYS[1], index) ~= ARGV[1] then
+	return false
+end
+return redis.call(pop, KEYS[1])
+`)
+
+// ConsumeBatch retrieves up to maxItems tasks of the same type. The type is
+// that of the first task consumed, checking high priority first; more tasks
+// are taken while they are next in the same queue, waiting up to maxWait for
+// them to arrive. It returns redis.Nil if all queues are empty.
+func (q *RedisQueue) ConsumeBatch(maxItems int, maxWait time.Duration) ([]*Task, error) {
+	first, err := q.Consume()
+	if err != nil {
+		return nil, err
+	}
+
+	return q.FillBatch(first, maxItems, maxWait)
+}
+
+// FillBatch starts a batch with an already consumed task and adds up to
+// maxItems-1 more tasks of its type, taken while they are next in the queue
+// the first task came from. A task of another type at the head of the queue
+// ends the batch early instead of being skipped, so no task loses its place.
+// Until maxWait has passed, an empty queue is polled for more tasks. The
+// added tasks are run by the worker of the first task.
+func (q *RedisQueue) FillBatch(first *Task, maxItems int, maxWait time.Duration) ([]*Task, error) {
+	batch := []*Task{first}
+	queueName := taskQueueName(first)
+	order := q.queueOrder(queueName)
+	deadline := time.Now().Add(maxWait)
+
+	for len(batch) < maxItems {
+		taskJSON, err := q.client.LIndex(ctx, queueKey(queueName), q.nextIndex(queueName)).Result()
+		if err != nil && err != redis.Nil {
+			return batch, err
+		}
+
+		if err == redis.Nil {
+			// Nothing queued yet, wait for more tasks while there is time
+			remaining := time.Until(deadline)
+			if remaining <= 0 {
+				break
+			}
+			time.Sleep(min(batchPollInterval, remaining))
+			continue
+		}
+
+		var task Task
+		if err := q.decode([]byte(taskJSON), &task); err != nil {
+			return batch, fmt.Errorf("error decoding queued task: %v", err)
+		}
+		if task.Type != first.Type {
+			break
+		}
+
+		popped, err := popIfNextScript.Run(ctx, q.client, []string{queueKey(queueName)}, taskJSON, string(order)).Result()
+		if err == redis.Nil || popped == nil {
+			// Another consumer took it; look at the new head
+			continue
+		}
+		if err != nil {
+			return batch, err
+		}
+
+		task.Status = "running"
+		task.WorkerID = first.WorkerID
+		err = q.UpdateStatus(&task)
+		if errors.Is(err, ErrInvalidTransition) {
+			q.logger.Info(fmt.Sprintf("Skipping task %s: %v", task.ID, err))
+			continue
+		}
+		if err != nil {
+			q.logger.Info(fmt.Sprintf("Failed to update status for task %s: %v", task.ID, err))
+		}
+
+		batch = append(batch, &task)
+	}
+
+	return batch, nil
+}
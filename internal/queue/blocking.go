@@ -0,0 +1,68 @@
+// internal/queue/blocking.go
+package queue
+
+import (
+	"fmt"
+	"time"
+)
+
+// ConsumeQueueNames returns the queues a consumer of the given tags and
+// priorities checks, in the order it checks them: the priority queues if no
+// tags are given, otherwise the queues of every tag at each priority
+func ConsumeQueueNames(tags []string, priorities []int) []string {
+	if len(tags) == 0 {
+		return priorityQueueNames(priorities)
+	}
+
+	var queueNames []string
+	for _, priority := range consumeOrder(priorities) {
+		for _, tag := range tags {
+			queueNames = append(queueNames, getTagQueueName(tag, priority))
+		}
+	}
+	return queueNames
+}
+
+// ConsumeBlocking waits up to timeout for a task to be pushed to one of the
+// given queues and consumes it for the given worker. It is meant for consumers that just found
+// those queues empty: a task that is published, retried or promoted from the
+// delayed set wakes a waiting consumer right away rather than at its next
+// poll, since all of them are pushed to the same lists. If several queues
+// have a task, the first in the given order wins. A LIFO queue among FIFO
+// ones doesn't wake the consumer, so its tasks wait for the next regular
+// consume. It returns redis.Nil if no task arrived in time.
+func (q *RedisQueue) ConsumeBlocking(workerID string, queueNames []string, timeout time.Duration) (task *Task, err error) {
+	ctx, span := startSpan(ctx, "RedisQueue.ConsumeBlocking")
+	defer func() { endConsumeSpan(ctx, span, task, err) }()
+
+	// A blocking pop takes from one end of every list it watches, so watch
+	// the FIFO queues, or the LIFO ones if there are no others
+	var fifo, lifo []string
+	queueNamesByKey := make(map[string]string, len(queueNames))
+	for _, queueName := range queueNames {
+		key := queueKey(queueName)
+		queueNamesByKey[key] = queueName
+		if q.queueOrder(queueName) == OrderLIFO {
+			lifo = append(lifo, key)
+		} else {
+			fifo = append(fifo, key)
+		}
+	}
+
+	return q.consumeWith(ctx, workerID, func() (string, string, error) {
+		var result []string
+		var err error
+		if len(fifo) > 0 {
+			result, err = q.client.BRPop(ctx, timeout, fifo...).Result()
+		} else {
+			result, err = q.client.BLPop(ctx, timeout, lifo...).Result()
+		}
+		if err != nil {
+			return "", "", err
+		}
+		if len(result) != 2 {
+			return "", "", fmt.Errorf("unexpected blocking pop result: %v", result)
+		}
+		return queueNamesByKey[result[0]], result[1], nil
+	})
+}
@@ -0,0 +1,66 @@
+package queue
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+func TestConsumeBlockingDeliversPublishedTask(t *testing.T) {
+	q, _ := newTestQueue(t)
+
+	type consumed struct {
+		task *Task
+		err  error
+		at   time.Time
+	}
+	done := make(chan consumed, 1)
+	go func() {
+		task, err := q.ConsumeBlocking("worker-1", ConsumeQueueNames(nil, nil), 10*time.Second)
+		done <- consumed{task, err, time.Now()}
+	}()
+
+	// Let the consumer start waiting before publishing
+	time.Sleep(100 * time.Millisecond)
+	select {
+	case got := <-done:
+		t.Fatalf("ConsumeBlocking returned before a task was published: %v, %v", got.task, got.err)
+	default:
+	}
+
+	publishedAt := time.Now()
+	if err := q.Publish(&Task{ID: "task-1", Type: "test", Priority: PriorityLow}); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+
+	select {
+	case got := <-done:
+		if got.err != nil {
+			t.Fatalf("ConsumeBlocking: %v", got.err)
+		}
+		if got.task.ID != "task-1" || got.task.Status != "running" || got.task.WorkerID != "worker-1" {
+			t.Errorf("ConsumeBlocking = %s (%s, worker %q), want task-1 running on worker-1",
+				got.task.ID, got.task.Status, got.task.WorkerID)
+		}
+		if latency := got.at.Sub(publishedAt); latency > time.Second {
+			t.Errorf("task delivered %s after it was published, want well under the 10s timeout", latency)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("ConsumeBlocking did not deliver the published task")
+	}
+}
+
+func TestConsumeBlockingTimeout(t *testing.T) {
+	q, _ := newTestQueue(t)
+
+	start := time.Now()
+	_, err := q.ConsumeBlocking("worker-1", ConsumeQueueNames(nil, nil), time.Second)
+	if !errors.Is(err, redis.Nil) {
+		t.Fatalf("ConsumeBlocking on empty queues = %v, want redis.Nil", err)
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("ConsumeBlocking waited %s, want about the 1s timeout", elapsed)
+	}
+}
@@ -0,0 +1,102 @@
+// internal/queue/client.go
+package queue
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Redis deployment modes accepted in the "mode" config entry
+const (
+	RedisModeSingle   = "single"
+	RedisModeSentinel = "sentinel"
+	RedisModeCluster  = "cluster"
+)
+
+// NewRedisClient creates a Redis client from a config map. Recognized entries:
+//
+//	mode            single (default), sentinel or cluster
+//	addr            address of a single Redis server (default localhost:6379)
+//	master_name     Sentinel master name (sentinel mode)
+//	sentinel_addrs  comma-separated Sentinel addresses (sentinel mode)
+//	cluster_addrs   comma-separated cluster node addresses (cluster mode)
+//	password        Redis password
+//	db              database number (not supported in cluster mode)
+func NewRedisClient(config map[string]string) (redis.UniversalClient, error) {
+	db := 0
+	if value := config["db"]; value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redis db %q: %v", value, err)
+		}
+		db = parsed
+	}
+
+	switch mode := config["mode"]; mode {
+	case "", RedisModeSingle:
+		addr := config["addr"]
+		if addr == "" {
+			addr = "localhost:6379"
+		}
+		return redis.NewClient(&redis.Options{
+			Addr:     addr,
+			Password: config["password"],
+			DB:       db,
+		}), nil
+
+	case RedisModeSentinel:
+		sentinelAddrs := splitAddrs(config["sentinel_addrs"])
+		if config["master_name"] == "" || len(sentinelAddrs) == 0 {
+			return nil, fmt.Errorf("sentinel mode requires master_name and sentinel_addrs")
+		}
+		return redis.NewFailoverClient(&redis.FailoverOptions{
+			MasterName:    config["master_name"],
+			SentinelAddrs: sentinelAddrs,
+			Password:      config["password"],
+			DB:            db,
+		}), nil
+
+	case RedisModeCluster:
+		clusterAddrs := splitAddrs(config["cluster_addrs"])
+		if len(clusterAddrs) == 0 {
+			return nil, fmt.Errorf("cluster mode requires cluster_addrs")
+		}
+		return redis.NewClusterClient(&redis.ClusterOptions{
+			Addrs:    clusterAddrs,
+			Password: config["password"],
+		}), nil
+
+	default:
+		return nil, fmt.Errorf("unknown redis mode: %s", mode)
+	}
+}
+
+// splitAddrs parses a comma-separated address list, skipping empty entries
+func splitAddrs(value string) []string {
+	addrs := make([]string, 0)
+	for _, addr := range strings.Split(value, ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			addrs = append(addrs, addr)
+		}
+	}
+	return addrs
+}
+
+// RedisAddrDescription returns a human-readable description of the configured
+// Redis endpoint for log messages
+func RedisAddrDescription(config map[string]string) string {
+	switch config["mode"] {
+	case RedisModeSentinel:
+		return fmt.Sprintf("sentinel master %s via %s", config["master_name"], config["sentinel_addrs"])
+	case RedisModeCluster:
+		return fmt.Sprintf("cluster %s", config["cluster_addrs"])
+	default:
+		if config["addr"] == "" {
+			return "localhost:6379"
+		}
+		return config["addr"]
+	}
+}
@@ -0,0 +1,80 @@
+// internal/queue/convert.go
+package queue
+
+import "time"
+
+// TaskFromJob converts a Queue interface Job into the Task stored by RedisQueue.
+// The payload is deep-copied, including nested maps and slices, so that later
+// changes to the task data (for example a processor storing its result) never
+// leak back into the caller's job.
+func TaskFromJob(job *Job) *Task {
+	if job == nil {
+		return nil
+	}
+
+	return &Task{
+		ID:          job.ID,
+		Type:        job.Type,
+		Data:        copyPayload(job.Payload),
+		Priority:    job.Priority,
+		CreatedAt:   job.CreatedAt,
+		ScheduledAt: job.ScheduledAt,
+		Status:      string(job.Status),
+		Attempts:    job.Attempts,
+		LastError:   job.Error,
+	}
+}
+
+// JobFromTask converts a stored Task back into a Queue interface Job.
+// The task data is deep-copied into the job payload unchanged.
+func JobFromTask(task *Task) *Job {
+	if task == nil {
+		return nil
+	}
+
+	return &Job{
+		ID:          task.ID,
+		Type:        task.Type,
+		Payload:     copyPayload(task.Data),
+		Priority:    task.Priority,
+		ScheduledAt: task.ScheduledAt,
+		Status:      JobStatus(task.Status),
+		Attempts:    task.Attempts,
+		Error:       task.LastError,
+		CreatedAt:   task.CreatedAt,
+		UpdatedAt:   time.Now(),
+	}
+}
+
+// copyPayload makes a deep copy of a payload map, preserving nil
+func copyPayload(payload map[string]interface{}) map[string]interface{} {
+	if payload == nil {
+		return nil
+	}
+
+	copied := make(map[string]interface{}, len(payload))
+	for k, v := range payload {
+		copied[k] = copyPayloadValue(v)
+	}
+	return copied
+}
+
+// copyPayloadValue deep-copies the maps and slices a decoded payload is made
+// of; other values are immutable or copied by value
+func copyPayloadValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return copyPayload(v)
+	case []interface{}:
+		if v == nil {
+			return v
+		}
+		copied := make([]interface{}, len(v))
+		for i, item := range v {
+			copied[i] = copyPayloadValue(item)
+		}
+		return copied
+	default:
+		return value
+	}
+}
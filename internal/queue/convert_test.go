@@ -0,0 +1,93 @@
+package queue
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestJobTaskRoundTrip(t *testing.T) {
+	created := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		job  *Job
+	}{
+		{
+			name: "full job",
+			job: &Job{
+				ID:   "job-1",
+				Type: "email",
+				Payload: map[string]interface{}{
+					"to":      "a@example.com",
+					"retries": float64(3),
+					"headers": map[string]interface{}{"x-id": "42"},
+					"cc":      []interface{}{"b@example.com", map[string]interface{}{"name": "c"}},
+				},
+				Priority:    PriorityHigh,
+				ScheduledAt: created.Add(time.Minute),
+				Status:      StatusRetrying,
+				Attempts:    2,
+				Error:       "timeout",
+				CreatedAt:   created,
+			},
+		},
+		{
+			name: "nil payload",
+			job:  &Job{ID: "job-2", Type: "noop", Priority: PriorityLow, Status: StatusPending, CreatedAt: created},
+		},
+		{
+			name: "empty payload",
+			job:  &Job{ID: "job-3", Type: "noop", Payload: map[string]interface{}{}, Status: StatusCompleted},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := JobFromTask(TaskFromJob(tt.job))
+
+			// UpdatedAt is set by the conversion
+			if got.UpdatedAt.IsZero() {
+				t.Error("UpdatedAt was not set")
+			}
+			got.UpdatedAt = tt.job.UpdatedAt
+
+			if !reflect.DeepEqual(got, tt.job) {
+				t.Errorf("round trip changed the job\n got: %+v\nwant: %+v", got, tt.job)
+			}
+		})
+	}
+}
+
+func TestTaskFromJobCopiesNestedPayload(t *testing.T) {
+	job := &Job{
+		ID: "job-1",
+		Payload: map[string]interface{}{
+			"headers": map[string]interface{}{"x-id": "42"},
+			"cc":      []interface{}{"b@example.com", map[string]interface{}{"name": "c"}},
+		},
+	}
+
+	task := TaskFromJob(job)
+	task.Data["headers"].(map[string]interface{})["x-id"] = "changed"
+	task.Data["cc"].([]interface{})[0] = "changed"
+	task.Data["cc"].([]interface{})[1].(map[string]interface{})["name"] = "changed"
+	task.Data["result"] = "added"
+
+	want := map[string]interface{}{
+		"headers": map[string]interface{}{"x-id": "42"},
+		"cc":      []interface{}{"b@example.com", map[string]interface{}{"name": "c"}},
+	}
+	if !reflect.DeepEqual(job.Payload, want) {
+		t.Errorf("changes to the task leaked into the job payload: %v", job.Payload)
+	}
+}
+
+func TestConvertNil(t *testing.T) {
+	if TaskFromJob(nil) != nil {
+		t.Error("TaskFromJob(nil) should return nil")
+	}
+	if JobFromTask(nil) != nil {
+		t.Error("JobFromTask(nil) should return nil")
+	}
+}
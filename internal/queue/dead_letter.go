@@ -0,0 +1,172 @@
+// internal/queue/dead_letter.go
+package queue
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	// DeadLetterTasksKey is the sorted set holding dead-lettered tasks scored by
+	// failure time. Queue stats still report it as DeadLetterQueue.
+	DeadLetterTasksKey = "dead_letter_tasks"
+
+	// DefaultDeadLetterRetention is how long dead-lettered tasks are kept by default
+	DefaultDeadLetterRetention = 7 * 24 * time.Hour
+)
+
+// ErrTaskNotDeadLettered is returned by RequeueDeadLetter for a task that is not
+// in the dead letter queue
+var ErrTaskNotDeadLettered = errors.New("task is not in the dead letter queue")
+
+// DeadLetters returns up to limit dead-lettered tasks, most recently failed first
+func (q *RedisQueue) DeadLetters(limit int) ([]*Task, error) {
+	if limit <= 0 {
+		return []*Task{}, nil
+	}
+
+	taskJSONs, err := q.client.ZRevRange(ctx, queueKey(DeadLetterTasksKey), 0, int64(limit-1)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	tasks := make([]*Task, 0, len(taskJSONs))
+	for _, taskJSON := range taskJSONs {
+		var task Task
+		if err := q.decode([]byte(taskJSON), &task); err != nil {
+			q.logger.Error(fmt.Sprintf("Error decoding dead-lettered task: %v", err))
+			continue
+		}
+		tasks = append(tasks, &task)
+	}
+
+	return tasks, nil
+}
+
+// DeadLetter returns a dead-lettered task, or ErrTaskNotDeadLettered if it is
+// not in the dead letter queue
+func (q *RedisQueue) DeadLetter(taskID string) (*Task, error) {
+	_, task, err := q.findDeadLetter(taskID)
+	if err != nil {
+		return nil, err
+	}
+	if task == nil {
+		return nil, ErrTaskNotDeadLettered
+	}
+	return task, nil
+}
+
+// RequeueDeadLetter moves a task from the dead letter queue back to its
+// priority queue with its attempts reset, so it gets the full number of
+// retries again. Its attempt history is kept.
+func (q *RedisQueue) RequeueDeadLetter(taskID string) (*Task, error) {
+	taskJSON, task, err := q.findDeadLetter(taskID)
+	if err != nil {
+		return nil, err
+	}
+	if task == nil {
+		return nil, ErrTaskNotDeadLettered
+	}
+
+	// Remove the entry first so that concurrent requeues don't both publish it
+	removed, err := q.client.ZRem(ctx, queueKey(DeadLetterTasksKey), taskJSON).Result()
+	if err != nil {
+		return nil, err
+	}
+	if removed == 0 {
+		return nil, ErrTaskNotDeadLettered
+	}
+
+	task.Status = string(StatusPending)
+	task.Attempts = 0
+	task.RetryDeadline = nil
+	task.ScheduledAt = time.Now()
+	if err := q.UpdateStatus(task); err != nil {
+		q.logger.Info(fmt.Sprintf("Failed to update status for task %s: %v", task.ID, err))
+	}
+
+	if err := q.publishToQueue(task, taskQueueName(task)); err != nil {
+		// Put the entry back so the task is not lost
+		q.client.ZAdd(ctx, queueKey(DeadLetterTasksKey), &redis.Z{
+			Score:  float64(time.Now().Unix()),
+			Member: taskJSON,
+		})
+		return nil, err
+	}
+
+	q.logger.Info("Requeued dead-lettered task", map[string]interface{}{
+		"task_id": task.ID,
+	})
+	return task, nil
+}
+
+// findDeadLetter looks for a task in the dead letter queue and returns its
+// serialized form along with it. The task is nil if it is not in the queue.
+func (q *RedisQueue) findDeadLetter(taskID string) (string, *Task, error) {
+	taskJSONs, err := q.client.ZRange(ctx, queueKey(DeadLetterTasksKey), 0, -1).Result()
+	if err != nil {
+		return "", nil, err
+	}
+
+	for _, taskJSON := range taskJSONs {
+		var task Task
+		if err := q.decode([]byte(taskJSON), &task); err != nil || task.ID != taskID {
+			continue
+		}
+		return taskJSON, &task, nil
+	}
+
+	return "", nil, nil
+}
+
+// PurgeDeadLetterQueue removes every dead-lettered task and returns how many
+// were removed. Their task records are left to expire.
+func (q *RedisQueue) PurgeDeadLetterQueue() (int64, error) {
+	pipe := q.client.TxPipeline()
+	count := pipe.ZCard(ctx, queueKey(DeadLetterTasksKey))
+	pipe.Del(ctx, queueKey(DeadLetterTasksKey))
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+
+	return count.Val(), nil
+}
+
+// TrimDeadLetterQueue removes dead-lettered tasks that failed longer than
+// retention ago and returns how many were removed
+func (q *RedisQueue) TrimDeadLetterQueue(retention time.Duration) (int64, error) {
+	cutoff := strconv.FormatInt(time.Now().Add(-retention).Unix(), 10)
+	return q.client.ZRemRangeByScore(ctx, queueKey(DeadLetterTasksKey), "-inf", "("+cutoff).Result()
+}
+
+// MigrateLegacyDeadLetterQueue moves tasks from the dead letter list used by
+// earlier versions into the sorted set. Their failure time is unknown, so their
+// retention starts now. It returns the number of migrated tasks.
+func (q *RedisQueue) MigrateLegacyDeadLetterQueue() (int, error) {
+	legacyKey := queueKey(DeadLetterQueue)
+	migrated := 0
+
+	for {
+		taskJSON, err := q.client.RPop(ctx, legacyKey).Result()
+		if err == redis.Nil {
+			return migrated, nil
+		}
+		if err != nil {
+			return migrated, err
+		}
+
+		if err := q.client.ZAdd(ctx, queueKey(DeadLetterTasksKey), &redis.Z{
+			Score:  float64(time.Now().Unix()),
+			Member: taskJSON,
+		}).Err(); err != nil {
+			// Put the task back so it is not lost
+			q.client.RPush(ctx, legacyKey, taskJSON)
+			return migrated, err
+		}
+		migrated++
+	}
+}
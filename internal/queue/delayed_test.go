@@ -0,0 +1,79 @@
+package queue
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// makeDelayedTasksDue moves the due time of every delayed task into the past
+func makeDelayedTasksDue(t *testing.T, q *RedisQueue) {
+	t.Helper()
+
+	entries, err := q.client.ZRangeWithScores(ctx, queueKey(DelayedTasksKey), 0, -1).Result()
+	if err != nil {
+		t.Fatalf("reading delayed tasks: %v", err)
+	}
+	for i := range entries {
+		entries[i].Score = float64(time.Now().Add(-time.Second).Unix())
+		if err := q.client.ZAdd(ctx, queueKey(DelayedTasksKey), &entries[i]).Err(); err != nil {
+			t.Fatalf("rescheduling delayed task: %v", err)
+		}
+	}
+}
+
+func TestDelayedHighPriorityTask(t *testing.T) {
+	q, _ := newTestQueue(t)
+
+	if err := q.Publish(&Task{ID: "low", Type: "test", Priority: PriorityLow}); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	if err := q.PublishDelayed(&Task{ID: "urgent", Type: "test", Priority: PriorityHigh}, 60); err != nil {
+		t.Fatalf("PublishDelayed(urgent): %v", err)
+	}
+	if err := q.PublishDelayed(&Task{ID: "clamped", Type: "test", Priority: MaxPriority + 5}, 60); err != nil {
+		t.Fatalf("PublishDelayed(clamped): %v", err)
+	}
+
+	// Not due yet: only the low priority task is consumed
+	if count, _, err := q.ProcessDelayedTasks(DefaultDelayedBatchSize); err != nil || count != 0 {
+		t.Fatalf("ProcessDelayedTasks before due = %d, %v, want 0, nil", count, err)
+	}
+	task, err := q.Consume()
+	if err != nil {
+		t.Fatalf("Consume: %v", err)
+	}
+	if task.ID != "low" {
+		t.Fatalf("Consume = %s, want low", task.ID)
+	}
+	if _, err := q.Consume(); !errors.Is(err, redis.Nil) {
+		t.Fatalf("Consume before due = %v, want redis.Nil", err)
+	}
+
+	makeDelayedTasksDue(t, q)
+	if count, _, err := q.ProcessDelayedTasks(DefaultDelayedBatchSize); err != nil || count != 2 {
+		t.Fatalf("ProcessDelayedTasks when due = %d, %v, want 2, nil", count, err)
+	}
+
+	// Once due, both are consumed by priority
+	for _, want := range []struct {
+		id       string
+		priority int
+	}{
+		{"clamped", MaxPriority},
+		{"urgent", PriorityHigh},
+	} {
+		task, err := q.Consume()
+		if err != nil {
+			t.Fatalf("Consume: %v", err)
+		}
+		if task.ID != want.id || task.Priority != want.priority {
+			t.Fatalf("Consume = %s at priority %d, want %s at %d", task.ID, task.Priority, want.id, want.priority)
+		}
+		if task.Status != "running" {
+			t.Errorf("status of %s = %s, want running", task.ID, task.Status)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+// internal/queue/drain.go
+package queue
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// drainBatchSize is how many tasks a single drain step moves
+const drainBatchSize = 500
+
+// drainQueueScript moves up to ARGV[1] tasks, oldest first, from one queue to
+// the back of another and returns how many it moved. Each task is in exactly
+// one of the queues at any time, so workers consuming from either queue
+// neither lose nor repeat a task.
+var drainQueueScript = redis.NewScript(`
+local moved = 0
+for i = 1, tonumber(ARGV[1]) do
+	local task = redis.call("RPOP", KEYS[1])
+	if not task then
+		break
+	end
+	redis.call("LPUSH", KEYS[2], task)
+	moved = moved + 1
+end
+return moved
+`)
+
+// ParseQueueName returns the name of a task queue given by priority name or
+// number, e.g. "high" or "2", or by its name in the queue stats, e.g.
+// task_queue:2 or task_queue:gpu:1
+func ParseQueueName(value string) (string, error) {
+	if priority, ok := PriorityFromName(value); ok {
+		return getQueueName(priority), nil
+	}
+	if priority, err := strconv.Atoi(value); err == nil && IsValidPriority(priority) {
+		return getQueueName(priority), nil
+	}
+
+	parts := strings.Split(value, ":")
+	if len(parts) >= 2 && len(parts) <= 3 && parts[0] == TaskQueuePrefix {
+		priority, err := strconv.Atoi(parts[len(parts)-1])
+		if err == nil && IsValidPriority(priority) && (len(parts) == 2 || ValidTag(parts[1])) {
+			return value, nil
+		}
+	}
+
+	return "", fmt.Errorf("unknown queue %q: expected a priority or a queue name such as %s",
+		value, getQueueName(PriorityNormal))
+}
+
+// DrainQueue moves every task of one queue to the back of another, oldest
+// first, and returns how many it moved. Queues are named as in the queue
+// stats. Tasks are moved in batches, each of which is atomic, so it is safe to
+// drain while workers consume from either queue; tasks published to the
+// source while it is drained are moved as well. Moved tasks keep their stored
+// priority and tags, which decide where they go if they are retried.
+func (q *RedisQueue) DrainQueue(from, to string) (int, error) {
+	if from == to {
+		return 0, fmt.Errorf("cannot drain queue %s into itself", from)
+	}
+
+	keys := []string{queueKey(from), queueKey(to)}
+	total := 0
+	for {
+		moved, err := drainQueueScript.Run(ctx, q.client, keys, drainBatchSize).Int()
+		total += moved
+		if err != nil {
+			return total, err
+		}
+		if moved < drainBatchSize {
+			break
+		}
+	}
+
+	q.logger.Info(fmt.Sprintf("Drained %d tasks from %s to %s", total, from, to))
+	return total, nil
+}
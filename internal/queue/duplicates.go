@@ -0,0 +1,33 @@
+// internal/queue/duplicates.go
+package queue
+
+import (
+	"fmt"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// DuplicateSubmissionsKey counts the submissions suppressed by PublishUnique
+// because a task with the same ID already existed
+const DuplicateSubmissionsKey = "duplicate_submissions"
+
+// recordDuplicate counts a suppressed duplicate submission of a task
+func (q *RedisQueue) recordDuplicate(task *Task) {
+	if q.metrics != nil {
+		q.metrics.RecordDuplicateSubmission(task.Type)
+	}
+
+	if err := q.client.Incr(ctx, queueKey(DuplicateSubmissionsKey)).Err(); err != nil {
+		q.logger.Error(fmt.Sprintf("Failed to count duplicate submission of task %s: %v", task.ID, err))
+	}
+}
+
+// DuplicateSubmissions returns how many duplicate submissions have been
+// suppressed, across all instances using the same Redis
+func (q *RedisQueue) DuplicateSubmissions() (int64, error) {
+	count, err := q.client.Get(ctx, queueKey(DuplicateSubmissionsKey)).Int64()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	return count, err
+}
@@ -0,0 +1,37 @@
+package queue
+
+import (
+	"errors"
+	"testing"
+
+	"BoltQ/pkg/metrics"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestPublishUniqueCountsDuplicates(t *testing.T) {
+	q, _ := newTestQueue(t)
+	q.SetMetrics(metrics.NewMetricsCollector("test"))
+
+	counter := metrics.DuplicateSubmissions.WithLabelValues("dedupe")
+	before := testutil.ToFloat64(counter)
+
+	if existing, err := q.PublishUnique(&Task{ID: "order-1", Type: "dedupe"}, 0); err != nil || existing != nil {
+		t.Fatalf("first PublishUnique = %v, %v, want nil, nil", existing, err)
+	}
+
+	existing, err := q.PublishUnique(&Task{ID: "order-1", Type: "dedupe"}, 0)
+	if !errors.Is(err, ErrTaskExists) {
+		t.Fatalf("second PublishUnique error = %v, want ErrTaskExists", err)
+	}
+	if existing == nil || existing.ID != "order-1" {
+		t.Errorf("second PublishUnique returned %v, want the existing task", existing)
+	}
+
+	if count, err := q.DuplicateSubmissions(); err != nil || count != 1 {
+		t.Errorf("DuplicateSubmissions = %d, %v, want 1, nil", count, err)
+	}
+	if got := testutil.ToFloat64(counter) - before; got != 1 {
+		t.Errorf("duplicate submission metric increased by %v, want 1", got)
+	}
+}
@@ -0,0 +1,114 @@
+// internal/queue/error_stats.go
+package queue
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	// ErrorStatsKeyPrefix prefixes the hourly hashes counting task failures
+	ErrorStatsKeyPrefix = "error_stats"
+
+	// ErrorStatsBucket is the resolution of the failure counts
+	ErrorStatsBucket = time.Hour
+
+	// ErrorStatsRetention is how long failure counts are kept, and the longest
+	// window they can be queried over
+	ErrorStatsRetention = 7 * 24 * time.Hour
+
+	// Hash fields counting failures by error category, overall and per job type
+	errorStatsCategoryField = "category:"
+	errorStatsTypeField     = "type:"
+)
+
+// ErrorStats is the breakdown of task failures by error category over a window
+type ErrorStats struct {
+	Window     string                      `json:"window"`
+	Since      time.Time                   `json:"since"`
+	Total      int64                       `json:"total"`
+	Categories map[string]int64            `json:"categories"`
+	JobTypes   map[string]map[string]int64 `json:"job_types"`
+}
+
+// errorStatsKey returns the key of the hash counting failures in the hour of t
+func errorStatsKey(t time.Time) string {
+	return queueKey(fmt.Sprintf("%s:%d", ErrorStatsKeyPrefix, t.Truncate(ErrorStatsBucket).Unix()))
+}
+
+// RecordError counts a failed task attempt by its error category, overall and
+// for its job type
+func (q *RedisQueue) RecordError(jobType, category string) error {
+	key := errorStatsKey(time.Now())
+
+	_, err := q.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HIncrBy(ctx, key, errorStatsCategoryField+category, 1)
+		pipe.HIncrBy(ctx, key, errorStatsTypeField+jobType+":"+category, 1)
+		pipe.Expire(ctx, key, ErrorStatsRetention+ErrorStatsBucket)
+		return nil
+	})
+	return err
+}
+
+// GetErrorStats returns the failures counted over the given window, which is
+// rounded up to whole hours and may be at most ErrorStatsRetention
+func (q *RedisQueue) GetErrorStats(window time.Duration) (*ErrorStats, error) {
+	if window <= 0 || window > ErrorStatsRetention {
+		return nil, fmt.Errorf("window must be positive and at most %s", ErrorStatsRetention)
+	}
+
+	now := time.Now()
+	since := now.Add(-window).Truncate(ErrorStatsBucket)
+
+	pipe := q.client.Pipeline()
+	var buckets []*redis.StringStringMapCmd
+	for bucket := since; !bucket.After(now); bucket = bucket.Add(ErrorStatsBucket) {
+		buckets = append(buckets, pipe.HGetAll(ctx, errorStatsKey(bucket)))
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	stats := &ErrorStats{
+		Window:     window.String(),
+		Since:      since,
+		Categories: make(map[string]int64),
+		JobTypes:   make(map[string]map[string]int64),
+	}
+
+	for _, bucket := range buckets {
+		for field, value := range bucket.Val() {
+			count, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				continue
+			}
+
+			if category, ok := strings.CutPrefix(field, errorStatsCategoryField); ok {
+				stats.Categories[category] += count
+				stats.Total += count
+				continue
+			}
+
+			// Job types may contain colons, categories don't
+			typeAndCategory, ok := strings.CutPrefix(field, errorStatsTypeField)
+			if !ok {
+				continue
+			}
+			sep := strings.LastIndex(typeAndCategory, ":")
+			if sep < 0 {
+				continue
+			}
+			jobType, category := typeAndCategory[:sep], typeAndCategory[sep+1:]
+			if stats.JobTypes[jobType] == nil {
+				stats.JobTypes[jobType] = make(map[string]int64)
+			}
+			stats.JobTypes[jobType][category] += count
+		}
+	}
+
+	return stats, nil
+}
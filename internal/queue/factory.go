@@ -11,6 +11,13 @@ type QueueType string
 const (
 	// QueueTypeRedis represents a Redis-backed queue
 	QueueTypeRedis QueueType = "redis"
+
+	// QueueTypeMemory represents an in-process queue for tests and local development
+	QueueTypeMemory QueueType = "memory"
+
+	// QueueTypeZSet represents a Redis-backed queue that orders tasks in a
+	// sorted set by priority from 0 to 100, then by age
+	QueueTypeZSet QueueType = "zset"
 )
 
 // QueueServiceFactory creates and manages queue instances
@@ -46,4 +53,10 @@ func (f *QueueServiceFactory) CreateQueue(queueType QueueType, config map[string
 func (f *QueueServiceFactory) InitDefaultFactories() {
 	// Register Redis queue factory
 	f.RegisterQueueFactory(QueueTypeRedis, NewRedisQueueFactory(f.logger))
+
+	// Register sorted set queue factory
+	f.RegisterQueueFactory(QueueTypeZSet, NewZSetQueueFactory(f.logger))
+
+	// Register in-memory queue factory
+	f.RegisterQueueFactory(QueueTypeMemory, NewMemoryQueueFactory())
 }
@@ -0,0 +1,190 @@
+// internal/queue/health.go
+package queue
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	// WorkerHeartbeatsKey is a sorted set of worker IDs scored by their last heartbeat
+	WorkerHeartbeatsKey = "worker_heartbeats"
+
+	// JobTypesKey is a sorted set of job types with a registered processor,
+	// scored by the last heartbeat of a worker pool that handles them
+	JobTypesKey = "job_types"
+
+	// AnyJobType is recorded in JobTypesKey by worker pools with a default
+	// processor, which handle every job type
+	AnyJobType = "*"
+
+	// WorkerTagsKey is a sorted set of the tags worker pools consume, scored
+	// by the last heartbeat of a pool consuming them
+	WorkerTagsKey = "worker_tags"
+
+	// DelayedProcessorLastRunKey holds the unix time of the last delayed processor run
+	DelayedProcessorLastRunKey = "delayed_processor:last_run"
+
+	// WorkerHeartbeatInterval is how often worker pools send heartbeats
+	WorkerHeartbeatInterval = 5 * time.Second
+
+	// WorkerHeartbeatTTL is how long a worker counts as alive after its last heartbeat
+	WorkerHeartbeatTTL = 3 * WorkerHeartbeatInterval
+)
+
+// Ping checks that Redis is reachable
+func (q *RedisQueue) Ping() error {
+	return q.client.Ping(ctx).Err()
+}
+
+// RecordWorkerHeartbeats marks the given workers as alive now
+func (q *RedisQueue) RecordWorkerHeartbeats(workerIDs []string) error {
+	if len(workerIDs) == 0 {
+		return nil
+	}
+
+	now := float64(time.Now().Unix())
+	members := make([]*redis.Z, 0, len(workerIDs))
+	for _, id := range workerIDs {
+		members = append(members, &redis.Z{Score: now, Member: id})
+	}
+
+	return q.client.ZAdd(ctx, WorkerHeartbeatsKey, members...).Err()
+}
+
+// RemoveWorkerHeartbeats removes workers that shut down cleanly
+func (q *RedisQueue) RemoveWorkerHeartbeats(workerIDs []string) error {
+	if len(workerIDs) == 0 {
+		return nil
+	}
+
+	members := make([]interface{}, 0, len(workerIDs))
+	for _, id := range workerIDs {
+		members = append(members, id)
+	}
+
+	return q.client.ZRem(ctx, WorkerHeartbeatsKey, members...).Err()
+}
+
+// RecordJobTypes marks the given job types as handled by a live worker pool
+func (q *RedisQueue) RecordJobTypes(jobTypes []string) error {
+	if len(jobTypes) == 0 {
+		return nil
+	}
+
+	now := float64(time.Now().Unix())
+	members := make([]*redis.Z, 0, len(jobTypes))
+	for _, jobType := range jobTypes {
+		members = append(members, &redis.Z{Score: now, Member: jobType})
+	}
+
+	return q.client.ZAdd(ctx, JobTypesKey, members...).Err()
+}
+
+// RecordWorkerTags marks the given tags as consumed by a live worker pool
+func (q *RedisQueue) RecordWorkerTags(tags []string) error {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	now := float64(time.Now().Unix())
+	members := make([]*redis.Z, 0, len(tags))
+	for _, tag := range tags {
+		members = append(members, &redis.Z{Score: now, Member: tag})
+	}
+
+	return q.client.ZAdd(ctx, WorkerTagsKey, members...).Err()
+}
+
+// ConsumedTags returns the tags consumed by a worker pool that sent a
+// heartbeat within ttl
+func (q *RedisQueue) ConsumedTags(ttl time.Duration) ([]string, error) {
+	cutoff := strconv.FormatInt(time.Now().Add(-ttl).Unix(), 10)
+	return q.client.ZRangeByScore(ctx, WorkerTagsKey, &redis.ZRangeBy{Min: cutoff, Max: "+inf"}).Result()
+}
+
+// IsJobTypeRegistered reports whether a worker pool with a processor for the
+// job type, or with a default processor, sent a heartbeat within ttl
+func (q *RedisQueue) IsJobTypeRegistered(jobType string, ttl time.Duration) (bool, error) {
+	registered, err := q.jobTypeSeen(jobType, ttl)
+	if err != nil || registered {
+		return registered, err
+	}
+
+	return q.jobTypeSeen(AnyJobType, ttl)
+}
+
+// jobTypeSeen reports whether a job type was recorded within ttl
+func (q *RedisQueue) jobTypeSeen(jobType string, ttl time.Duration) (bool, error) {
+	lastSeen, err := q.client.ZScore(ctx, JobTypesKey, jobType).Result()
+	if err == redis.Nil {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return time.Since(time.Unix(int64(lastSeen), 0)) <= ttl, nil
+}
+
+// LiveWorkers returns the number of workers that sent a heartbeat within ttl.
+// Heartbeats older than ttl are pruned.
+func (q *RedisQueue) LiveWorkers(ttl time.Duration) (int64, error) {
+	cutoff := strconv.FormatInt(time.Now().Add(-ttl).Unix(), 10)
+
+	if err := q.client.ZRemRangeByScore(ctx, WorkerHeartbeatsKey, "-inf", "("+cutoff).Err(); err != nil {
+		return 0, err
+	}
+
+	return q.client.ZCount(ctx, WorkerHeartbeatsKey, cutoff, "+inf").Result()
+}
+
+// RecordDelayedProcessorRun stores the time of the latest delayed processor run
+func (q *RedisQueue) RecordDelayedProcessorRun() error {
+	return q.client.Set(ctx, DelayedProcessorLastRunKey, time.Now().Unix(), 0).Err()
+}
+
+// DelayedProcessorLastRun returns the time of the latest delayed processor run,
+// or the zero time if it never ran
+func (q *RedisQueue) DelayedProcessorLastRun() (time.Time, error) {
+	value, err := q.client.Get(ctx, DelayedProcessorLastRunKey).Int64()
+	if err == redis.Nil {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return time.Unix(value, 0), nil
+}
+
+// OldestTaskAge returns how long the oldest task across all priority queues has
+// been waiting, or 0 if every queue is empty
+func (q *RedisQueue) OldestTaskAge() (time.Duration, error) {
+	var oldest time.Duration
+
+	for priority := MaxPriority; priority >= MinPriority; priority-- {
+		// Tasks are pushed on the left and consumed from the right
+		taskJSON, err := q.client.LIndex(ctx, queueKey(getQueueName(priority)), -1).Result()
+		if err == redis.Nil {
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		var task Task
+		if err := q.decode([]byte(taskJSON), &task); err != nil {
+			return 0, fmt.Errorf("error decoding queued task: %v", err)
+		}
+
+		if age := time.Since(task.CreatedAt); age > oldest {
+			oldest = age
+		}
+	}
+
+	return oldest, nil
+}
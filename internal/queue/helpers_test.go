@@ -0,0 +1,27 @@
+package queue
+
+import (
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+)
+
+// testLogger discards log output
+type testLogger struct{}
+
+func (testLogger) Info(string, ...map[string]interface{})  {}
+func (testLogger) Error(string, ...map[string]interface{}) {}
+func (testLogger) Debug(string, ...map[string]interface{}) {}
+
+// newTestQueue returns a queue backed by an in-memory Redis server that is
+// shut down when the test ends
+func newTestQueue(t testing.TB) (*RedisQueue, *miniredis.Miniredis) {
+	t.Helper()
+
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	return NewRedisQueue(client, testLogger{}), server
+}
@@ -0,0 +1,178 @@
+// internal/queue/key_migration.go
+package queue
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// KeyMigrationKey marks that the untagged keys of earlier versions have been
+// moved, so later startups skip the migration
+const KeyMigrationKey = "migrations:hash_tags"
+
+// keyMigrationBatch is how many delayed tasks the migration moves at a time
+const keyMigrationBatch = 500
+
+// MigrateUntaggedKeys moves the keys used before keys were hash-tagged to
+// their current names: the priority queues (task_queue:N), the delayed set
+// (delayed_tasks), the dead letter list (dead_letter_queue) and the task
+// records (task:<id>). Migrated tasks keep their order and are consumed before
+// tasks published under the new names. Keys in different slots can't be
+// renamed in Redis Cluster, so their contents are copied over instead. It is
+// safe to run from several instances at once and returns the number of moved
+// tasks and records.
+func (q *RedisQueue) MigrateUntaggedKeys() (int, error) {
+	done, err := q.client.Get(ctx, queueKey(KeyMigrationKey)).Result()
+	if err != nil && err != redis.Nil {
+		return 0, err
+	}
+	if done != "" {
+		return 0, nil
+	}
+
+	migrated := 0
+
+	lists := []string{DeadLetterQueue}
+	for priority := MinPriority; priority <= MaxPriority; priority++ {
+		lists = append(lists, getQueueName(priority))
+	}
+	for _, name := range lists {
+		count, err := q.migrateList(name, queueKey(name))
+		migrated += count
+		if err != nil {
+			return migrated, err
+		}
+	}
+
+	// The dead letter list has since been replaced by a sorted set
+	if _, err := q.MigrateLegacyDeadLetterQueue(); err != nil {
+		return migrated, err
+	}
+
+	count, err := q.migrateDelayedTasks()
+	migrated += count
+	if err != nil {
+		return migrated, err
+	}
+
+	count, err = q.migrateTaskRecords()
+	migrated += count
+	if err != nil {
+		return migrated, err
+	}
+
+	if err := q.client.Set(ctx, queueKey(KeyMigrationKey), time.Now().Unix(), 0).Err(); err != nil {
+		return migrated, err
+	}
+	return migrated, nil
+}
+
+// migrateList moves the entries of a list onto the consuming end of another,
+// newest first, so that the oldest entry is consumed first
+func (q *RedisQueue) migrateList(from, to string) (int, error) {
+	migrated := 0
+
+	for {
+		taskJSON, err := q.client.LPop(ctx, from).Result()
+		if err == redis.Nil {
+			return migrated, nil
+		}
+		if err != nil {
+			return migrated, err
+		}
+
+		if err := q.client.RPush(ctx, to, taskJSON).Err(); err != nil {
+			// Put the task back so it is not lost
+			q.client.LPush(ctx, from, taskJSON)
+			return migrated, err
+		}
+		migrated++
+	}
+}
+
+// migrateDelayedTasks moves the delayed set to its tagged key, keeping the
+// time each task is due
+func (q *RedisQueue) migrateDelayedTasks() (int, error) {
+	migrated := 0
+
+	for {
+		entries, err := q.client.ZRangeWithScores(ctx, DelayedTasksKey, 0, keyMigrationBatch-1).Result()
+		if err != nil {
+			return migrated, err
+		}
+		if len(entries) == 0 {
+			return migrated, nil
+		}
+
+		members := make([]*redis.Z, len(entries))
+		taskJSONs := make([]interface{}, len(entries))
+		for i := range entries {
+			members[i] = &entries[i]
+			taskJSONs[i] = entries[i].Member
+		}
+
+		if err := q.client.ZAdd(ctx, queueKey(DelayedTasksKey), members...).Err(); err != nil {
+			return migrated, err
+		}
+		if err := q.client.ZRem(ctx, DelayedTasksKey, taskJSONs...).Err(); err != nil {
+			return migrated, err
+		}
+		migrated += len(entries)
+	}
+}
+
+// migrateTaskRecords moves the task records to their tagged keys, keeping
+// their TTL. A record already stored under the new key is kept.
+func (q *RedisQueue) migrateTaskRecords() (int, error) {
+	migrated := 0
+
+	err := q.scanTaskRecords(func(ctx context.Context, _ taskScanner, keys []string) error {
+		for _, key := range keys {
+			taskID := strings.TrimPrefix(key, "task:")
+			if strings.HasPrefix(taskID, "{") {
+				continue
+			}
+
+			moved, err := q.migrateTaskRecord(ctx, key, taskKey(taskID))
+			if err != nil {
+				return err
+			}
+			if moved {
+				migrated++
+			}
+		}
+		return nil
+	})
+	return migrated, err
+}
+
+// migrateTaskRecord copies a task record to a new key with its remaining TTL
+// and removes the old one. It reports false if the record has expired.
+func (q *RedisQueue) migrateTaskRecord(ctx context.Context, from, to string) (bool, error) {
+	taskJSON, err := q.client.Get(ctx, from).Result()
+	if err == redis.Nil {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	ttl, err := q.client.PTTL(ctx, from).Result()
+	if err != nil {
+		return false, err
+	}
+	if ttl < 0 {
+		ttl = 0
+	}
+
+	if err := q.client.SetNX(ctx, to, taskJSON, ttl).Err(); err != nil {
+		return false, err
+	}
+	if err := q.client.Del(ctx, from).Err(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,88 @@
+package queue
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMigrateUntaggedKeys(t *testing.T) {
+	q, server := newTestQueue(t)
+
+	encode := func(id string, priority int) string {
+		taskJSON, err := q.encode(&Task{ID: id, Type: "test", Priority: priority, Status: "pending"})
+		if err != nil {
+			t.Fatalf("encode(%s): %v", id, err)
+		}
+		return string(taskJSON)
+	}
+
+	// Keys as stored by versions without hash tags: published with LPUSH, so
+	// "old-1" is the oldest task of the queue
+	legacyQueue := getQueueName(PriorityNormal)
+	server.Lpush(legacyQueue, encode("old-1", PriorityNormal))
+	server.Lpush(legacyQueue, encode("old-2", PriorityNormal))
+	server.ZAdd(DelayedTasksKey, float64(time.Now().Add(time.Hour).Unix()), encode("delayed", PriorityNormal))
+	server.Lpush(DeadLetterQueue, encode("dead", PriorityNormal))
+	server.Set("task:old-1", encode("old-1", PriorityNormal))
+	server.SetTTL("task:old-1", time.Hour)
+
+	// A task published after the upgrade
+	if err := q.Publish(&Task{ID: "new", Type: "test", Priority: PriorityNormal}); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+
+	migrated, err := q.MigrateUntaggedKeys()
+	if err != nil {
+		t.Fatalf("MigrateUntaggedKeys: %v", err)
+	}
+	if migrated != 5 {
+		t.Errorf("migrated = %d, want 5", migrated)
+	}
+
+	for _, key := range []string{legacyQueue, DelayedTasksKey, DeadLetterQueue, "task:old-1"} {
+		if server.Exists(key) {
+			t.Errorf("legacy key %s still exists", key)
+		}
+	}
+
+	if ttl := server.TTL(taskKey("old-1")); ttl <= 0 || ttl > time.Hour {
+		t.Errorf("TTL of migrated record = %v, want up to 1h", ttl)
+	}
+
+	// Migrated tasks keep their order ahead of newer ones
+	for _, want := range []string{"old-1", "old-2", "new"} {
+		task, err := q.Consume()
+		if err != nil {
+			t.Fatalf("Consume: %v", err)
+		}
+		if task.ID != want {
+			t.Fatalf("Consume = %s, want %s", task.ID, want)
+		}
+	}
+
+	if _, err := q.GetTaskStatus("old-1"); err != nil {
+		t.Errorf("GetTaskStatus(old-1) after migration: %v", err)
+	}
+
+	delayed, err := q.PeekDelayed(10)
+	if err != nil {
+		t.Fatalf("PeekDelayed: %v", err)
+	}
+	if len(delayed) != 1 || delayed[0].ID != "delayed" {
+		t.Errorf("delayed tasks = %v, want [delayed]", delayed)
+	}
+
+	dead, err := q.DeadLetters(10)
+	if err != nil {
+		t.Fatalf("DeadLetters: %v", err)
+	}
+	if len(dead) != 1 || dead[0].ID != "dead" {
+		t.Errorf("dead letter tasks = %v, want [dead]", dead)
+	}
+
+	// Later startups skip the migration
+	server.Lpush(legacyQueue, encode("late", PriorityNormal))
+	if migrated, err := q.MigrateUntaggedKeys(); err != nil || migrated != 0 {
+		t.Errorf("second MigrateUntaggedKeys = %d, %v, want 0, nil", migrated, err)
+	}
+}
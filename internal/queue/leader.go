@@ -0,0 +1,63 @@
+// internal/queue/leader.go
+package queue
+
+import (
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// DelayedProcessorLeaderKey holds the ID of the instance running the delayed processor
+const DelayedProcessorLeaderKey = "delayed_processor:leader"
+
+// renewLeadershipScript extends the lock only if it is still held by the caller
+var renewLeadershipScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// releaseLeadershipScript deletes the lock only if it is still held by the caller
+var releaseLeadershipScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// AcquireLeadership takes or renews the lock at key for holderID. It returns
+// true if holderID holds the lock for the next ttl. A lock whose holder stops
+// renewing it expires after ttl and can then be taken by another instance.
+func (q *RedisQueue) AcquireLeadership(key, holderID string, ttl time.Duration) (bool, error) {
+	acquired, err := q.client.SetNX(ctx, key, holderID, ttl).Result()
+	if err != nil {
+		return false, err
+	}
+	if acquired {
+		return true, nil
+	}
+
+	renewed, err := renewLeadershipScript.Run(ctx, q.client, []string{key}, holderID, ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, err
+	}
+
+	return renewed == 1, nil
+}
+
+// ReleaseLeadership gives up the lock at key if holderID holds it
+func (q *RedisQueue) ReleaseLeadership(key, holderID string) error {
+	return releaseLeadershipScript.Run(ctx, q.client, []string{key}, holderID).Err()
+}
+
+// Leader returns the ID of the current holder of the lock at key, or an empty
+// string if nobody holds it
+func (q *RedisQueue) Leader(key string) (string, error) {
+	holderID, err := q.client.Get(ctx, key).Result()
+	if err == redis.Nil {
+		return "", nil
+	}
+
+	return holderID, err
+}
@@ -0,0 +1,185 @@
+// internal/queue/lease.go
+package queue
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// LeasesKey holds the IDs of leased tasks, scored by the time in milliseconds
+// at which their lease expires
+const LeasesKey = "leases"
+
+// leaseScanDepth is how many tasks at the head of each queue Lease looks at
+// for one of the requested job types
+const leaseScanDepth = 100
+
+// ErrLeaseNotHeld is returned by ReleaseLease when a task is not leased, e.g.
+// because its lease expired and it was requeued
+var ErrLeaseNotHeld = errors.New("task is not leased")
+
+// errLeaseExpired is recorded as the failed attempt of a task whose lease expired
+var errLeaseExpired = errors.New("lease expired before the task was reported")
+
+// Lease consumes the next untagged task of one of the given job types, or of
+// any type if none are given, and leases it for the given duration. Unlike a
+// task consumed by a worker, a leased task that is not released in time is
+// put back in its queue by RequeueExpiredLeases. Only the first tasks of each
+// queue are checked for a matching type. It returns redis.Nil if no task
+// matches.
+func (q *RedisQueue) Lease(jobTypes []string, duration time.Duration) (*Task, error) {
+	queueNames := priorityQueueNames(nil)
+
+	var task *Task
+	var err error
+	if len(jobTypes) == 0 {
+		task, err = q.consumeFirst(ctx, "", queueNames)
+	} else {
+		task, err = q.consumeWith(ctx, "", func() (string, string, error) {
+			return q.popMatching(queueNames, jobTypes)
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	expiresAt := time.Now().Add(duration)
+	if err := q.client.ZAdd(ctx, queueKey(LeasesKey), &redis.Z{
+		Score:  float64(expiresAt.UnixMilli()),
+		Member: task.ID,
+	}).Err(); err != nil {
+		// Put the task back rather than run it without a lease
+		q.requeueLeased(task)
+		return nil, err
+	}
+
+	return task, nil
+}
+
+// popMatching removes the task that would be consumed next among those of the
+// given job types, checking the queues in order. It returns redis.Nil if none
+// of the tasks checked has one of the types.
+func (q *RedisQueue) popMatching(queueNames []string, jobTypes []string) (string, string, error) {
+	wanted := make(map[string]bool, len(jobTypes))
+	for _, jobType := range jobTypes {
+		wanted[jobType] = true
+	}
+
+	for _, queueName := range queueNames {
+		// Look at the tasks in the order the queue gives them out
+		lifo := q.queueOrder(queueName) == OrderLIFO
+		start, stop, removeCount := int64(-leaseScanDepth), int64(-1), int64(-1)
+		if lifo {
+			start, stop, removeCount = 0, leaseScanDepth-1, 1
+		}
+
+		entries, err := q.client.LRange(ctx, queueKey(queueName), start, stop).Result()
+		if err != nil {
+			return "", "", err
+		}
+
+		for i := range entries {
+			taskJSON := entries[i]
+			if !lifo {
+				taskJSON = entries[len(entries)-1-i]
+			}
+
+			// Corrupt entries are left for workers to set aside
+			var task Task
+			if err := q.decode([]byte(taskJSON), &task); err != nil || !wanted[task.Type] {
+				continue
+			}
+
+			// Another consumer may have taken the task since it was listed
+			removed, err := q.client.LRem(ctx, queueKey(queueName), removeCount, taskJSON).Result()
+			if err != nil {
+				return "", "", err
+			}
+			if removed > 0 {
+				return queueName, taskJSON, nil
+			}
+		}
+	}
+
+	return "", "", redis.Nil
+}
+
+// ReleaseLease ends the lease of a task, so it is no longer requeued when the
+// lease expires. It returns ErrLeaseNotHeld if the task is not leased.
+func (q *RedisQueue) ReleaseLease(taskID string) error {
+	removed, err := q.client.ZRem(ctx, queueKey(LeasesKey), taskID).Result()
+	if err != nil {
+		return err
+	}
+	if removed == 0 {
+		return ErrLeaseNotHeld
+	}
+	return nil
+}
+
+// RequeueExpiredLeases puts up to batchSize tasks whose lease has expired back
+// in their queue, all of them if batchSize is 0, and returns how many it
+// requeued. Tasks that were cancelled or finished in the meantime are dropped.
+func (q *RedisQueue) RequeueExpiredLeases(batchSize int) (int, error) {
+	rangeBy := &redis.ZRangeBy{
+		Min: "-inf",
+		Max: strconv.FormatInt(time.Now().UnixMilli(), 10),
+	}
+	if batchSize > 0 {
+		rangeBy.Count = int64(batchSize)
+	}
+
+	taskIDs, err := q.client.ZRangeByScore(ctx, queueKey(LeasesKey), rangeBy).Result()
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, taskID := range taskIDs {
+		// Only one instance gets to requeue each task
+		if err := q.ReleaseLease(taskID); err != nil {
+			if !errors.Is(err, ErrLeaseNotHeld) {
+				q.logger.Error(fmt.Sprintf("Failed to release expired lease of task %s: %v", taskID, err))
+			}
+			continue
+		}
+
+		task, err := q.GetTaskStatus(taskID)
+		if err != nil {
+			q.logger.Error(fmt.Sprintf("Failed to load task %s with an expired lease: %v", taskID, err))
+			continue
+		}
+		if task.Status != string(StatusRunning) {
+			continue
+		}
+
+		task.RecordAttempt(errLeaseExpired)
+		if q.requeueLeased(task) {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+// requeueLeased puts a leased task back in its queue as pending and reports
+// whether it was requeued
+func (q *RedisQueue) requeueLeased(task *Task) bool {
+	task.Status = string(StatusPending)
+	if err := q.UpdateStatus(task); err != nil {
+		q.logger.Error(fmt.Sprintf("Failed to update status of leased task %s: %v", task.ID, err))
+		return false
+	}
+
+	if err := q.publishToQueue(task, taskQueueName(task)); err != nil {
+		q.logger.Error(fmt.Sprintf("Failed to requeue leased task %s: %v", task.ID, err))
+		return false
+	}
+
+	q.logger.Info(fmt.Sprintf("Leased task %s requeued", task.ID))
+	return true
+}
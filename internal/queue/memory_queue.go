@@ -0,0 +1,324 @@
+// internal/queue/memory_queue.go
+package queue
+
+import (
+	"container/heap"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// MemoryQueue is an in-process implementation of the Queue interface for
+// tests and local development. It follows the same rules as the Redis queue:
+// higher priorities are consumed first, delayed jobs become available once
+// their time has come, retried jobs wait out the retry policy's backoff, jobs that fail for
+// good are kept in a dead letter list and status updates must be valid
+// transitions. Nothing is persisted and the queue is not shared between
+// processes.
+type MemoryQueue struct {
+	jobs       map[string]*Job
+	queues     [MaxPriority + 1][]string
+	delayed    delayedHeap
+	deadLetter []string
+	retry      RetryPolicy
+	closed     bool
+	wake       chan struct{}
+	done       chan struct{}
+	mu         sync.Mutex
+}
+
+// delayedJob is a job waiting in the delayed heap of a MemoryQueue
+type delayedJob struct {
+	id      string
+	readyAt time.Time
+}
+
+// delayedHeap orders delayed jobs by the time they become ready
+type delayedHeap []delayedJob
+
+func (h delayedHeap) Len() int           { return len(h) }
+func (h delayedHeap) Less(i, j int) bool { return h[i].readyAt.Before(h[j].readyAt) }
+func (h delayedHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+func (h *delayedHeap) Push(x interface{}) {
+	*h = append(*h, x.(delayedJob))
+}
+
+func (h *delayedHeap) Pop() interface{} {
+	old := *h
+	entry := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return entry
+}
+
+// NewMemoryQueue creates an empty in-memory queue and starts the goroutine
+// that promotes its delayed jobs. Close stops it.
+func NewMemoryQueue() *MemoryQueue {
+	q := &MemoryQueue{
+		jobs:  make(map[string]*Job),
+		retry: DefaultRetryPolicy,
+		wake:  make(chan struct{}, 1),
+		done:  make(chan struct{}),
+	}
+
+	go q.promoteDelayed()
+
+	return q
+}
+
+// SetRetryPolicy sets the backoff a job marked retrying waits out. It
+// defaults to DefaultRetryPolicy.
+func (q *MemoryQueue) SetRetryPolicy(policy RetryPolicy) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.retry = policy
+}
+
+// Publish adds a job to the queue with specified priority
+func (q *MemoryQueue) Publish(ctx context.Context, job *Job) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	stored, err := q.storeLocked(job, StatusPending)
+	if err != nil {
+		return err
+	}
+
+	q.enqueueLocked(stored)
+	return nil
+}
+
+// PublishDelayed adds a job to be executed at a future time
+func (q *MemoryQueue) PublishDelayed(ctx context.Context, job *Job, delay time.Duration) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	stored, err := q.storeLocked(job, StatusScheduled)
+	if err != nil {
+		return err
+	}
+
+	q.delayLocked(stored, delay)
+	return nil
+}
+
+// storeLocked stores a copy of a published job with the given status.
+// Callers must hold q.mu.
+func (q *MemoryQueue) storeLocked(job *Job, status JobStatus) (*Job, error) {
+	if q.closed {
+		return nil, fmt.Errorf("queue is closed")
+	}
+
+	stored := JobFromTask(TaskFromJob(job))
+	stored.Priority = NormalizePriority(stored.Priority)
+	stored.Status = status
+	stored.CreatedAt = time.Now()
+	q.jobs[stored.ID] = stored
+	return stored, nil
+}
+
+// enqueueLocked appends a job to its priority queue. Callers must hold q.mu.
+func (q *MemoryQueue) enqueueLocked(job *Job) {
+	q.queues[job.Priority] = append(q.queues[job.Priority], job.ID)
+}
+
+// delayLocked puts a job in the delayed heap and wakes the promoter if the
+// job is now the next one due. Callers must hold q.mu.
+func (q *MemoryQueue) delayLocked(job *Job, delay time.Duration) {
+	job.ScheduledAt = time.Now().Add(delay)
+	heap.Push(&q.delayed, delayedJob{id: job.ID, readyAt: job.ScheduledAt})
+
+	if q.delayed[0].id == job.ID {
+		select {
+		case q.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// promoteDelayed moves delayed jobs to their priority queues when they become
+// ready. It sleeps until the next job is due or a sooner one is added.
+func (q *MemoryQueue) promoteDelayed() {
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+
+	for {
+		q.mu.Lock()
+		next := q.releaseDelayedLocked(time.Now())
+		q.mu.Unlock()
+
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(next)
+
+		select {
+		case <-q.done:
+			return
+		case <-q.wake:
+		case <-timer.C:
+		}
+	}
+}
+
+// releaseDelayedLocked moves delayed jobs that are ready to their priority
+// queues and returns how long until the next one is due. Callers must hold q.mu.
+func (q *MemoryQueue) releaseDelayedLocked(now time.Time) time.Duration {
+	for q.delayed.Len() > 0 {
+		if wait := q.delayed[0].readyAt.Sub(now); wait > 0 {
+			return wait
+		}
+
+		entry := heap.Pop(&q.delayed).(delayedJob)
+		job, ok := q.jobs[entry.id]
+		if !ok || checkTransition(string(job.Status), string(StatusPending)) != nil {
+			// Cancelled or finished while it was waiting
+			continue
+		}
+
+		job.Status = StatusPending
+		q.enqueueLocked(job)
+	}
+
+	return time.Hour
+}
+
+// Consume retrieves the next available job from the queue, checking high
+// priority first. It returns nil and no error when no job is available.
+func (q *MemoryQueue) Consume(ctx context.Context) (*Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return nil, fmt.Errorf("queue is closed")
+	}
+
+	for priority := MaxPriority; priority >= MinPriority; priority-- {
+		for len(q.queues[priority]) > 0 {
+			id := q.queues[priority][0]
+			q.queues[priority] = q.queues[priority][1:]
+
+			job, ok := q.jobs[id]
+			if !ok || checkTransition(string(job.Status), string(StatusRunning)) != nil {
+				// Cancelled or finished while it was queued
+				continue
+			}
+
+			job.Status = StatusRunning
+			job.UpdatedAt = time.Now()
+			return JobFromTask(TaskFromJob(job)), nil
+		}
+	}
+
+	return nil, nil
+}
+
+// UpdateStatus updates a job's status. Like RetryTask and
+// MoveToDeadLetterQueue on the Redis queue, a job marked retrying counts an
+// attempt and waits out the retry policy's backoff before it is consumed again, and a job
+// marked failed is moved to the dead letter list.
+func (q *MemoryQueue) UpdateStatus(ctx context.Context, jobID string, status JobStatus, err error) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	job, ok := q.jobs[jobID]
+	if !ok {
+		return fmt.Errorf("task not found")
+	}
+
+	if transitionErr := checkTransition(string(job.Status), string(status)); transitionErr != nil {
+		return transitionErr
+	}
+
+	job.Status = status
+	job.UpdatedAt = time.Now()
+	if err != nil {
+		job.Error = err.Error()
+	}
+
+	switch status {
+	case StatusRetrying:
+		job.Attempts++
+		q.delayLocked(job, time.Duration(q.retry.DelaySeconds(job.Attempts))*time.Second)
+	case StatusFailed:
+		q.deadLetter = append(q.deadLetter, job.ID)
+	}
+	return nil
+}
+
+// GetJob retrieves a job by ID
+func (q *MemoryQueue) GetJob(ctx context.Context, jobID string) (*Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	job, ok := q.jobs[jobID]
+	if !ok {
+		return nil, fmt.Errorf("task not found")
+	}
+
+	return JobFromTask(TaskFromJob(job)), nil
+}
+
+// DeadLetterJobs returns the jobs that failed for good, oldest first
+func (q *MemoryQueue) DeadLetterJobs() []*Job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	jobs := make([]*Job, 0, len(q.deadLetter))
+	for _, id := range q.deadLetter {
+		jobs = append(jobs, JobFromTask(TaskFromJob(q.jobs[id])))
+	}
+	return jobs
+}
+
+// GetStats returns statistics about the queue, using the same keys as the
+// Redis queue
+func (q *MemoryQueue) GetStats(ctx context.Context) (map[string]interface{}, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	stats := make(map[string]interface{})
+	for priority := MaxPriority; priority >= MinPriority; priority-- {
+		stats[getQueueName(priority)] = int64(len(q.queues[priority]))
+	}
+	stats[DelayedTasksKey] = int64(q.delayed.Len())
+	stats[DeadLetterQueue] = int64(len(q.deadLetter))
+	stats[PoisonTasksKey] = int64(0)
+
+	return stats, nil
+}
+
+// Close stops the delayed job promoter; later calls to Publish and Consume fail
+func (q *MemoryQueue) Close() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if !q.closed {
+		q.closed = true
+		close(q.done)
+	}
+	return nil
+}
+
+// MemoryQueueFactory creates in-memory queues
+type MemoryQueueFactory struct{}
+
+// NewMemoryQueueFactory creates a new in-memory queue factory
+func NewMemoryQueueFactory() QueueFactory {
+	return &MemoryQueueFactory{}
+}
+
+// CreateQueue creates a new, empty in-memory queue. The config is ignored.
+func (f *MemoryQueueFactory) CreateQueue(config map[string]string) (Queue, error) {
+	return NewMemoryQueue(), nil
+}
+
+// Close for the factory (nothing to release)
+func (f *MemoryQueueFactory) Close() error {
+	return nil
+}
@@ -0,0 +1,110 @@
+// internal/queue/order.go
+package queue
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// QueueOrder decides which end of a queue tasks are consumed from. Tasks are
+// always pushed on the left.
+type QueueOrder string
+
+const (
+	// OrderFIFO consumes the oldest task first, from the right
+	OrderFIFO QueueOrder = "fifo"
+
+	// OrderLIFO consumes the newest task first, from the left
+	OrderLIFO QueueOrder = "lifo"
+)
+
+// ParseQueueOrder parses a queue order name
+func ParseQueueOrder(name string) (QueueOrder, error) {
+	switch QueueOrder(name) {
+	case OrderFIFO, OrderLIFO:
+		return QueueOrder(name), nil
+	default:
+		return "", fmt.Errorf("unknown queue order: %s", name)
+	}
+}
+
+// SetQueueOrder sets the consume order of a queue, named as in the queue
+// stats, e.g. task_queue:2 or task_queue:gpu:1. Queues are FIFO by default.
+// Priorities still come first: a LIFO queue only changes which of its own
+// tasks is taken next.
+func (q *RedisQueue) SetQueueOrder(queueName string, order QueueOrder) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.queueOrders == nil {
+		q.queueOrders = make(map[string]QueueOrder)
+	}
+	q.queueOrders[queueName] = order
+}
+
+// queueOrder returns the consume order of a queue
+func (q *RedisQueue) queueOrder(queueName string) QueueOrder {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	if order, ok := q.queueOrders[queueName]; ok {
+		return order
+	}
+	return OrderFIFO
+}
+
+// nextIndex returns the list index of the task a queue will give out next
+func (q *RedisQueue) nextIndex(queueName string) int64 {
+	if q.queueOrder(queueName) == OrderLIFO {
+		return 0
+	}
+	return -1
+}
+
+// popFirstScript pops the next task of the first non-empty queue, taking it
+// from the left of queues marked "lifo" and from the right of the others. It
+// returns the index of the queue and the task, or nil if all are empty.
+var popFirstScript = redis.NewScript(`
+for i, key in ipairs(KEYS) do
+	local task
+	if ARGV[i] == "lifo" then
+		task = redis.call("LPOP", key)
+	else
+		task = redis.call("RPOP", key)
+	end
+	if task then
+		return {i, task}
+	end
+end
+return false
+`)
+
+// popFirst removes the task the first non-empty queue gives out next, checking
+// the queues in the given order in a single round trip. Workers polling idle
+// high priority queues then cost Redis one call per poll rather than one per
+// queue. It returns redis.Nil if all queues are empty.
+func (q *RedisQueue) popFirst(ctx context.Context, queueNames []string) (string, string, error) {
+	keys := make([]string, len(queueNames))
+	orders := make([]interface{}, len(queueNames))
+	for i, queueName := range queueNames {
+		keys[i] = queueKey(queueName)
+		orders[i] = string(q.queueOrder(queueName))
+	}
+
+	result, err := popFirstScript.Run(ctx, q.client, keys, orders...).Slice()
+	if err != nil {
+		return "", "", err
+	}
+	if len(result) != 2 {
+		return "", "", fmt.Errorf("unexpected pop result: %v", result)
+	}
+
+	index, _ := result[0].(int64)
+	taskJSON, _ := result[1].(string)
+	if index < 1 || int(index) > len(queueNames) {
+		return "", "", fmt.Errorf("unexpected pop result: %v", result)
+	}
+	return queueNames[index-1], taskJSON, nil
+}
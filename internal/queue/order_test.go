@@ -0,0 +1,71 @@
+package queue
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// popFunc pops the first task found in a list of queues, checked in order
+type popFunc func(q *RedisQueue, queueNames []string) error
+
+// popFirstPop pops with popFirst, one script call
+func popFirstPop(q *RedisQueue, queueNames []string) error {
+	_, _, err := q.popFirst(ctx, queueNames)
+	return err
+}
+
+// rpopLoopPop pops the way consumers did before popFirst, with one RPOP call
+// per queue until one returns a task. It is kept as a benchmark baseline.
+func rpopLoopPop(q *RedisQueue, queueNames []string) error {
+	for _, name := range queueNames {
+		err := q.client.RPop(ctx, queueKey(name)).Err()
+		if err != redis.Nil {
+			return err
+		}
+	}
+	return redis.Nil
+}
+
+// benchmarkPopIdle measures a poll of every priority queue while all of them
+// are empty, what an idle worker does between tasks
+func benchmarkPopIdle(b *testing.B, pop popFunc) {
+	q, _ := newTestQueue(b)
+	queueNames := priorityQueueNames(nil)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := pop(q, queueNames); !errors.Is(err, redis.Nil) {
+			b.Fatalf("pop = %v, want redis.Nil", err)
+		}
+	}
+}
+
+// benchmarkPopLowestPriority measures popping from the lowest priority queue,
+// which is only reached after checking all the others
+func benchmarkPopLowestPriority(b *testing.B, pop popFunc) {
+	q, server := newTestQueue(b)
+	queueNames := priorityQueueNames(nil)
+
+	key := queueKey(getQueueName(MinPriority))
+	for i := 0; i < b.N; i++ {
+		server.Lpush(key, fmt.Sprintf("task-%d", i))
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := pop(q, queueNames); err != nil {
+			b.Fatalf("pop: %v", err)
+		}
+	}
+}
+
+func BenchmarkPopFirstIdle(b *testing.B) { benchmarkPopIdle(b, popFirstPop) }
+
+func BenchmarkPopFirstLowestPriority(b *testing.B) { benchmarkPopLowestPriority(b, popFirstPop) }
+
+func BenchmarkRPopLoopIdle(b *testing.B) { benchmarkPopIdle(b, rpopLoopPop) }
+
+func BenchmarkRPopLoopLowestPriority(b *testing.B) { benchmarkPopLowestPriority(b, rpopLoopPop) }
@@ -0,0 +1,44 @@
+// internal/queue/payload.go
+package queue
+
+import (
+	"errors"
+	"fmt"
+)
+
+// DefaultMaxPayloadSize is the default limit for the serialized size of a task
+const DefaultMaxPayloadSize int64 = 1 << 20 // 1MB
+
+// ErrPayloadTooLarge is returned when a serialized task exceeds the maximum payload size
+var ErrPayloadTooLarge = errors.New("task payload too large")
+
+// SetMaxPayloadSize limits the serialized size of tasks accepted by Publish and
+// PublishDelayed. A maxSize of 0 or less removes the limit.
+func (q *RedisQueue) SetMaxPayloadSize(maxSize int64) {
+	q.maxPayloadSize = maxSize
+}
+
+// MaxPayloadSize returns the current payload size limit (0 = unlimited)
+func (q *RedisQueue) MaxPayloadSize() int64 {
+	return q.maxPayloadSize
+}
+
+// CheckPayloadSize returns ErrPayloadTooLarge if the task would exceed the
+// payload size limit once serialized
+func (q *RedisQueue) CheckPayloadSize(task *Task) error {
+	taskJSON, err := q.encode(task)
+	if err != nil {
+		return err
+	}
+
+	return q.checkPayloadSize(task, taskJSON)
+}
+
+// checkPayloadSize returns ErrPayloadTooLarge if the serialized task exceeds the limit
+func (q *RedisQueue) checkPayloadSize(task *Task, taskJSON []byte) error {
+	if q.maxPayloadSize > 0 && int64(len(taskJSON)) > q.maxPayloadSize {
+		return fmt.Errorf("%w: task %s is %d bytes, limit is %d bytes",
+			ErrPayloadTooLarge, task.ID, len(taskJSON), q.maxPayloadSize)
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+// internal/queue/peek.go
+package queue
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// PeekNext returns the task that will be consumed next from a priority queue
+// without removing it, or nil if the queue is empty
+func (q *RedisQueue) PeekNext(priority int) (*Task, error) {
+	queueName := getQueueName(priority)
+	taskJSON, err := q.client.LIndex(ctx, queueKey(queueName), q.nextIndex(queueName)).Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var task Task
+	if err := q.decode([]byte(taskJSON), &task); err != nil {
+		return nil, fmt.Errorf("error decoding queued task: %v", err)
+	}
+
+	return &task, nil
+}
+
+// PeekDelayed returns up to limit delayed tasks in the order they are due,
+// with ScheduledAt set to the time they will be moved to their queue
+func (q *RedisQueue) PeekDelayed(limit int) ([]*Task, error) {
+	if limit <= 0 {
+		return []*Task{}, nil
+	}
+
+	entries, err := q.client.ZRangeWithScores(ctx, queueKey(DelayedTasksKey), 0, int64(limit-1)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	tasks := make([]*Task, 0, len(entries))
+	for _, entry := range entries {
+		taskJSON, ok := entry.Member.(string)
+		if !ok {
+			continue
+		}
+
+		var task Task
+		if err := q.decode([]byte(taskJSON), &task); err != nil {
+			q.logger.Error(fmt.Sprintf("Error decoding delayed task: %v", err))
+			continue
+		}
+
+		// The score is authoritative for when the task is released
+		task.ScheduledAt = time.Unix(int64(entry.Score), 0)
+		tasks = append(tasks, &task)
+	}
+
+	return tasks, nil
+}
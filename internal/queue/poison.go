@@ -0,0 +1,67 @@
+// internal/queue/poison.go
+package queue
+
+import (
+	"fmt"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	// PoisonTasksKey holds the raw entries that could not be decoded as tasks
+	PoisonTasksKey = "poison_tasks"
+
+	// MaxPoisonTasks bounds the poison list; older entries are dropped first
+	MaxPoisonTasks = 10000
+)
+
+// quarantineDelayedScript moves an entry from the delayed set to the poison
+// list. It returns 0 if another processor removed the entry first.
+var quarantineDelayedScript = redis.NewScript(`
+if redis.call("ZREM", KEYS[1], ARGV[1]) == 0 then
+	return 0
+end
+redis.call("LPUSH", KEYS[2], ARGV[1])
+redis.call("LTRIM", KEYS[2], 0, tonumber(ARGV[2]) - 1)
+return 1
+`)
+
+// quarantine stores a raw entry popped from a queue that could not be decoded
+// in the poison list, so that it can be inspected instead of being lost
+func (q *RedisQueue) quarantine(queueName, raw string, decodeErr error) {
+	q.logger.Error(fmt.Sprintf("Moving undecodable entry from %s to %s: %v", queueName, PoisonTasksKey, decodeErr))
+	if q.metrics != nil {
+		q.metrics.RecordPoisonTask(queueName)
+	}
+
+	_, err := q.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.LPush(ctx, queueKey(PoisonTasksKey), raw)
+		pipe.LTrim(ctx, queueKey(PoisonTasksKey), 0, MaxPoisonTasks-1)
+		return nil
+	})
+	if err != nil {
+		q.logger.Error(fmt.Sprintf("Failed to store undecodable entry from %s: %v", queueName, err))
+	}
+}
+
+// quarantineDelayed moves an undecodable entry from the delayed set to the
+// poison list, so that it stops being picked up by every sweep
+func (q *RedisQueue) quarantineDelayed(raw string, decodeErr error) {
+	moved, err := quarantineDelayedScript.Run(ctx, q.client,
+		[]string{queueKey(DelayedTasksKey), queueKey(PoisonTasksKey)}, raw, MaxPoisonTasks).Int()
+	if err != nil {
+		q.logger.Error(fmt.Sprintf("Failed to move undecodable entry from %s: %v", DelayedTasksKey, err))
+		return
+	}
+	if moved == 1 {
+		q.logger.Error(fmt.Sprintf("Moved undecodable entry from %s to %s: %v", DelayedTasksKey, PoisonTasksKey, decodeErr))
+		if q.metrics != nil {
+			q.metrics.RecordPoisonTask(DelayedTasksKey)
+		}
+	}
+}
+
+// PoisonTasks returns up to limit of the most recently quarantined raw entries
+func (q *RedisQueue) PoisonTasks(limit int) ([]string, error) {
+	return q.client.LRange(ctx, queueKey(PoisonTasksKey), 0, int64(limit)-1).Result()
+}
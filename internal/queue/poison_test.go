@@ -0,0 +1,38 @@
+package queue
+
+import (
+	"errors"
+	"testing"
+
+	"BoltQ/pkg/metrics"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestQuarantineUndecodableEntry(t *testing.T) {
+	q, server := newTestQueue(t)
+	q.SetMetrics(metrics.NewMetricsCollector("test"))
+
+	queueName := getQueueName(PriorityNormal)
+	counter := metrics.PoisonTasks.WithLabelValues(queueName)
+	before := testutil.ToFloat64(counter)
+
+	server.Lpush(queueKey(queueName), "not a task")
+
+	if _, err := q.Consume(); !errors.Is(err, redis.Nil) {
+		t.Fatalf("Consume = %v, want redis.Nil after quarantining the entry", err)
+	}
+
+	poisoned, err := q.PoisonTasks(10)
+	if err != nil {
+		t.Fatalf("PoisonTasks: %v", err)
+	}
+	if len(poisoned) != 1 || poisoned[0] != "not a task" {
+		t.Errorf("PoisonTasks = %q, want the undecodable entry", poisoned)
+	}
+
+	if got := testutil.ToFloat64(counter) - before; got != 1 {
+		t.Errorf("poison task metric increased by %v, want 1", got)
+	}
+}
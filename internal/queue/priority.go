@@ -0,0 +1,50 @@
+// internal/queue/priority.go
+package queue
+
+import "strings"
+
+// priorityNames maps priority names to their canonical values
+var priorityNames = map[string]int{
+	"low":      PriorityLow,
+	"normal":   PriorityNormal,
+	"high":     PriorityHigh,
+	"critical": PriorityCritical,
+}
+
+// IsValidPriority reports whether a priority is within the supported range
+func IsValidPriority(priority int) bool {
+	return priority >= MinPriority && priority <= MaxPriority
+}
+
+// NormalizePriority clamps a priority into the supported range so that every
+// task lands in a queue that Consume actually checks
+func NormalizePriority(priority int) int {
+	if priority < MinPriority {
+		return MinPriority
+	}
+	if priority > MaxPriority {
+		return MaxPriority
+	}
+	return priority
+}
+
+// PriorityFromName maps a priority name ("low", "normal", "high", "critical")
+// to its canonical value. The second return value is false for unknown names.
+func PriorityFromName(name string) (int, bool) {
+	priority, ok := priorityNames[strings.ToLower(strings.TrimSpace(name))]
+	return priority, ok
+}
+
+// PriorityName returns the name of a priority, clamping out-of-range values
+func PriorityName(priority int) string {
+	switch NormalizePriority(priority) {
+	case PriorityLow:
+		return "low"
+	case PriorityHigh:
+		return "high"
+	case PriorityCritical:
+		return "critical"
+	default:
+		return "normal"
+	}
+}
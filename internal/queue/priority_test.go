@@ -0,0 +1,106 @@
+package queue
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/go-redis/redis/v8"
+)
+
+func TestConsumeOrder(t *testing.T) {
+	for published := MinPriority; published <= MaxPriority; published++ {
+		for other := MinPriority; other <= MaxPriority; other++ {
+			t.Run(fmt.Sprintf("%s_then_%s", PriorityName(other), PriorityName(published)), func(t *testing.T) {
+				q, _ := newTestQueue(t)
+
+				// Publish the other task first so that FIFO order alone
+				// would consume it first
+				first := &Task{ID: "first", Type: "test", Priority: other}
+				second := &Task{ID: "second", Type: "test", Priority: published}
+				for _, task := range []*Task{first, second} {
+					if err := q.Publish(task); err != nil {
+						t.Fatalf("Publish(%s): %v", task.ID, err)
+					}
+				}
+
+				want := []string{"first", "second"}
+				if published > other {
+					want = []string{"second", "first"}
+				}
+
+				for _, id := range want {
+					task, err := q.Consume()
+					if err != nil {
+						t.Fatalf("Consume: %v", err)
+					}
+					if task.ID != id {
+						t.Fatalf("Consume = %s (priority %d), want %s", task.ID, task.Priority, id)
+					}
+				}
+
+				if _, err := q.Consume(); !errors.Is(err, redis.Nil) {
+					t.Fatalf("Consume on empty queues = %v, want redis.Nil", err)
+				}
+			})
+		}
+	}
+}
+
+func TestConsumeOrderAllLevels(t *testing.T) {
+	tests := []struct {
+		name       string
+		priorities []int
+		allowed    []int
+		want       []int
+	}{
+		{
+			name:       "ascending",
+			priorities: []int{PriorityLow, PriorityNormal, PriorityHigh, PriorityCritical},
+			want:       []int{PriorityCritical, PriorityHigh, PriorityNormal, PriorityLow},
+		},
+		{
+			name:       "descending",
+			priorities: []int{PriorityCritical, PriorityHigh, PriorityNormal, PriorityLow},
+			want:       []int{PriorityCritical, PriorityHigh, PriorityNormal, PriorityLow},
+		},
+		{
+			name:       "out of range is clamped",
+			priorities: []int{MinPriority - 1, MaxPriority + 1, PriorityNormal},
+			want:       []int{MaxPriority, PriorityNormal, MinPriority},
+		},
+		{
+			name:       "allowed priorities in the given order",
+			priorities: []int{PriorityLow, PriorityNormal, PriorityHigh, PriorityCritical},
+			allowed:    []int{PriorityLow, PriorityHigh},
+			want:       []int{PriorityLow, PriorityHigh},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, _ := newTestQueue(t)
+
+			for i, priority := range tt.priorities {
+				task := &Task{ID: fmt.Sprintf("task-%d", i), Type: "test", Priority: priority}
+				if err := q.Publish(task); err != nil {
+					t.Fatalf("Publish(%s): %v", task.ID, err)
+				}
+			}
+
+			for _, want := range tt.want {
+				task, err := q.ConsumePriorities("worker-1", tt.allowed)
+				if err != nil {
+					t.Fatalf("ConsumePriorities: %v", err)
+				}
+				if task.Priority != want {
+					t.Fatalf("consumed priority %d, want %d", task.Priority, want)
+				}
+			}
+
+			if _, err := q.ConsumePriorities("worker-1", tt.allowed); !errors.Is(err, redis.Nil) {
+				t.Fatalf("ConsumePriorities after draining = %v, want redis.Nil", err)
+			}
+		})
+	}
+}
@@ -5,11 +5,17 @@ import (
 	"time"
 )
 
-// Priority levels for jobs
+// Priority levels for jobs. These are the canonical priority values used for
+// queue names and consume order; higher values are consumed first.
 const (
-	PriorityLow    = 0
-	PriorityNormal = 1
-	PriorityHigh   = 2
+	PriorityLow      = 0
+	PriorityNormal   = 1
+	PriorityHigh     = 2
+	PriorityCritical = 3
+
+	// MinPriority and MaxPriority bound the supported priority range
+	MinPriority = PriorityLow
+	MaxPriority = PriorityCritical
 )
 
 // JobStatus represents the current state of a job
@@ -60,7 +66,8 @@ type Queue interface {
 	// PublishDelayed adds a job to be executed at a future time
 	PublishDelayed(ctx context.Context, job *Job, delay time.Duration) error
 
-	// Consume retrieves the next available job from the queue
+	// Consume retrieves the next available job from the queue. It returns a
+	// nil job and no error when no job is available.
 	Consume(ctx context.Context) (*Job, error)
 
 	// UpdateStatus updates a job's status
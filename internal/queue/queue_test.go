@@ -0,0 +1,38 @@
+package queue
+
+import (
+	"context"
+	"testing"
+)
+
+func TestConsumeEmptyQueue(t *testing.T) {
+	tests := []struct {
+		name  string
+		queue func(t *testing.T) Queue
+	}{
+		{"memory", func(t *testing.T) Queue { return NewMemoryQueue() }},
+		{"redis", func(t *testing.T) Queue {
+			q, _ := newTestQueue(t)
+			return NewRedisQueueAdapter(q)
+		}},
+		{"zset", func(t *testing.T) Queue {
+			q, _ := newTestQueue(t)
+			return NewZSetQueue(q.client, testLogger{})
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := tt.queue(t)
+			defer q.Close()
+
+			job, err := q.Consume(context.Background())
+			if err != nil {
+				t.Fatalf("Consume on an empty queue: %v, want no error", err)
+			}
+			if job != nil {
+				t.Errorf("Consume on an empty queue = job %s, want nil", job.ID)
+			}
+		})
+	}
+}
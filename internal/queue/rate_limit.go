@@ -0,0 +1,106 @@
+// internal/queue/rate_limit.go
+package queue
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// RateLimitKeyPrefix prefixes the token buckets of rate limited clients
+const RateLimitKeyPrefix = "rate_limit"
+
+// RateLimit allows Rate requests per second on average, in bursts of up to
+// Burst requests. A zero Rate means no limit.
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+// Unlimited reports whether the limit lets every request through
+func (l RateLimit) Unlimited() bool {
+	return l.Rate <= 0
+}
+
+// String formats the limit as ParseRateLimit accepts it
+func (l RateLimit) String() string {
+	if l.Unlimited() {
+		return "0"
+	}
+	return fmt.Sprintf("%s/%d", strconv.FormatFloat(l.Rate, 'f', -1, 64), l.Burst)
+}
+
+// ParseRateLimit parses a rate limit written as requests per second, optionally
+// followed by a slash and the burst size, e.g. "10/50". Without a burst size,
+// bursts of one second's worth of requests are allowed. "0" means no limit.
+func ParseRateLimit(value string) (RateLimit, error) {
+	rateStr, burstStr, hasBurst := strings.Cut(strings.TrimSpace(value), "/")
+
+	rate, err := strconv.ParseFloat(strings.TrimSpace(rateStr), 64)
+	if err != nil || rate < 0 || math.IsInf(rate, 0) {
+		return RateLimit{}, fmt.Errorf("invalid rate limit %q: rate must be a non-negative number", value)
+	}
+
+	burst := int(math.Ceil(rate))
+	if hasBurst {
+		burst, err = strconv.Atoi(strings.TrimSpace(burstStr))
+		if err != nil || burst < 1 {
+			return RateLimit{}, fmt.Errorf("invalid rate limit %q: burst must be at least 1", value)
+		}
+	}
+	if burst < 1 {
+		burst = 1
+	}
+
+	return RateLimit{Rate: rate, Burst: burst}, nil
+}
+
+// takeTokenScript takes a token from a bucket refilled at ARGV[1] tokens per
+// second up to ARGV[2] tokens, as of ARGV[3] milliseconds. It returns 1 and 0
+// if a token was taken, or 0 and the milliseconds until one is available.
+var takeTokenScript = redis.NewScript(`
+local rate, burst, now = tonumber(ARGV[1]), tonumber(ARGV[2]), tonumber(ARGV[3])
+local state = redis.call("HMGET", KEYS[1], "tokens", "ts")
+local tokens, ts = tonumber(state[1]), tonumber(state[2])
+if tokens == nil or ts == nil then
+	tokens, ts = burst, now
+end
+tokens = math.min(burst, tokens + math.max(0, now - ts) * rate / 1000)
+
+local allowed, wait = 0, 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+else
+	wait = math.ceil((1 - tokens) * 1000 / rate)
+end
+
+redis.call("HSET", KEYS[1], "tokens", tostring(tokens), "ts", tostring(now))
+redis.call("PEXPIRE", KEYS[1], math.ceil(burst * 1000 / rate) + 1000)
+return {allowed, wait}
+`)
+
+// TakeRateLimitToken takes a token from the bucket of a client, shared by all
+// instances using the same Redis. It returns false and how long to wait for
+// the next token if the client has exceeded its limit.
+func (q *RedisQueue) TakeRateLimitToken(client string, limit RateLimit) (bool, time.Duration, error) {
+	if limit.Unlimited() {
+		return true, 0, nil
+	}
+
+	key := queueKey(fmt.Sprintf("%s:%s", RateLimitKeyPrefix, client))
+	result, err := takeTokenScript.Run(ctx, q.client, []string{key},
+		limit.Rate, limit.Burst, time.Now().UnixMilli()).Int64Slice()
+	if err != nil {
+		return false, 0, err
+	}
+	if len(result) != 2 {
+		return false, 0, fmt.Errorf("unexpected rate limit script result: %v", result)
+	}
+
+	return result[0] == 1, time.Duration(result[1]) * time.Millisecond, nil
+}
@@ -0,0 +1,32 @@
+// internal/queue/read_only.go
+package queue
+
+import (
+	"github.com/go-redis/redis/v8"
+)
+
+// ReadOnlyKey holds the read-only mode set at runtime, shared by all API
+// instances. While it is unset, each instance uses its configured default.
+const ReadOnlyKey = "read_only"
+
+// GetReadOnly returns the read-only mode set at runtime. ok is false if it was
+// never set.
+func (q *RedisQueue) GetReadOnly() (enabled bool, ok bool, err error) {
+	value, err := q.client.Get(ctx, queueKey(ReadOnlyKey)).Result()
+	if err == redis.Nil {
+		return false, false, nil
+	}
+	if err != nil {
+		return false, false, err
+	}
+	return value == "1", true, nil
+}
+
+// SetReadOnly sets the read-only mode of all API instances
+func (q *RedisQueue) SetReadOnly(enabled bool) error {
+	value := "0"
+	if enabled {
+		value = "1"
+	}
+	return q.client.Set(ctx, queueKey(ReadOnlyKey), value, 0).Err()
+}
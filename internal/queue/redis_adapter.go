@@ -4,6 +4,8 @@ package queue
 import (
 	"context"
 	"time"
+
+	"github.com/go-redis/redis/v8"
 )
 
 // RedisQueueAdapter adapts RedisQueue to implement the Queue interface
@@ -20,63 +22,27 @@ func NewRedisQueueAdapter(redisQueue *RedisQueue) Queue {
 
 // Publish adds a job to the queue with specified priority
 func (a *RedisQueueAdapter) Publish(ctx context.Context, job *Job) error {
-	// Convert Job to Task
-	task := &Task{
-		ID:          job.ID,
-		Type:        job.Type,
-		Data:        job.Payload,
-		Priority:    job.Priority,
-		CreatedAt:   job.CreatedAt,
-		ScheduledAt: job.ScheduledAt,
-		Status:      string(job.Status),
-		Attempts:    job.Attempts,
-		LastError:   job.Error,
-	}
-
-	return a.redisQueue.Publish(task)
+	return a.redisQueue.Publish(TaskFromJob(job))
 }
 
 // PublishDelayed adds a job to be executed at a future time
 func (a *RedisQueueAdapter) PublishDelayed(ctx context.Context, job *Job, delay time.Duration) error {
-	// Convert Job to Task
-	task := &Task{
-		ID:          job.ID,
-		Type:        job.Type,
-		Data:        job.Payload,
-		Priority:    job.Priority,
-		CreatedAt:   job.CreatedAt,
-		ScheduledAt: job.ScheduledAt,
-		Status:      string(job.Status),
-		Attempts:    job.Attempts,
-		LastError:   job.Error,
-	}
-
 	delaySeconds := int(delay.Seconds())
-	return a.redisQueue.PublishDelayed(task, delaySeconds)
+	return a.redisQueue.PublishDelayed(TaskFromJob(job), delaySeconds)
 }
 
-// Consume retrieves the next available job from the queue
+// Consume retrieves the next available job from the queue. It returns nil and
+// no error when no job is available.
 func (a *RedisQueueAdapter) Consume(ctx context.Context) (*Job, error) {
 	task, err := a.redisQueue.Consume()
+	if err == redis.Nil {
+		return nil, nil
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	// Convert Task to Job
-	job := &Job{
-		ID:          task.ID,
-		Type:        task.Type,
-		Payload:     task.Data,
-		Priority:    task.Priority,
-		ScheduledAt: task.ScheduledAt,
-		Status:      JobStatus(task.Status),
-		Attempts:    task.Attempts,
-		Error:       task.LastError,
-		CreatedAt:   task.CreatedAt,
-		UpdatedAt:   time.Now(),
-	}
-
-	return job, nil
+	return JobFromTask(task), nil
 }
 
 // UpdateStatus updates a job's status
@@ -103,21 +69,7 @@ func (a *RedisQueueAdapter) GetJob(ctx context.Context, jobID string) (*Job, err
 		return nil, err
 	}
 
-	// Convert Task to Job
-	job := &Job{
-		ID:          task.ID,
-		Type:        task.Type,
-		Payload:     task.Data,
-		Priority:    task.Priority,
-		ScheduledAt: task.ScheduledAt,
-		Status:      JobStatus(task.Status),
-		Attempts:    task.Attempts,
-		Error:       task.LastError,
-		CreatedAt:   task.CreatedAt,
-		UpdatedAt:   time.Now(),
-	}
-
-	return job, nil
+	return JobFromTask(task), nil
 }
 
 // GetStats returns statistics about the queue
@@ -0,0 +1,74 @@
+// internal/queue/redis_client.go
+package queue
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// RedisClient is the subset of Redis commands BoltQ uses. Every client
+// returned by NewRedisClient satisfies it; tests can pass a stub or an
+// in-memory implementation instead of a connection to a real server.
+type RedisClient interface {
+	// Scripter runs the Lua scripts used for atomic multi-key updates
+	redis.Scripter
+
+	Ping(ctx context.Context) *redis.StatusCmd
+	Close() error
+
+	// Strings and keys
+	Get(ctx context.Context, key string) *redis.StringCmd
+	Incr(ctx context.Context, key string) *redis.IntCmd
+	Set(ctx context.Context, key string, value interface{}, expiration time.Duration) *redis.StatusCmd
+	SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) *redis.BoolCmd
+	Del(ctx context.Context, keys ...string) *redis.IntCmd
+	Expire(ctx context.Context, key string, expiration time.Duration) *redis.BoolCmd
+	PTTL(ctx context.Context, key string) *redis.DurationCmd
+	Scan(ctx context.Context, cursor uint64, match string, count int64) *redis.ScanCmd
+
+	// Lists
+	LPush(ctx context.Context, key string, values ...interface{}) *redis.IntCmd
+	RPush(ctx context.Context, key string, values ...interface{}) *redis.IntCmd
+	RPop(ctx context.Context, key string) *redis.StringCmd
+	LPop(ctx context.Context, key string) *redis.StringCmd
+	BRPop(ctx context.Context, timeout time.Duration, keys ...string) *redis.StringSliceCmd
+	BLPop(ctx context.Context, timeout time.Duration, keys ...string) *redis.StringSliceCmd
+	LRange(ctx context.Context, key string, start, stop int64) *redis.StringSliceCmd
+	LRem(ctx context.Context, key string, count int64, value interface{}) *redis.IntCmd
+	LIndex(ctx context.Context, key string, index int64) *redis.StringCmd
+	LLen(ctx context.Context, key string) *redis.IntCmd
+
+	// Sorted sets
+	ZAdd(ctx context.Context, key string, members ...*redis.Z) *redis.IntCmd
+	ZRem(ctx context.Context, key string, members ...interface{}) *redis.IntCmd
+	ZRange(ctx context.Context, key string, start, stop int64) *redis.StringSliceCmd
+	ZRangeWithScores(ctx context.Context, key string, start, stop int64) *redis.ZSliceCmd
+	ZRangeByScore(ctx context.Context, key string, opt *redis.ZRangeBy) *redis.StringSliceCmd
+	ZRevRange(ctx context.Context, key string, start, stop int64) *redis.StringSliceCmd
+	ZRemRangeByScore(ctx context.Context, key, min, max string) *redis.IntCmd
+	ZPopMin(ctx context.Context, key string, count ...int64) *redis.ZSliceCmd
+	ZCard(ctx context.Context, key string) *redis.IntCmd
+	ZCount(ctx context.Context, key, min, max string) *redis.IntCmd
+	ZScore(ctx context.Context, key, member string) *redis.FloatCmd
+
+	// Hashes and streams
+	HMGet(ctx context.Context, key string, fields ...string) *redis.SliceCmd
+	HGetAll(ctx context.Context, key string) *redis.StringStringMapCmd
+	HIncrBy(ctx context.Context, key, field string, incr int64) *redis.IntCmd
+	XRange(ctx context.Context, stream, start, stop string) *redis.XMessageSliceCmd
+
+	// Pipelines and transactions
+	Pipeline() redis.Pipeliner
+	TxPipeline() redis.Pipeliner
+	TxPipelined(ctx context.Context, fn func(redis.Pipeliner) error) ([]redis.Cmder, error)
+	Watch(ctx context.Context, fn func(*redis.Tx) error, keys ...string) error
+
+	// Pub/Sub
+	Publish(ctx context.Context, channel string, message interface{}) *redis.IntCmd
+	Subscribe(ctx context.Context, channels ...string) *redis.PubSub
+}
+
+// Every deployment mode of NewRedisClient must keep satisfying RedisClient
+var _ RedisClient = (redis.UniversalClient)(nil)
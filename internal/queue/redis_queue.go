@@ -3,158 +3,419 @@ package queue
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
+	"sync"
 	"time"
 
+	"BoltQ/pkg/metrics"
+	"BoltQ/pkg/tracing"
+
 	"github.com/go-redis/redis/v8"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 var ctx = context.Background()
 
+// logSampleKey is the data field that groups high-volume log messages for
+// sampling (see logger.SampleKeyField)
+const logSampleKey = "sample_key"
+
+// maxStatusUpdateAttempts bounds the retries of a conditional status update
+// that lost a race with a concurrent update
+const maxStatusUpdateAttempts = 3
+
+// DefaultDelayedBatchSize is how many ready delayed tasks one sweep moves at most
+const DefaultDelayedBatchSize = 1000
+
 const (
 	// Queue names
 	TaskQueuePrefix = "task_queue"
 	DelayedTasksKey = "delayed_tasks"
-	DeadLetterQueue = "dead_letter_queue"
+	DeadLetterQueue = "dead_letter_queue" // stats name of DeadLetterTasksKey
+
+	// KeyHashTag prefixes the Redis keys of all queues so that in Redis Cluster
+	// they hash to the same slot and operations spanning several queues, such as
+	// moving a delayed task onto a priority queue, stay on one node
+	KeyHashTag = "{boltq}"
 )
 
 // Task represents a job to be processed
 type Task struct {
-	ID          string                 `json:"id"`
-	Type        string                 `json:"type"`
-	Data        map[string]interface{} `json:"data"`
-	Priority    int                    `json:"priority"`
-	CreatedAt   time.Time              `json:"created_at"`
-	ScheduledAt time.Time              `json:"scheduled_at,omitempty"`
-	Status      string                 `json:"status"`
-	Attempts    int                    `json:"attempts"`
-	LastError   string                 `json:"last_error,omitempty"`
+	ID             string                 `json:"id"`
+	Type           string                 `json:"type"`
+	Data           map[string]interface{} `json:"data"`
+	Priority       int                    `json:"priority"`
+	CreatedAt      time.Time              `json:"created_at"`
+	SubmittedAt    time.Time              `json:"submitted_at,omitempty"`
+	ScheduledAt    time.Time              `json:"scheduled_at,omitempty"`
+	Status         string                 `json:"status"`
+	Attempts       int                    `json:"attempts"`
+	LastError      string                 `json:"last_error,omitempty"`
+	ErrorCategory  string                 `json:"error_category,omitempty"`
+	AttemptHistory []AttemptRecord        `json:"attempt_history,omitempty"`
+	WorkerID       string                 `json:"worker_id,omitempty"`
+	CallbackURL    string                 `json:"callback_url,omitempty"`
+	DependsOnJobID string                 `json:"depends_on_job_id,omitempty"`
+	WaitingSince   *time.Time             `json:"waiting_since,omitempty"`
+	Deadline       *time.Time             `json:"deadline,omitempty"`
+	RetryDeadline  *time.Time             `json:"retry_deadline,omitempty"`
+	Tags           []string               `json:"tags,omitempty"`
+	PayloadRefs    []string               `json:"payload_refs,omitempty"`
 }
 
 // RedisQueue implements a Redis-backed task queue
 type RedisQueue struct {
-	client *redis.Client
-	logger Logger
+	client          RedisClient
+	logger          Logger
+	metrics         *metrics.MetricsCollector
+	audit           *AuditLog
+	maxQueueLength  int64
+	overflowPolicy  OverflowPolicy
+	maxPayloadSize  int64
+	serializer      Serializer
+	deadLetterFns   []func(task *Task)
+	queueOrders     map[string]QueueOrder
+	retryPolicy     RetryPolicy
+	activeTaskTTL   time.Duration
+	terminalTaskTTL time.Duration
+	mu              sync.RWMutex
 }
 
 // NewRedisQueue creates a new Redis queue
-func NewRedisQueue(client *redis.Client, logger Logger) *RedisQueue {
+func NewRedisQueue(client RedisClient, logger Logger) *RedisQueue {
 	return &RedisQueue{
-		client: client,
-		logger: logger,
+		client:         client,
+		logger:         logger,
+		maxPayloadSize: DefaultMaxPayloadSize,
+		serializer:     JSONSerializer{},
+		retryPolicy:    DefaultRetryPolicy,
+		activeTaskTTL:  DefaultTaskTTL,
+	}
+}
+
+// SetMetrics sets the collector the queue records its metrics with. Without
+// one, the queue records none.
+func (q *RedisQueue) SetMetrics(metricsCollector *metrics.MetricsCollector) {
+	q.metrics = metricsCollector
+}
+
+// EnableAuditLog turns recording of status transitions into per-task audit
+// streams on or off. It is off by default because it adds a write per transition.
+func (q *RedisQueue) EnableAuditLog(enabled bool) {
+	if enabled {
+		q.audit = NewAuditLog(q.client)
+	} else {
+		q.audit = nil
 	}
 }
 
+// SetMaxQueueLength limits the length of each priority queue. When a queue is
+// full, Publish either fails with ErrQueueFull or drops the oldest tasks,
+// depending on the policy. A maxLength of 0 or less removes the limit.
+func (q *RedisQueue) SetMaxQueueLength(maxLength int64, policy OverflowPolicy) {
+	q.maxQueueLength = maxLength
+	q.overflowPolicy = policy
+}
+
+// GetTaskHistory returns the recorded status transitions of a task
+func (q *RedisQueue) GetTaskHistory(taskID string) ([]AuditEvent, error) {
+	return NewAuditLog(q.client).History(taskID)
+}
+
 // Publish adds a task to the queue immediately
-func (q *RedisQueue) Publish(task *Task) error {
+func (q *RedisQueue) Publish(task *Task) (err error) {
 	task.CreatedAt = time.Now()
+	task.markSubmitted()
 	task.Status = "pending"
+	task.Priority = NormalizePriority(task.Priority)
+
+	ctx, span := startSpan(ctx, "RedisQueue.Publish", taskAttributes(task)...)
+	defer func() { endSpan(ctx, span, err) }()
 
-	return q.publishToQueue(task, getQueueName(task.Priority))
+	if err := q.storeBeforePublish(ctx, task); err != nil {
+		return err
+	}
+
+	if err := q.publishToQueue(task, taskQueueName(task)); err != nil {
+		q.markPublishFailed(ctx, task, err)
+		return err
+	}
+
+	return nil
 }
 
 // PublishDelayed schedules a task for future execution
-func (q *RedisQueue) PublishDelayed(task *Task, delaySeconds int) error {
+func (q *RedisQueue) PublishDelayed(task *Task, delaySeconds int) (err error) {
 	task.CreatedAt = time.Now()
+	task.markSubmitted()
 	task.ScheduledAt = time.Now().Add(time.Duration(delaySeconds) * time.Second)
 	task.Status = "scheduled"
+	task.Priority = NormalizePriority(task.Priority)
+
+	ctx, span := startSpan(ctx, "RedisQueue.PublishDelayed",
+		attribute.String(queueNameAttribute, DelayedTasksKey), attribute.String(taskIDAttribute, task.ID))
+	defer func() { endSpan(ctx, span, err) }()
+
+	if err := q.storeBeforePublish(ctx, task); err != nil {
+		return err
+	}
 
-	taskJSON, err := json.Marshal(task)
+	taskJSON, err := q.encode(task)
 	if err != nil {
 		return err
 	}
 
 	// Store in a Redis sorted set with score = unix timestamp when task should execute
 	score := float64(task.ScheduledAt.Unix())
-	err = q.client.ZAdd(ctx, DelayedTasksKey, &redis.Z{
+	err = q.client.ZAdd(ctx, queueKey(DelayedTasksKey), &redis.Z{
 		Score:  score,
 		Member: string(taskJSON),
 	}).Err()
 
 	if err != nil {
+		q.markPublishFailed(ctx, task, err)
 		return err
 	}
 
-	q.logger.Info(fmt.Sprintf("Task %s scheduled for %s", task.ID, task.ScheduledAt.Format(time.RFC3339)))
+	q.logger.Info(fmt.Sprintf("Task %s scheduled for %s", task.ID, task.ScheduledAt.Format(time.RFC3339)),
+		map[string]interface{}{logSampleKey: "task_scheduled"})
 	return nil
 }
 
-// ProcessDelayedTasks moves ready tasks from delayed set to regular queue
-func (q *RedisQueue) ProcessDelayedTasks() (int, error) {
+// PublishUnique publishes a task whose ID is chosen by the caller, immediately
+// or after delaySeconds. If a task with that ID already exists, nothing is
+// published and the existing task is returned together with ErrTaskExists,
+// which makes retried submissions idempotent.
+func (q *RedisQueue) PublishUnique(task *Task, delaySeconds int) (*Task, error) {
+	key := taskKey(task.ID)
+
+	placeholder, err := q.encode(Task{ID: task.ID, Type: task.Type, Status: "pending", CreatedAt: time.Now()})
+	if err != nil {
+		return nil, err
+	}
+
+	// Reserve the ID atomically so concurrent submissions can't both publish
+	reserved, err := q.client.SetNX(ctx, key, string(placeholder), q.taskTTL(string(StatusPending))).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	if !reserved {
+		existing, err := q.GetTaskStatus(task.ID)
+		if err != nil {
+			return nil, err
+		}
+		q.recordDuplicate(task)
+		return existing, ErrTaskExists
+	}
+	q.adjustStatusCounts(ctx, "", string(StatusPending))
+
+	if delaySeconds > 0 {
+		err = q.PublishDelayed(task, delaySeconds)
+	} else {
+		err = q.Publish(task)
+	}
+
+	if err != nil {
+		// Release the ID so the caller can retry the submission
+		if delErr := q.client.Del(ctx, key).Err(); delErr != nil {
+			q.logger.Error(fmt.Sprintf("Failed to release task ID %s: %v", task.ID, delErr))
+		} else {
+			q.adjustStatusCounts(ctx, task.Status, "")
+		}
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+// markSubmitted records the first time a task is published. Unlike CreatedAt,
+// which every retry or requeue resets, it is kept for the life of the task.
+func (t *Task) markSubmitted() {
+	if t.SubmittedAt.IsZero() {
+		t.SubmittedAt = t.CreatedAt
+	}
+}
+
+// Latency returns how long ago the task was first submitted, including the
+// time it spent waiting, retrying and running
+func (t *Task) Latency() time.Duration {
+	submittedAt := t.SubmittedAt
+	if submittedAt.IsZero() {
+		submittedAt = t.CreatedAt
+	}
+	return time.Since(submittedAt)
+}
+
+// storeBeforePublish checks the payload size and stores the task record, so
+// its status is visible before a worker can pick it up
+func (q *RedisQueue) storeBeforePublish(ctx context.Context, task *Task) error {
+	taskJSON, err := q.encode(task)
+	if err != nil {
+		return err
+	}
+
+	if err := q.checkPayloadSize(task, taskJSON); err != nil {
+		return err
+	}
+
+	return q.updateStatus(ctx, task)
+}
+
+// markPublishFailed records that a task could not be enqueued
+func (q *RedisQueue) markPublishFailed(ctx context.Context, task *Task, publishErr error) {
+	task.Status = "failed"
+	task.LastError = fmt.Sprintf("failed to enqueue task: %v", publishErr)
+
+	if err := q.updateStatus(ctx, task); err != nil {
+		q.logger.Error(fmt.Sprintf("Failed to update status for task %s: %v", task.ID, err))
+	}
+}
+
+// ProcessDelayedTasks moves up to batchSize ready tasks from the delayed set
+// to their queues, or every ready task if batchSize is not positive. It also
+// reports whether more ready tasks may remain, so the caller can sweep again.
+func (q *RedisQueue) ProcessDelayedTasks(batchSize int) (count int, more bool, err error) {
+	ctx, span := startSpan(ctx, "RedisQueue.ProcessDelayedTasks", attribute.String(queueNameAttribute, DelayedTasksKey))
+	defer func() {
+		tracing.AddSpanAttributes(ctx, attribute.Int("boltq.tasks_moved", count))
+		endSpan(ctx, span, err)
+	}()
+
 	now := time.Now().Unix()
 
 	// Find tasks that are ready to be processed (score <= current timestamp)
-	tasks, err := q.client.ZRangeByScore(ctx, DelayedTasksKey, &redis.ZRangeBy{
+	rangeBy := &redis.ZRangeBy{
 		Min: "0",
 		Max: fmt.Sprintf("%d", now),
-	}).Result()
+	}
+	if batchSize > 0 {
+		rangeBy.Count = int64(batchSize)
+	}
 
+	tasks, err := q.client.ZRangeByScore(ctx, queueKey(DelayedTasksKey), rangeBy).Result()
 	if err != nil {
-		return 0, err
+		return 0, false, err
 	}
 
-	count := 0
+	more = batchSize > 0 && len(tasks) == batchSize
 
 	// Process each ready task
 	for _, taskJSON := range tasks {
 		var task Task
-		if err := json.Unmarshal([]byte(taskJSON), &task); err != nil {
-			q.logger.Info(fmt.Sprintf("Error unmarshalling delayed task: %v", err))
+		if err := q.decode([]byte(taskJSON), &task); err != nil {
+			q.quarantineDelayed(taskJSON, err)
 			continue
 		}
 
-		// Update status and publish to appropriate queue
-		task.Status = "pending"
-		if err := q.publishToQueue(&task, getQueueName(task.Priority)); err != nil {
-			q.logger.Info(fmt.Sprintf("Error publishing delayed task %s: %v", task.ID, err))
+		// Remove from delayed set first; another processor may have claimed it
+		removed, err := q.client.ZRem(ctx, queueKey(DelayedTasksKey), taskJSON).Result()
+		if err != nil {
+			q.logger.Info(fmt.Sprintf("Error removing task %s from delayed set: %v", task.ID, err))
+			continue
+		}
+		if removed == 0 {
 			continue
 		}
 
-		// Remove from delayed set
-		if err := q.client.ZRem(ctx, DelayedTasksKey, taskJSON).Err(); err != nil {
-			q.logger.Info(fmt.Sprintf("Error removing task %s from delayed set: %v", task.ID, err))
+		// Update status and publish to appropriate queue. Tasks delayed before
+		// priorities were normalized may carry an out of range priority, so
+		// clamp it to one the consume loop checks.
+		task.Status = "pending"
+		task.Priority = NormalizePriority(task.Priority)
+		if err := q.publishToQueue(&task, taskQueueName(&task)); err != nil {
+			q.logger.Info(fmt.Sprintf("Error publishing delayed task %s: %v", task.ID, err))
+			q.markPublishFailed(ctx, &task, err)
 			continue
 		}
 
 		count++
 	}
 
-	return count, nil
+	return count, more, nil
 }
 
 // Consume retrieves a task from the queue, checking high priority first
-func (q *RedisQueue) Consume() (*Task, error) {
-	// Try to consume from high priority to low priority
-	for priority := PriorityHigh; priority <= PriorityLow; priority++ {
-		queueName := getQueueName(priority)
-		taskJSON, err := q.client.RPop(ctx, queueName).Result()
+func (q *RedisQueue) Consume() (task *Task, err error) {
+	ctx, span := startSpan(ctx, "RedisQueue.Consume")
+	defer func() { endConsumeSpan(ctx, span, task, err) }()
 
-		if err == redis.Nil {
-			// No tasks in this queue, try the next one
-			continue
-		}
+	// Consume from highest priority to lowest priority
+	return q.consumeFirst(ctx, "", priorityQueueNames(nil))
+}
+
+// ConsumePriorities retrieves a task for the given worker from the first
+// non-empty queue of the given priorities, checked in the order given, or of
+// all priorities from the highest down if none are given. It returns
+// redis.Nil if all of those queues are empty.
+func (q *RedisQueue) ConsumePriorities(workerID string, priorities []int) (task *Task, err error) {
+	ctx, span := startSpan(ctx, "RedisQueue.ConsumePriorities")
+	defer func() { endConsumeSpan(ctx, span, task, err) }()
+
+	return q.consumeFirst(ctx, workerID, priorityQueueNames(priorities))
+}
+
+// priorityQueueNames returns the names of the queues of the given priorities,
+// or of all priorities from the highest down if none are given
+func priorityQueueNames(priorities []int) []string {
+	var queueNames []string
+	for _, priority := range consumeOrder(priorities) {
+		queueNames = append(queueNames, getQueueName(priority))
+	}
+	return queueNames
+}
+
+// ConsumePriority retrieves a task from a single priority queue only.
+// It returns redis.Nil if that queue is empty.
+func (q *RedisQueue) ConsumePriority(priority int) (task *Task, err error) {
+	ctx, span := startSpan(ctx, "RedisQueue.ConsumePriority", attribute.String(queueNameAttribute, getQueueName(priority)))
+	defer func() { endConsumeSpan(ctx, span, task, err) }()
+
+	return q.consumeFirst(ctx, "", []string{getQueueName(priority)})
+}
 
+// consumeFirst pops tasks from the first non-empty of the given queues until
+// one can be marked running. It returns redis.Nil if all queues are empty.
+func (q *RedisQueue) consumeFirst(ctx context.Context, workerID string, queueNames []string) (*Task, error) {
+	return q.consumeWith(ctx, workerID, func() (string, string, error) {
+		return q.popFirst(ctx, queueNames)
+	})
+}
+
+// consumeWith pops tasks with pop until one can be marked running by the given
+// worker, returning the error of pop, e.g. redis.Nil once there is nothing
+// left to pop. The worker ID is empty for consumers that aren't workers.
+func (q *RedisQueue) consumeWith(ctx context.Context, workerID string, pop func() (string, string, error)) (*Task, error) {
+	for {
+		queueName, taskJSON, err := pop()
 		if err != nil {
 			return nil, err
 		}
 
+		// Set corrupt entries aside and move on to the next task
 		var task Task
-		if err := json.Unmarshal([]byte(taskJSON), &task); err != nil {
-			return nil, err
+		if err := q.decode([]byte(taskJSON), &task); err != nil {
+			q.quarantine(queueName, taskJSON, err)
+			continue
 		}
 
-		// Update status
+		// Update status, dropping tasks that were cancelled or already
+		// finished while waiting in the queue. The worker is set first so
+		// that the task record and audit log show who runs the task.
 		task.Status = "running"
-		if err := q.UpdateStatus(&task); err != nil {
+		task.WorkerID = workerID
+		err = q.updateStatus(ctx, &task)
+		if errors.Is(err, ErrInvalidTransition) {
+			q.logger.Info(fmt.Sprintf("Skipping task %s: %v", task.ID, err))
+			continue
+		}
+		if err != nil {
 			q.logger.Info(fmt.Sprintf("Failed to update status for task %s: %v", task.ID, err))
 		}
 
 		return &task, nil
 	}
-
-	// No tasks in any queue
-	return nil, redis.Nil
 }
 
 // MoveToDeadLetterQueue moves a failed task to the dead letter queue
@@ -162,44 +423,151 @@ func (q *RedisQueue) MoveToDeadLetterQueue(task *Task, err error) error {
 	task.Status = "failed"
 	task.LastError = err.Error()
 
-	taskJSON, jsonErr := json.Marshal(task)
+	taskJSON, jsonErr := q.encode(task)
 	if jsonErr != nil {
 		return jsonErr
 	}
 
-	return q.client.LPush(ctx, DeadLetterQueue, string(taskJSON)).Err()
+	// Score by failure time so that entries can expire after the retention period
+	if err := q.client.ZAdd(ctx, queueKey(DeadLetterTasksKey), &redis.Z{
+		Score:  float64(time.Now().Unix()),
+		Member: string(taskJSON),
+	}).Err(); err != nil {
+		return err
+	}
+
+	// Keep the stored task record in sync with its terminal status
+	if err := q.UpdateStatus(task); err != nil {
+		q.logger.Error(fmt.Sprintf("Failed to update status for dead-lettered task %s: %v", task.ID, err))
+	}
+
+	q.notifyDeadLetter(task)
+	return nil
 }
 
-// RetryTask schedules a task for retry with exponential backoff
+// OnDeadLetter registers a callback invoked whenever a task is moved to the
+// dead letter queue, e.g. to send a webhook or chat notification. Multiple
+// callbacks can be registered; each runs asynchronously in its own goroutine
+// with a copy of the task, so callbacks never block task processing.
+func (q *RedisQueue) OnDeadLetter(fn func(task *Task)) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.deadLetterFns = append(q.deadLetterFns, fn)
+}
+
+// notifyDeadLetter runs the registered dead letter callbacks asynchronously
+func (q *RedisQueue) notifyDeadLetter(task *Task) {
+	q.mu.RLock()
+	callbacks := q.deadLetterFns
+	q.mu.RUnlock()
+
+	for _, fn := range callbacks {
+		taskCopy := *task
+		go func(fn func(task *Task)) {
+			defer func() {
+				if r := recover(); r != nil {
+					q.logger.Error(fmt.Sprintf("Dead letter callback panicked for task %s: %v", taskCopy.ID, r))
+				}
+			}()
+			fn(&taskCopy)
+		}(fn)
+	}
+}
+
+// RetryTask schedules a task for retry with the backoff of the queue's retry policy
 func (q *RedisQueue) RetryTask(task *Task, err error) error {
 	task.Attempts++
 	task.Status = "retrying"
 	task.LastError = err.Error()
 
-	// Calculate backoff time: 2^attempts seconds, capped at 5 minutes
-	backoffSeconds := 1 << uint(task.Attempts)
-	if backoffSeconds > 300 {
-		backoffSeconds = 300
-	}
+	return q.PublishDelayed(task, q.RetryPolicy().DelaySeconds(task.Attempts))
+}
 
-	return q.PublishDelayed(task, backoffSeconds)
+// RetryBackoff returns the delay in seconds before retry number attempts
+// under DefaultRetryPolicy: 2^attempts seconds, capped at 5 minutes
+func RetryBackoff(attempts int) int {
+	return DefaultRetryPolicy.DelaySeconds(attempts)
 }
 
-// UpdateStatus updates a task's status in Redis
+// UpdateStatus updates a task's status in Redis. The write is conditional: it
+// fails with ErrInvalidTransition instead of moving a task backwards, so a
+// late or duplicate update cannot overwrite a final status.
 func (q *RedisQueue) UpdateStatus(task *Task) error {
-	taskJSON, err := json.Marshal(task)
+	return q.updateStatus(ctx, task)
+}
+
+// updateStatus is UpdateStatus as part of an enclosing operation's span
+func (q *RedisQueue) updateStatus(ctx context.Context, task *Task) (err error) {
+	ctx, span := startSpan(ctx, "RedisQueue.UpdateStatus",
+		attribute.String(taskIDAttribute, task.ID), attribute.String("boltq.status", task.Status))
+	defer func() { endSpan(ctx, span, err) }()
+
+	taskJSON, err := q.encode(task)
+	if err != nil {
+		return err
+	}
+
+	key := taskKey(task.ID)
+	ttl := q.taskTTL(task.Status)
+
+	var oldStatus string
+	update := func(tx *redis.Tx) error {
+		oldStatus = ""
+
+		current, err := tx.Get(ctx, key).Result()
+		if err != nil && err != redis.Nil {
+			return err
+		}
+		if err == nil {
+			var previous Task
+			if err := q.decode([]byte(current), &previous); err == nil {
+				oldStatus = previous.Status
+			}
+		}
+
+		if err := checkTransition(oldStatus, task.Status); err != nil {
+			return err
+		}
+
+		// Store status with the TTL of its status, failing if the task changed
+		// since it was read
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, key, string(taskJSON), ttl)
+			return nil
+		})
+		return err
+	}
+
+	for attempt := 0; attempt < maxStatusUpdateAttempts; attempt++ {
+		err = q.client.Watch(ctx, update, key)
+		if err != redis.TxFailedErr {
+			break
+		}
+	}
 	if err != nil {
 		return err
 	}
 
-	// Store status with TTL
-	key := fmt.Sprintf("task:%s", task.ID)
-	return q.client.Set(ctx, key, string(taskJSON), 24*time.Hour).Err()
+	q.adjustStatusCounts(ctx, oldStatus, task.Status)
+	q.recordTransition(task, oldStatus)
+	return nil
+}
+
+// recordTransition appends a status transition to the audit log if it is enabled
+func (q *RedisQueue) recordTransition(task *Task, oldStatus string) {
+	if q.audit == nil {
+		return
+	}
+
+	if err := q.audit.Record(task, oldStatus); err != nil {
+		q.logger.Error(fmt.Sprintf("Failed to record audit event for task %s: %v", task.ID, err))
+	}
 }
 
 // GetTaskStatus retrieves a task's current status
 func (q *RedisQueue) GetTaskStatus(taskID string) (*Task, error) {
-	key := fmt.Sprintf("task:%s", taskID)
+	key := taskKey(taskID)
 	taskJSON, err := q.client.Get(ctx, key).Result()
 
 	if err == redis.Nil {
@@ -211,21 +579,39 @@ func (q *RedisQueue) GetTaskStatus(taskID string) (*Task, error) {
 	}
 
 	var task Task
-	if err := json.Unmarshal([]byte(taskJSON), &task); err != nil {
+	if err := q.decode([]byte(taskJSON), &task); err != nil {
 		return nil, err
 	}
 
 	return &task, nil
 }
 
+// CancelTask marks a task as cancelled. A queued task is dropped when it is
+// consumed, and a running task is signalled to stop by the worker processing it.
+// Tasks that have already finished are left untouched, and unknown tasks fail
+// with the "task not found" error of GetTaskStatus.
+func (q *RedisQueue) CancelTask(taskID string) error {
+	task, err := q.GetTaskStatus(taskID)
+	if err != nil {
+		return err
+	}
+
+	if task.Status == "failed" || IsFinalStatus(task.Status) {
+		return nil
+	}
+
+	task.Status = "cancelled"
+	return q.UpdateStatus(task)
+}
+
 // GetQueueStats returns statistics about the queues
 func (q *RedisQueue) GetQueueStats() (map[string]interface{}, error) {
 	stats := make(map[string]interface{})
 
 	// Get counts for each priority queue
-	for priority := PriorityHigh; priority <= PriorityLow; priority++ {
+	for priority := MaxPriority; priority >= MinPriority; priority-- {
 		queueName := getQueueName(priority)
-		count, err := q.client.LLen(ctx, queueName).Result()
+		count, err := q.client.LLen(ctx, queueKey(queueName)).Result()
 		if err != nil {
 			return nil, err
 		}
@@ -233,19 +619,26 @@ func (q *RedisQueue) GetQueueStats() (map[string]interface{}, error) {
 	}
 
 	// Get count of delayed tasks
-	delayedCount, err := q.client.ZCard(ctx, DelayedTasksKey).Result()
+	delayedCount, err := q.client.ZCard(ctx, queueKey(DelayedTasksKey)).Result()
 	if err != nil {
 		return nil, err
 	}
 	stats[DelayedTasksKey] = delayedCount
 
 	// Get count of dead letter queue
-	deadLetterCount, err := q.client.LLen(ctx, DeadLetterQueue).Result()
+	deadLetterCount, err := q.client.ZCard(ctx, queueKey(DeadLetterTasksKey)).Result()
 	if err != nil {
 		return nil, err
 	}
 	stats[DeadLetterQueue] = deadLetterCount
 
+	// Get count of entries that could not be decoded
+	poisonCount, err := q.client.LLen(ctx, queueKey(PoisonTasksKey)).Result()
+	if err != nil {
+		return nil, err
+	}
+	stats[PoisonTasksKey] = poisonCount
+
 	return stats, nil
 }
 
@@ -254,24 +647,45 @@ func (q *RedisQueue) Close() error {
 	return q.client.Close()
 }
 
-// Helper function to get the queue name for a priority level
+// queueKey returns the hash-tagged Redis key of a queue
+func queueKey(name string) string {
+	return KeyHashTag + ":" + name
+}
+
+// taskKey returns the Redis key of a task record. The task ID is its hash
+// tag, so in Redis Cluster a record's slot depends on its ID alone.
+func taskKey(taskID string) string {
+	return "task:{" + taskID + "}"
+}
+
+// Helper function to get the queue name for a priority level.
+// Out-of-range priorities are clamped into the supported range.
 func getQueueName(priority int) string {
-	return fmt.Sprintf("%s:%d", TaskQueuePrefix, priority)
+	return fmt.Sprintf("%s:%d", TaskQueuePrefix, NormalizePriority(priority))
 }
 
 // Helper to publish a task to a specific queue
 func (q *RedisQueue) publishToQueue(task *Task, queueName string) error {
-	taskJSON, err := json.Marshal(task)
+	taskJSON, err := q.encode(task)
 	if err != nil {
 		return err
 	}
 
-	err = q.client.LPush(ctx, queueName, string(taskJSON)).Err()
+	if err := q.checkPayloadSize(task, taskJSON); err != nil {
+		return err
+	}
+
+	if q.maxQueueLength > 0 {
+		err = q.pushWithBackpressure(queueName, string(taskJSON))
+	} else {
+		err = q.client.LPush(ctx, queueKey(queueName), string(taskJSON)).Err()
+	}
 	if err != nil {
 		return err
 	}
 
-	q.logger.Info(fmt.Sprintf("Task %s added to queue %s", task.ID, queueName))
+	q.logger.Info(fmt.Sprintf("Task %s added to queue %s", task.ID, queueName),
+		map[string]interface{}{logSampleKey: "task_enqueued"})
 	return nil
 }
 
@@ -289,17 +703,12 @@ func NewRedisQueueFactory(logger Logger) QueueFactory {
 
 // CreateQueue creates a new Redis queue with the provided configuration
 func (f *RedisQueueFactory) CreateQueue(config map[string]string) (Queue, error) {
-	// Get Redis address from config with default fallback
-	redisAddr := "localhost:6379"
-	if addr, ok := config["addr"]; ok {
-		redisAddr = addr
+	// Create Redis client for the configured deployment mode
+	client, err := NewRedisClient(config)
+	if err != nil {
+		return nil, err
 	}
 
-	// Create Redis client
-	client := redis.NewClient(&redis.Options{
-		Addr: redisAddr,
-	})
-
 	// Ping Redis to ensure connection
 	ctx := context.Background()
 	if err := client.Ping(ctx).Err(); err != nil {
@@ -307,7 +716,7 @@ func (f *RedisQueueFactory) CreateQueue(config map[string]string) (Queue, error)
 		return nil, err
 	}
 
-	f.logger.Info("Connected to Redis at " + redisAddr)
+	f.logger.Info("Connected to Redis at " + RedisAddrDescription(config))
 
 	// Create Redis queue
 	redisQueue := NewRedisQueue(client, f.logger)
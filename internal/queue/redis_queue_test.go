@@ -0,0 +1,31 @@
+package queue
+
+import "testing"
+
+func TestCancelTask(t *testing.T) {
+	q, server := newTestQueue(t)
+
+	task := &Task{ID: "task-1", Type: "test", Priority: PriorityNormal}
+	if err := q.Publish(task); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+
+	if err := q.CancelTask(task.ID); err != nil {
+		t.Fatalf("CancelTask(%s): %v", task.ID, err)
+	}
+	cancelled, err := q.GetTaskStatus(task.ID)
+	if err != nil {
+		t.Fatalf("GetTaskStatus: %v", err)
+	}
+	if cancelled.Status != "cancelled" {
+		t.Errorf("status = %s, want cancelled", cancelled.Status)
+	}
+
+	err = q.CancelTask("unknown")
+	if err == nil || err.Error() != "task not found" {
+		t.Fatalf("CancelTask(unknown) = %v, want task not found", err)
+	}
+	if server.Exists(taskKey("unknown")) {
+		t.Error("CancelTask created a record for an unknown task")
+	}
+}
@@ -0,0 +1,145 @@
+// internal/queue/reprioritize.go
+package queue
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// ErrTaskRunning is returned by Reprioritize for a task a worker is processing
+var ErrTaskRunning = errors.New("task is already running")
+
+// ErrTaskNotQueued is returned by Reprioritize for a task that is neither
+// waiting in a priority queue nor in the delayed set
+var ErrTaskNotQueued = errors.New("task is not queued")
+
+// requeueTaskScript moves a serialized task from one queue to the back of
+// another, replacing it with its updated form. It returns 0 if the task was
+// already consumed or moved by someone else.
+var requeueTaskScript = redis.NewScript(`
+if redis.call("LREM", KEYS[1], 1, ARGV[1]) == 0 then
+	return 0
+end
+redis.call("LPUSH", KEYS[2], ARGV[2])
+return 1
+`)
+
+// replaceDelayedTaskScript replaces a serialized task in the delayed set with
+// its updated form, keeping its score. It returns 0 if the task was already
+// released or replaced by someone else.
+var replaceDelayedTaskScript = redis.NewScript(`
+local score = redis.call("ZSCORE", KEYS[1], ARGV[1])
+if not score then
+	return 0
+end
+redis.call("ZREM", KEYS[1], ARGV[1])
+redis.call("ZADD", KEYS[1], score, ARGV[2])
+return 1
+`)
+
+// Reprioritize changes the priority of a task that has not started yet. A
+// pending task is moved to the back of the target priority queue; a delayed
+// task keeps its place in the delayed set and is released to the new queue.
+// It returns ErrTaskRunning for a running task and ErrTaskNotQueued for a task
+// that has finished or is not waiting anywhere.
+func (q *RedisQueue) Reprioritize(taskID string, newPriority int) error {
+	task, err := q.GetTaskStatus(taskID)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case task.Status == string(StatusRunning):
+		return ErrTaskRunning
+	case task.Status == string(StatusFailed) || IsFinalStatus(task.Status):
+		return ErrTaskNotQueued
+	}
+
+	newPriority = NormalizePriority(newPriority)
+
+	moved, err := q.reprioritizeQueued(task, newPriority)
+	if err != nil {
+		return err
+	}
+	if !moved {
+		moved, err = q.reprioritizeDelayed(task, newPriority)
+		if err != nil {
+			return err
+		}
+	}
+	if !moved {
+		return ErrTaskNotQueued
+	}
+
+	// Keep the stored task in line with the queue it now waits in
+	task.Priority = newPriority
+	if err := q.UpdateStatus(task); err != nil {
+		return fmt.Errorf("task moved but its status could not be updated: %w", err)
+	}
+
+	q.logger.Info("Reprioritized task", map[string]interface{}{
+		"task_id":  task.ID,
+		"priority": newPriority,
+	})
+	return nil
+}
+
+// reprioritizeQueued looks for a task in the priority queues it may be routed
+// to and moves it to the queue for newPriority. Tasks promoted by aging are not
+// in the queue of their stored priority, so every priority is searched.
+func (q *RedisQueue) reprioritizeQueued(task *Task, newPriority int) (bool, error) {
+	for priority := MaxPriority; priority >= MinPriority; priority-- {
+		routed := *task
+		routed.Priority = priority
+		sourceQueue := queueKey(taskQueueName(&routed))
+
+		taskJSONs, err := q.client.LRange(ctx, sourceQueue, 0, -1).Result()
+		if err != nil {
+			return false, err
+		}
+
+		for _, taskJSON := range taskJSONs {
+			var queued Task
+			if err := q.decode([]byte(taskJSON), &queued); err != nil || queued.ID != task.ID {
+				continue
+			}
+
+			queued.Priority = newPriority
+			updatedJSON, err := q.encode(&queued)
+			if err != nil {
+				return false, err
+			}
+
+			targetQueue := queueKey(taskQueueName(&queued))
+			moved, err := requeueTaskScript.Run(ctx, q.client, []string{sourceQueue, targetQueue}, taskJSON, string(updatedJSON)).Int()
+			if err != nil {
+				return false, err
+			}
+			return moved == 1, nil
+		}
+	}
+
+	return false, nil
+}
+
+// reprioritizeDelayed looks for a task in the delayed set and updates its priority
+func (q *RedisQueue) reprioritizeDelayed(task *Task, newPriority int) (bool, error) {
+	taskJSON, delayed, err := q.findDelayed(task.ID)
+	if err != nil || delayed == nil {
+		return false, err
+	}
+
+	delayed.Priority = newPriority
+	updatedJSON, err := q.encode(delayed)
+	if err != nil {
+		return false, err
+	}
+
+	replaced, err := replaceDelayedTaskScript.Run(ctx, q.client, []string{queueKey(DelayedTasksKey)}, taskJSON, string(updatedJSON)).Int()
+	if err != nil {
+		return false, err
+	}
+	return replaced == 1, nil
+}
@@ -0,0 +1,73 @@
+// internal/queue/retry_now.go
+package queue
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrTaskNotRetrying is returned by RetryNow for a task that is not waiting in
+// the delayed set for a retry
+var ErrTaskNotRetrying = errors.New("task is not waiting for a retry")
+
+// RetryNow moves a task waiting for a retry from the delayed set to its
+// priority queue, skipping the rest of its backoff. Tasks that were delayed
+// on submission rather than after a failed attempt are left alone and
+// ErrTaskNotRetrying is returned, as it is for tasks not in the delayed set.
+func (q *RedisQueue) RetryNow(taskID string) (*Task, error) {
+	taskJSON, task, err := q.findDelayed(taskID)
+	if err != nil {
+		return nil, err
+	}
+	if task == nil || task.Attempts == 0 {
+		return nil, ErrTaskNotRetrying
+	}
+
+	// Remove from the delayed set first; the delayed processor may have released it
+	removed, err := q.client.ZRem(ctx, queueKey(DelayedTasksKey), taskJSON).Result()
+	if err != nil {
+		return nil, err
+	}
+	if removed == 0 {
+		return nil, ErrTaskNotRetrying
+	}
+
+	// Store the new status before the task can be consumed, so that a worker
+	// picking it up right away is not overwritten
+	task.Status = string(StatusPending)
+	task.ScheduledAt = time.Now()
+	if err := q.UpdateStatus(task); err != nil {
+		q.logger.Info(fmt.Sprintf("Failed to update status for task %s: %v", task.ID, err))
+	}
+
+	if err := q.publishToQueue(task, taskQueueName(task)); err != nil {
+		q.markPublishFailed(ctx, task, err)
+		return nil, err
+	}
+
+	q.logger.Info("Retrying task now", map[string]interface{}{
+		"task_id":  task.ID,
+		"attempts": task.Attempts,
+	})
+	return task, nil
+}
+
+// findDelayed looks for a task in the delayed set and returns its serialized
+// form along with it. The task is nil if it is not in the set.
+func (q *RedisQueue) findDelayed(taskID string) (string, *Task, error) {
+	taskJSONs, err := q.client.ZRange(ctx, queueKey(DelayedTasksKey), 0, -1).Result()
+	if err != nil {
+		return "", nil, err
+	}
+
+	for _, taskJSON := range taskJSONs {
+		var delayed Task
+		if err := q.decode([]byte(taskJSON), &delayed); err != nil || delayed.ID != taskID {
+			continue
+		}
+		return taskJSON, &delayed, nil
+	}
+
+	return "", nil, nil
+}
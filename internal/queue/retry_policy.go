@@ -0,0 +1,93 @@
+// internal/queue/retry_policy.go
+package queue
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// RetryPolicy is the exponential backoff applied between retries of a task:
+// the first retry waits BaseDelay and each further one Multiplier times longer,
+// never less than MinDelay nor more than MaxDelay. Delays are rounded up to
+// whole seconds, the resolution of the delayed set.
+type RetryPolicy struct {
+	BaseDelay  time.Duration
+	Multiplier float64
+	MinDelay   time.Duration
+	MaxDelay   time.Duration
+}
+
+// DefaultRetryPolicy waits 2^attempts seconds, capped at 5 minutes
+var DefaultRetryPolicy = RetryPolicy{
+	BaseDelay:  2 * time.Second,
+	Multiplier: 2,
+	MaxDelay:   5 * time.Minute,
+}
+
+// Validate checks that the policy produces a usable delay sequence
+func (p RetryPolicy) Validate() error {
+	if p.BaseDelay <= 0 {
+		return fmt.Errorf("retry base delay must be positive: %s", p.BaseDelay)
+	}
+	if p.Multiplier < 1 {
+		return fmt.Errorf("retry multiplier must be at least 1: %g", p.Multiplier)
+	}
+	if p.MinDelay < 0 {
+		return fmt.Errorf("retry min delay must not be negative: %s", p.MinDelay)
+	}
+	if p.MaxDelay > 0 && p.MaxDelay < p.MinDelay {
+		return fmt.Errorf("retry max delay %s is below the min delay %s", p.MaxDelay, p.MinDelay)
+	}
+	return nil
+}
+
+// Delay returns the delay before retry number attempts, counting from 1. A
+// MaxDelay of 0 leaves the delay uncapped.
+func (p RetryPolicy) Delay(attempts int) time.Duration {
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	// Compute in floating point so large attempt counts saturate instead of overflowing
+	delay := float64(p.BaseDelay) * math.Pow(p.Multiplier, float64(attempts-1))
+	if p.MaxDelay > 0 && delay > float64(p.MaxDelay) {
+		delay = float64(p.MaxDelay)
+	}
+
+	d := time.Duration(math.MaxInt64)
+	if delay < float64(math.MaxInt64) {
+		d = time.Duration(delay)
+	}
+	if d < p.MinDelay {
+		d = p.MinDelay
+	}
+	return d
+}
+
+// DelaySeconds returns Delay rounded up to whole seconds
+func (p RetryPolicy) DelaySeconds(attempts int) int {
+	d := p.Delay(attempts)
+	seconds := int(d / time.Second)
+	if d%time.Second != 0 {
+		seconds++
+	}
+	return seconds
+}
+
+// SetRetryPolicy sets the backoff that RetryTask waits before requeueing a
+// task. It defaults to DefaultRetryPolicy.
+func (q *RedisQueue) SetRetryPolicy(policy RetryPolicy) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.retryPolicy = policy
+}
+
+// RetryPolicy returns the backoff that RetryTask waits before requeueing a task
+func (q *RedisQueue) RetryPolicy() RetryPolicy {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	return q.retryPolicy
+}
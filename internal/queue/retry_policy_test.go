@@ -0,0 +1,40 @@
+package queue
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicyDelaySeconds(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   RetryPolicy
+		attempts int
+		want     int
+	}{
+		{"default first retry", DefaultRetryPolicy, 1, 2},
+		{"default second retry", DefaultRetryPolicy, 2, 4},
+		{"default fifth retry", DefaultRetryPolicy, 5, 32},
+		{"default eighth retry", DefaultRetryPolicy, 8, 256},
+		{"default capped", DefaultRetryPolicy, 9, 300},
+		{"default capped far out", DefaultRetryPolicy, 1000, 300},
+		{"attempts below 1 count as 1", DefaultRetryPolicy, 0, 2},
+		{"constant backoff", RetryPolicy{BaseDelay: 10 * time.Second, Multiplier: 1}, 7, 10},
+		{"rounded up", RetryPolicy{BaseDelay: 1500 * time.Millisecond, Multiplier: 2}, 2, 3},
+		{"sub-second rounded up", RetryPolicy{BaseDelay: 100 * time.Millisecond, Multiplier: 2}, 1, 1},
+		{"min delay", RetryPolicy{BaseDelay: time.Second, Multiplier: 2, MinDelay: 5 * time.Second}, 2, 5},
+		{"min delay exceeded", RetryPolicy{BaseDelay: time.Second, Multiplier: 2, MinDelay: 5 * time.Second}, 4, 8},
+		{"cap below next step", RetryPolicy{BaseDelay: time.Second, Multiplier: 3, MaxDelay: 10 * time.Second}, 3, 9},
+		{"cap reached", RetryPolicy{BaseDelay: time.Second, Multiplier: 3, MaxDelay: 10 * time.Second}, 4, 10},
+		{"uncapped saturates", RetryPolicy{BaseDelay: time.Second, Multiplier: 2}, 1000, int(math.MaxInt64/int64(time.Second)) + 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.DelaySeconds(tt.attempts); got != tt.want {
+				t.Errorf("DelaySeconds(%d) = %d, want %d", tt.attempts, got, tt.want)
+			}
+		})
+	}
+}
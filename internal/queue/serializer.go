@@ -0,0 +1,122 @@
+// internal/queue/serializer.go
+package queue
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+const (
+	// FormatJSON identifies JSON blobs. It is the first byte of every JSON
+	// object, so JSON blobs are stored without an extra prefix and entries
+	// written before serializers were configurable still decode.
+	FormatJSON byte = '{'
+
+	// FormatMsgpack identifies msgpack blobs
+	FormatMsgpack byte = 0x01
+)
+
+// Serializer encodes and decodes tasks stored in Redis
+type Serializer interface {
+	// Format returns the byte that prefixes blobs written by this serializer
+	Format() byte
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// JSONSerializer stores tasks as JSON. It is the default.
+type JSONSerializer struct{}
+
+// Format returns FormatJSON
+func (JSONSerializer) Format() byte { return FormatJSON }
+
+// Marshal encodes v as JSON
+func (JSONSerializer) Marshal(v interface{}) ([]byte, error) { return json.Marshal(v) }
+
+// Unmarshal decodes JSON into v
+func (JSONSerializer) Unmarshal(data []byte, v interface{}) error { return json.Unmarshal(data, v) }
+
+// MsgpackSerializer stores tasks as msgpack, which is smaller and faster to
+// encode than JSON. Field names follow the json struct tags.
+type MsgpackSerializer struct{}
+
+// Format returns FormatMsgpack
+func (MsgpackSerializer) Format() byte { return FormatMsgpack }
+
+// Marshal encodes v as msgpack
+func (MsgpackSerializer) Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := msgpack.NewEncoder(&buf)
+	enc.SetCustomStructTag("json")
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Unmarshal decodes msgpack into v. Integers inside untyped values such as
+// task data decode as int64 and floats as float64.
+func (MsgpackSerializer) Unmarshal(data []byte, v interface{}) error {
+	dec := msgpack.NewDecoder(bytes.NewReader(data))
+	dec.SetCustomStructTag("json")
+	dec.UseLooseInterfaceDecoding(true)
+	return dec.Decode(v)
+}
+
+// serializers lists the formats that can be decoded, whatever the configured serializer
+var serializers = map[byte]Serializer{
+	FormatJSON:    JSONSerializer{},
+	FormatMsgpack: MsgpackSerializer{},
+}
+
+// SerializerByName returns the serializer called name ("json" or "msgpack")
+func SerializerByName(name string) (Serializer, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", "json":
+		return JSONSerializer{}, nil
+	case "msgpack":
+		return MsgpackSerializer{}, nil
+	default:
+		return nil, fmt.Errorf("unknown serializer: %s", name)
+	}
+}
+
+// SetSerializer sets the serializer used for tasks written from now on. Tasks
+// already stored in another format are still read.
+func (q *RedisQueue) SetSerializer(serializer Serializer) {
+	q.serializer = serializer
+}
+
+// encode serializes v with the configured serializer, prefixed with its format byte
+func (q *RedisQueue) encode(v interface{}) ([]byte, error) {
+	data, err := q.serializer.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	if q.serializer.Format() == FormatJSON {
+		return data, nil
+	}
+	return append([]byte{q.serializer.Format()}, data...), nil
+}
+
+// decode deserializes a blob written by encode, whichever serializer wrote it
+func (q *RedisQueue) decode(data []byte, v interface{}) error {
+	if len(data) == 0 {
+		return fmt.Errorf("empty task blob")
+	}
+
+	serializer, ok := serializers[data[0]]
+	if !ok {
+		return fmt.Errorf("unknown task format 0x%02x", data[0])
+	}
+
+	if data[0] == FormatJSON {
+		return serializer.Unmarshal(data, v)
+	}
+	return serializer.Unmarshal(data[1:], v)
+}
@@ -0,0 +1,55 @@
+// internal/queue/stats.go
+package queue
+
+// QueueSummary is a client-facing view of the queue statistics that doesn't
+// depend on the names of the underlying Redis keys
+type QueueSummary struct {
+	TotalPending int64                  `json:"total_pending"`
+	ByPriority   map[string]int64       `json:"by_priority"`
+	Delayed      int64                  `json:"delayed"`
+	DeadLetter   int64                  `json:"dead_letter"`
+	Total        int64                  `json:"total"`
+	Queues       map[string]interface{} `json:"queues"`
+
+	// SuppressedDuplicates counts submissions that were not published
+	// because a task with the same ID already existed
+	SuppressedDuplicates int64 `json:"suppressed_duplicates"`
+}
+
+// GetQueueSummary returns the queue statistics with pending tasks counted by
+// priority name. Total counts pending, delayed and dead-lettered tasks. The
+// statistics returned by GetQueueStats are kept under Queues. Suppressed
+// duplicate submissions are counted separately.
+func (q *RedisQueue) GetQueueSummary() (*QueueSummary, error) {
+	stats, err := q.GetQueueStats()
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &QueueSummary{
+		ByPriority: make(map[string]int64),
+		Delayed:    statCount(stats, DelayedTasksKey),
+		DeadLetter: statCount(stats, DeadLetterQueue),
+		Queues:     stats,
+	}
+
+	for priority := MaxPriority; priority >= MinPriority; priority-- {
+		count := statCount(stats, getQueueName(priority))
+		summary.ByPriority[PriorityName(priority)] = count
+		summary.TotalPending += count
+	}
+
+	summary.Total = summary.TotalPending + summary.Delayed + summary.DeadLetter
+
+	summary.SuppressedDuplicates, err = q.DuplicateSubmissions()
+	if err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
+
+// statCount returns a count from the queue statistics, or 0 if it is missing
+func statCount(stats map[string]interface{}, key string) int64 {
+	count, _ := stats[key].(int64)
+	return count
+}
@@ -0,0 +1,75 @@
+// internal/queue/status.go
+package queue
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+const (
+	// StatusScheduled is the status of a task waiting in the delayed set
+	StatusScheduled JobStatus = "scheduled"
+
+	// StatusExpired is the status of a task whose deadline passed before it ran
+	StatusExpired JobStatus = "expired"
+)
+
+// ErrInvalidTransition is returned by UpdateStatus when a status change would
+// move a task backwards, e.g. from completed back to running
+var ErrInvalidTransition = errors.New("invalid status transition")
+
+// ErrTaskExists is returned by PublishUnique when a task with the same ID exists
+var ErrTaskExists = errors.New("task already exists")
+
+// taskIDPattern is the format accepted for caller-supplied task IDs
+var taskIDPattern = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// IsValidTaskID reports whether a caller-supplied task ID has an accepted format:
+// 1 to 128 letters, digits, '.', '_', ':' or '-'
+func IsValidTaskID(id string) bool {
+	return taskIDPattern.MatchString(id)
+}
+
+// allowedTransitions lists the statuses each status may move to. Completed,
+// cancelled and expired tasks are final; failed tasks may only be requeued.
+var allowedTransitions = map[JobStatus][]JobStatus{
+	StatusPending:   {StatusPending, StatusScheduled, StatusRunning, StatusFailed, StatusCancelled, StatusExpired},
+	StatusScheduled: {StatusScheduled, StatusPending, StatusRunning, StatusFailed, StatusCancelled, StatusExpired},
+	StatusRetrying: {StatusRetrying, StatusScheduled, StatusPending, StatusRunning, StatusFailed,
+		StatusCancelled, StatusExpired},
+	StatusRunning: {StatusRunning, StatusScheduled, StatusPending, StatusRetrying,
+		StatusCompleted, StatusFailed, StatusCancelled, StatusExpired},
+	StatusFailed:    {StatusPending, StatusScheduled, StatusRetrying},
+	StatusCompleted: {},
+	StatusCancelled: {},
+	StatusExpired:   {},
+}
+
+// IsFinalStatus reports whether a task status can no longer change
+func IsFinalStatus(status string) bool {
+	transitions, known := allowedTransitions[JobStatus(status)]
+	return known && len(transitions) == 0
+}
+
+// checkTransition returns ErrInvalidTransition if a task may not move from one
+// status to another. Tasks without a stored status and unknown statuses may
+// move anywhere.
+func checkTransition(from, to string) error {
+	if from == "" {
+		return nil
+	}
+
+	transitions, known := allowedTransitions[JobStatus(from)]
+	if !known {
+		return nil
+	}
+
+	for _, allowed := range transitions {
+		if string(allowed) == to {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, from, to)
+}
@@ -0,0 +1,164 @@
+// internal/queue/status_counts.go
+package queue
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	// StatusCountsKey is the hash counting task records by status
+	StatusCountsKey = "status_counts"
+
+	// StatusCountsReconcileKey is held by the instance reconciling the status
+	// counts, so only one instance scans the task records per interval
+	StatusCountsReconcileKey = "status_counts:reconcile"
+
+	// statusCountScanBatch is how many task records reconciliation reads at a time
+	statusCountScanBatch = 500
+)
+
+// taskScanner is the part of a Redis client, or of one cluster node, that
+// reconciliation uses to read the task records
+type taskScanner interface {
+	Scan(ctx context.Context, cursor uint64, match string, count int64) *redis.ScanCmd
+	Pipeline() redis.Pipeliner
+}
+
+// adjustStatusCounts moves a task from one status count to another. Either
+// status is empty for a task record that was created or removed.
+func (q *RedisQueue) adjustStatusCounts(ctx context.Context, from, to string) {
+	if from == to {
+		return
+	}
+
+	key := queueKey(StatusCountsKey)
+	_, err := q.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		if from != "" {
+			pipe.HIncrBy(ctx, key, from, -1)
+		}
+		if to != "" {
+			pipe.HIncrBy(ctx, key, to, 1)
+		}
+		return nil
+	})
+	if err != nil {
+		q.logger.Error(fmt.Sprintf("Failed to update status counts from %q to %q: %v", from, to, err))
+	}
+}
+
+// GetStatusCounts returns the number of task records in each status. The
+// counts are kept up to date by every status update, without scanning the
+// task records, and may drift until ReconcileStatusCounts corrects them, e.g.
+// as records expire.
+func (q *RedisQueue) GetStatusCounts() (map[string]int64, error) {
+	values, err := q.client.HGetAll(ctx, queueKey(StatusCountsKey)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int64, len(values))
+	for status, value := range values {
+		count, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || count <= 0 {
+			continue
+		}
+		counts[status] = count
+	}
+	return counts, nil
+}
+
+// ClaimStatusCountsReconcile reports whether the caller may reconcile the
+// status counts now. Once claimed, other instances are refused for interval.
+func (q *RedisQueue) ClaimStatusCountsReconcile(interval time.Duration) (bool, error) {
+	return q.client.SetNX(ctx, queueKey(StatusCountsReconcileKey), time.Now().Unix(), interval).Result()
+}
+
+// ReconcileStatusCounts recounts the task records by status and replaces the
+// status counts with the result. It scans every task record, so it is meant
+// to run now and then rather than on each read. Status updates made while it
+// scans may be off by one until the next reconciliation.
+func (q *RedisQueue) ReconcileStatusCounts() (map[string]int64, error) {
+	counts := make(map[string]int64)
+	var mu sync.Mutex
+
+	err := q.scanTaskRecords(func(ctx context.Context, client taskScanner, keys []string) error {
+		pipe := client.Pipeline()
+		cmds := make([]*redis.StringCmd, len(keys))
+		for i, key := range keys {
+			cmds[i] = pipe.Get(ctx, key)
+		}
+		if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+			return err
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		for _, cmd := range cmds {
+			// Records may expire between the scan and the read
+			taskJSON, err := cmd.Result()
+			if err != nil {
+				continue
+			}
+
+			var task Task
+			if err := q.decode([]byte(taskJSON), &task); err != nil || task.Status == "" {
+				continue
+			}
+			counts[task.Status]++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	key := queueKey(StatusCountsKey)
+	_, err = q.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, key)
+		for status, count := range counts {
+			pipe.HSet(ctx, key, status, count)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return counts, nil
+}
+
+// scanTaskRecords calls fn with batches of task record keys. In a cluster,
+// the records of each master are scanned on that master, concurrently.
+func (q *RedisQueue) scanTaskRecords(fn func(ctx context.Context, client taskScanner, keys []string) error) error {
+	scan := func(ctx context.Context, client taskScanner) error {
+		var cursor uint64
+		for {
+			keys, next, err := client.Scan(ctx, cursor, "task:*", statusCountScanBatch).Result()
+			if err != nil {
+				return err
+			}
+			if len(keys) > 0 {
+				if err := fn(ctx, client, keys); err != nil {
+					return err
+				}
+			}
+			if next == 0 {
+				return nil
+			}
+			cursor = next
+		}
+	}
+
+	if cluster, ok := q.client.(*redis.ClusterClient); ok {
+		return cluster.ForEachMaster(ctx, func(ctx context.Context, node *redis.Client) error {
+			return scan(ctx, node)
+		})
+	}
+	return scan(ctx, q.client)
+}
@@ -0,0 +1,287 @@
+// internal/queue/submit_buffer.go
+package queue
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// ErrBufferFull is returned by SubmitBuffer.Add when the buffer holds its maximum number of tasks
+var ErrBufferFull = errors.New("submit buffer is full")
+
+// bufferedSubmission is a task waiting in the submit buffer
+type bufferedSubmission struct {
+	Task         *Task `json:"task"`
+	DelaySeconds int   `json:"delay_seconds,omitempty"`
+	Unique       bool  `json:"unique,omitempty"`
+}
+
+// SubmitBuffer persists submissions to local disk while Redis is unreachable
+// and publishes them in submission order once it recovers. Each submission is
+// stored as its own file named by a sequence number, written atomically via
+// rename, so the buffer survives restarts of the process.
+//
+// Buffering weakens durability: tasks only live on this host's disk until they
+// are flushed, so the buffer is opt-in.
+type SubmitBuffer struct {
+	dir        string
+	maxEntries int
+	queue      *RedisQueue
+	logger     Logger
+	nextSeq    uint64
+	stopChan   chan struct{}
+	wg         sync.WaitGroup
+	mu         sync.Mutex
+}
+
+// NewSubmitBuffer creates a submit buffer in dir holding at most maxEntries
+// tasks. Submissions left over from a previous run are kept and flushed first.
+func NewSubmitBuffer(dir string, maxEntries int, queue *RedisQueue, logger Logger) (*SubmitBuffer, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("error creating submit buffer directory: %v", err)
+	}
+
+	b := &SubmitBuffer{
+		dir:        dir,
+		maxEntries: maxEntries,
+		queue:      queue,
+		logger:     logger,
+		stopChan:   make(chan struct{}),
+	}
+
+	files, err := b.entries()
+	if err != nil {
+		return nil, err
+	}
+	if len(files) > 0 {
+		last, _ := strconv.ParseUint(strings.TrimSuffix(files[len(files)-1], ".json"), 10, 64)
+		b.nextSeq = last + 1
+		logger.Info(fmt.Sprintf("Submit buffer contains %d tasks from a previous run", len(files)))
+	}
+
+	return b, nil
+}
+
+// Add persists a submission that could not be published
+func (b *SubmitBuffer) Add(task *Task, delaySeconds int, unique bool) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	files, err := b.entries()
+	if err != nil {
+		return err
+	}
+	if len(files) >= b.maxEntries {
+		return ErrBufferFull
+	}
+
+	data, err := json.Marshal(bufferedSubmission{Task: task, DelaySeconds: delaySeconds, Unique: unique})
+	if err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%020d.json", b.nextSeq)
+	tmpPath := filepath.Join(b.dir, name+".tmp")
+	if err := writeFileSync(tmpPath, data); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("error writing buffered task: %v", err)
+	}
+	if err := os.Rename(tmpPath, filepath.Join(b.dir, name)); err != nil {
+		return fmt.Errorf("error writing buffered task: %v", err)
+	}
+	// Make the rename itself survive a crash
+	if err := syncDir(b.dir); err != nil {
+		return fmt.Errorf("error writing buffered task: %v", err)
+	}
+
+	b.nextSeq++
+	return nil
+}
+
+// writeFileSync writes data to a new file and flushes it to disk
+func writeFileSync(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// syncDir flushes a directory's entries to disk
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+
+	return d.Sync()
+}
+
+// Len returns the number of buffered submissions
+func (b *SubmitBuffer) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	files, err := b.entries()
+	if err != nil {
+		return 0
+	}
+	return len(files)
+}
+
+// Start begins flushing the buffer at regular intervals
+func (b *SubmitBuffer) Start(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	b.wg.Add(1)
+
+	go func() {
+		defer b.wg.Done()
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				b.Flush()
+			case <-b.stopChan:
+				return
+			}
+		}
+	}()
+
+	b.logger.Info("Submit buffer flusher started")
+}
+
+// Stop stops the flusher. Buffered submissions stay on disk for the next run.
+func (b *SubmitBuffer) Stop() {
+	close(b.stopChan)
+	b.wg.Wait()
+	b.logger.Info("Submit buffer flusher stopped")
+}
+
+// Flush publishes buffered submissions in order. It stops at the first
+// submission that can't be published because Redis is still unavailable or
+// the queue is full, so ordering is preserved. Submissions that can never be
+// published are dropped with an error log. It returns the number published.
+func (b *SubmitBuffer) Flush() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	files, err := b.entries()
+	if err != nil {
+		b.logger.Error(fmt.Sprintf("Error reading submit buffer: %v", err))
+		return 0
+	}
+
+	published := 0
+	for _, name := range files {
+		path := filepath.Join(b.dir, name)
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			b.logger.Error(fmt.Sprintf("Error reading buffered task %s: %v", name, err))
+			return published
+		}
+
+		var submission bufferedSubmission
+		if err := json.Unmarshal(data, &submission); err != nil || submission.Task == nil {
+			b.logger.Error(fmt.Sprintf("Dropping unreadable buffered task %s", name))
+			os.Remove(path)
+			continue
+		}
+
+		err = b.publish(&submission)
+		if IsRedisUnavailable(err) || errors.Is(err, ErrQueueFull) {
+			return published
+		}
+
+		if err != nil && !errors.Is(err, ErrTaskExists) {
+			b.logger.Error(fmt.Sprintf("Dropping buffered task %s: %v", submission.Task.ID, err))
+		} else if err == nil {
+			published++
+		}
+
+		if err := os.Remove(path); err != nil {
+			b.logger.Error(fmt.Sprintf("Error removing buffered task %s: %v", name, err))
+			return published
+		}
+	}
+
+	if published > 0 {
+		b.logger.Info(fmt.Sprintf("Flushed %d buffered tasks", published))
+	}
+	return published
+}
+
+// publish publishes a buffered submission the same way the API would have
+func (b *SubmitBuffer) publish(submission *bufferedSubmission) error {
+	switch {
+	case submission.Unique:
+		_, err := b.queue.PublishUnique(submission.Task, submission.DelaySeconds)
+		return err
+	case submission.DelaySeconds > 0:
+		return b.queue.PublishDelayed(submission.Task, submission.DelaySeconds)
+	default:
+		return b.queue.Publish(submission.Task)
+	}
+}
+
+// entries returns the buffered submission file names in submission order
+func (b *SubmitBuffer) entries() ([]string, error) {
+	dirEntries, err := os.ReadDir(b.dir)
+	if err != nil {
+		return nil, fmt.Errorf("error reading submit buffer directory: %v", err)
+	}
+
+	files := make([]string, 0, len(dirEntries))
+	for _, entry := range dirEntries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".json") {
+			files = append(files, entry.Name())
+		}
+	}
+
+	// Zero-padded sequence numbers sort lexically in submission order
+	sort.Strings(files)
+	return files, nil
+}
+
+// IsRedisUnavailable reports whether an error means Redis could not be reached,
+// as opposed to a rejected command
+func IsRedisUnavailable(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	if errors.Is(err, io.EOF) || errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) {
+		return true
+	}
+
+	msg := err.Error()
+	return strings.Contains(msg, "connection refused") ||
+		strings.Contains(msg, "connection pool timeout") ||
+		strings.Contains(msg, "i/o timeout")
+}
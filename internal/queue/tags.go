@@ -0,0 +1,87 @@
+// internal/queue/tags.go
+package queue
+
+import (
+	"fmt"
+	"regexp"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+var tagPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// ValidTag reports whether a tag can be used to route tasks
+func ValidTag(tag string) bool {
+	return tagPattern.MatchString(tag)
+}
+
+// HasTags reports whether a task carries every one of the given tags
+func (t *Task) HasTags(tags []string) bool {
+	for _, required := range tags {
+		found := false
+		for _, tag := range t.Tags {
+			if tag == required {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// getTagQueueName returns the queue holding tasks routed by a tag at a priority
+func getTagQueueName(tag string, priority int) string {
+	return fmt.Sprintf("%s:%s:%d", TaskQueuePrefix, tag, NormalizePriority(priority))
+}
+
+// taskQueueName returns the queue a task is published to. Tagged tasks are
+// routed by their first tag so that only pools consuming that tag see them.
+func taskQueueName(task *Task) string {
+	if len(task.Tags) > 0 {
+		return getTagQueueName(task.Tags[0], task.Priority)
+	}
+	return getQueueName(task.Priority)
+}
+
+// ConsumeTags retrieves a task for the given worker routed by any of the given
+// tags, checking high priority first. Priorities outside the allowed list are
+// skipped when it is not empty. It returns redis.Nil if all matching queues
+// are empty.
+func (q *RedisQueue) ConsumeTags(workerID string, tags []string, priorities []int) (task *Task, err error) {
+	ctx, span := startSpan(ctx, "RedisQueue.ConsumeTags", attribute.StringSlice("boltq.tags", tags))
+	defer func() { endConsumeSpan(ctx, span, task, err) }()
+
+	return q.consumeFirst(ctx, workerID, ConsumeQueueNames(tags, priorities))
+}
+
+// ConsumeTag retrieves a task for the given worker routed by a single tag,
+// checking high priority first. Priorities outside the allowed list are
+// skipped when it is not empty. It returns redis.Nil if all of the tag's
+// queues are empty.
+func (q *RedisQueue) ConsumeTag(workerID string, tag string, priorities []int) (task *Task, err error) {
+	ctx, span := startSpan(ctx, "RedisQueue.ConsumeTag", attribute.StringSlice("boltq.tags", []string{tag}))
+	defer func() { endConsumeSpan(ctx, span, task, err) }()
+
+	var queueNames []string
+	for _, priority := range consumeOrder(priorities) {
+		queueNames = append(queueNames, getTagQueueName(tag, priority))
+	}
+
+	return q.consumeFirst(ctx, workerID, queueNames)
+}
+
+// consumeOrder returns the priorities to check, all of them from the highest
+// down if no list is given
+func consumeOrder(priorities []int) []int {
+	if len(priorities) > 0 {
+		return priorities
+	}
+
+	for priority := MaxPriority; priority >= MinPriority; priority-- {
+		priorities = append(priorities, priority)
+	}
+	return priorities
+}
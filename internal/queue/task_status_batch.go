@@ -0,0 +1,49 @@
+// internal/queue/task_status_batch.go
+package queue
+
+import (
+	"fmt"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// GetTaskStatuses returns the tasks with the given IDs in the same order, with
+// nil for each task that doesn't exist. A record that can't be decoded doesn't
+// fail the lookup of the others: its task is nil and its entry in the returned
+// errors holds the decode error. The records are read with one pipeline rather
+// than MGET, which a cluster refuses for keys in different slots.
+func (q *RedisQueue) GetTaskStatuses(taskIDs []string) ([]*Task, []error, error) {
+	tasks := make([]*Task, len(taskIDs))
+	decodeErrs := make([]error, len(taskIDs))
+	if len(taskIDs) == 0 {
+		return tasks, decodeErrs, nil
+	}
+
+	pipe := q.client.Pipeline()
+	cmds := make([]*redis.StringCmd, len(taskIDs))
+	for i, taskID := range taskIDs {
+		cmds[i] = pipe.Get(ctx, taskKey(taskID))
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, nil, err
+	}
+
+	for i, cmd := range cmds {
+		taskJSON, err := cmd.Result()
+		if err == redis.Nil {
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		var task Task
+		if err := q.decode([]byte(taskJSON), &task); err != nil {
+			decodeErrs[i] = fmt.Errorf("failed to decode task %s: %w", taskIDs[i], err)
+			continue
+		}
+		tasks[i] = &task
+	}
+
+	return tasks, decodeErrs, nil
+}
@@ -0,0 +1,27 @@
+package queue
+
+import "testing"
+
+func TestGetTaskStatuses(t *testing.T) {
+	q, server := newTestQueue(t)
+
+	if err := q.Publish(&Task{ID: "good", Type: "test", Priority: PriorityNormal}); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	server.Set(taskKey("corrupt"), "not a task")
+
+	tasks, decodeErrs, err := q.GetTaskStatuses([]string{"good", "missing", "corrupt"})
+	if err != nil {
+		t.Fatalf("GetTaskStatuses: %v", err)
+	}
+
+	if tasks[0] == nil || tasks[0].ID != "good" || decodeErrs[0] != nil {
+		t.Errorf("good = %v, %v, want the task", tasks[0], decodeErrs[0])
+	}
+	if tasks[1] != nil || decodeErrs[1] != nil {
+		t.Errorf("missing = %v, %v, want nil, nil", tasks[1], decodeErrs[1])
+	}
+	if tasks[2] != nil || decodeErrs[2] == nil {
+		t.Errorf("corrupt = %v, %v, want nil and a decode error", tasks[2], decodeErrs[2])
+	}
+}
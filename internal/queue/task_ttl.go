@@ -0,0 +1,36 @@
+// internal/queue/task_ttl.go
+package queue
+
+import "time"
+
+// DefaultTaskTTL is how long a task record is kept after its last status update
+const DefaultTaskTTL = 24 * time.Hour
+
+// SetTaskTTL sets how long task records are kept after their last status
+// update: active for tasks that may still change, terminal for completed,
+// failed, cancelled and expired tasks. A shorter terminal TTL frees the memory
+// of finished tasks sooner. A terminal TTL of 0 or less uses the active TTL.
+func (q *RedisQueue) SetTaskTTL(active, terminal time.Duration) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.activeTaskTTL = active
+	q.terminalTaskTTL = terminal
+}
+
+// taskTTL returns how long a task record with the given status is kept
+func (q *RedisQueue) taskTTL(status string) time.Duration {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	if q.terminalTaskTTL > 0 && isTerminalStatus(status) {
+		return q.terminalTaskTTL
+	}
+	return q.activeTaskTTL
+}
+
+// isTerminalStatus reports whether a task has finished. Failed tasks count as
+// finished although they may still be replayed or retried by hand.
+func isTerminalStatus(status string) bool {
+	return IsFinalStatus(status) || status == string(StatusFailed)
+}
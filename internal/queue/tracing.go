@@ -0,0 +1,51 @@
+// internal/queue/tracing.go
+package queue
+
+import (
+	"context"
+
+	"BoltQ/pkg/tracing"
+
+	"github.com/go-redis/redis/v8"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Span attributes identifying the queue and task of an operation
+const (
+	queueNameAttribute = "boltq.queue"
+	taskIDAttribute    = "boltq.task_id"
+)
+
+// startSpan starts a span for a Redis queue operation. Operations called
+// within it, such as the status update of a publish, become its children.
+func startSpan(parent context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	spanCtx, span := tracing.StartSpan(parent, name)
+	tracing.AddSpanAttributes(spanCtx, attrs...)
+	return spanCtx, span
+}
+
+// taskAttributes returns the span attributes of a task and the queue it goes to
+func taskAttributes(task *Task) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String(queueNameAttribute, taskQueueName(task)),
+		attribute.String(taskIDAttribute, task.ID),
+	}
+}
+
+// endSpan records the error of an operation, if any, and ends its span. An
+// empty queue is an expected outcome rather than an error.
+func endSpan(spanCtx context.Context, span trace.Span, err error) {
+	if err != nil && err != redis.Nil {
+		tracing.RecordError(spanCtx, err)
+	}
+	span.End()
+}
+
+// endConsumeSpan ends the span of a consume, tagged with the task it returned
+func endConsumeSpan(spanCtx context.Context, span trace.Span, task *Task, err error) {
+	if task != nil {
+		tracing.AddSpanAttributes(spanCtx, taskAttributes(task)...)
+	}
+	endSpan(spanCtx, span, err)
+}
@@ -0,0 +1,316 @@
+// internal/queue/zset_queue.go
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	// MaxWeightedPriority is the highest priority a ZSetQueue accepts. Its
+	// priorities range from MinPriority to MaxWeightedPriority.
+	MaxWeightedPriority = 100
+
+	// Sorted sets holding the ready and the delayed tasks of a ZSetQueue
+	ZSetTasksKey        = "task_zset"
+	ZSetDelayedTasksKey = "task_zset_delayed"
+
+	// zsetPriorityStride separates the scores of adjacent priorities by more
+	// than any enqueue time in milliseconds, so priority always comes first.
+	// The largest score stays below 2^53 and is exact as a float64.
+	zsetPriorityStride = 1e13
+
+	// zsetPromoteBatch bounds how many due delayed tasks one Consume promotes
+	zsetPromoteBatch = 100
+)
+
+// promoteZSetTaskScript moves a delayed task to the ready set unless another
+// consumer already did
+var promoteZSetTaskScript = redis.NewScript(`
+if redis.call("ZREM", KEYS[1], ARGV[1]) == 0 then
+	return 0
+end
+redis.call("ZADD", KEYS[2], ARGV[2], ARGV[1])
+return 1
+`)
+
+// ZSetQueue is an implementation of the Queue interface that keeps all ready
+// tasks in one Redis sorted set instead of a list per priority. Priorities
+// range from 0 to MaxWeightedPriority and each task is scored by its priority
+// and enqueue time, so ZPOPMIN hands out the highest priority task first and
+// the oldest among equal priorities. Task records, status transitions and the
+// dead letter queue are shared with the list-based RedisQueue.
+type ZSetQueue struct {
+	tasks  *RedisQueue
+	client RedisClient
+	logger Logger
+}
+
+// NewZSetQueue creates a sorted set queue on a Redis client
+func NewZSetQueue(client RedisClient, logger Logger) *ZSetQueue {
+	return &ZSetQueue{
+		tasks:  NewRedisQueue(client, logger),
+		client: client,
+		logger: logger,
+	}
+}
+
+// NormalizeWeightedPriority clamps a priority to the range of a ZSetQueue
+func NormalizeWeightedPriority(priority int) int {
+	if priority < MinPriority {
+		return MinPriority
+	}
+	if priority > MaxWeightedPriority {
+		return MaxWeightedPriority
+	}
+	return priority
+}
+
+// zsetScore orders tasks by descending priority, then by enqueue time
+func zsetScore(priority int, enqueuedAt time.Time) float64 {
+	return float64(MaxWeightedPriority-priority)*zsetPriorityStride + float64(enqueuedAt.UnixMilli())
+}
+
+// Publish adds a job to the queue with specified priority
+func (q *ZSetQueue) Publish(ctx context.Context, job *Job) error {
+	task := TaskFromJob(job)
+	task.CreatedAt = time.Now()
+	task.Status = "pending"
+	task.Priority = NormalizeWeightedPriority(task.Priority)
+
+	if err := q.tasks.storeBeforePublish(ctx, task); err != nil {
+		return err
+	}
+
+	if err := q.enqueue(ctx, task); err != nil {
+		q.tasks.markPublishFailed(ctx, task, err)
+		return err
+	}
+
+	return nil
+}
+
+// enqueue adds a task to the ready set, scored by its priority and the current time
+func (q *ZSetQueue) enqueue(ctx context.Context, task *Task) error {
+	taskJSON, err := q.tasks.encode(task)
+	if err != nil {
+		return err
+	}
+
+	return q.client.ZAdd(ctx, queueKey(ZSetTasksKey), &redis.Z{
+		Score:  zsetScore(task.Priority, time.Now()),
+		Member: string(taskJSON),
+	}).Err()
+}
+
+// PublishDelayed adds a job to be executed at a future time
+func (q *ZSetQueue) PublishDelayed(ctx context.Context, job *Job, delay time.Duration) error {
+	task := TaskFromJob(job)
+	task.CreatedAt = time.Now()
+	task.Status = "scheduled"
+	task.Priority = NormalizeWeightedPriority(task.Priority)
+
+	if err := q.tasks.storeBeforePublish(ctx, task); err != nil {
+		return err
+	}
+
+	if err := q.delay(ctx, task, delay); err != nil {
+		q.tasks.markPublishFailed(ctx, task, err)
+		return err
+	}
+
+	return nil
+}
+
+// delay adds a task to the delayed set, scored by the time it becomes ready
+func (q *ZSetQueue) delay(ctx context.Context, task *Task, delay time.Duration) error {
+	task.ScheduledAt = time.Now().Add(delay)
+
+	taskJSON, err := q.tasks.encode(task)
+	if err != nil {
+		return err
+	}
+
+	return q.client.ZAdd(ctx, queueKey(ZSetDelayedTasksKey), &redis.Z{
+		Score:  float64(task.ScheduledAt.UnixMilli()),
+		Member: string(taskJSON),
+	}).Err()
+}
+
+// promoteDelayed moves delayed tasks that are due to the ready set. A task
+// enters the ready set as of the time it was due, so it does not lose its
+// place to tasks of its priority published while it was waiting.
+func (q *ZSetQueue) promoteDelayed(ctx context.Context) error {
+	due, err := q.client.ZRangeByScore(ctx, queueKey(ZSetDelayedTasksKey), &redis.ZRangeBy{
+		Min:   "-inf",
+		Max:   fmt.Sprintf("%d", time.Now().UnixMilli()),
+		Count: zsetPromoteBatch,
+	}).Result()
+	if err != nil {
+		return err
+	}
+
+	for _, taskJSON := range due {
+		var task Task
+		if err := q.tasks.decode([]byte(taskJSON), &task); err != nil {
+			// Set corrupt entries aside unless another consumer already did
+			if removed, _ := q.client.ZRem(ctx, queueKey(ZSetDelayedTasksKey), taskJSON).Result(); removed == 1 {
+				q.tasks.quarantine(ZSetDelayedTasksKey, taskJSON, err)
+			}
+			continue
+		}
+
+		score := zsetScore(task.Priority, task.ScheduledAt)
+		keys := []string{queueKey(ZSetDelayedTasksKey), queueKey(ZSetTasksKey)}
+		if err := promoteZSetTaskScript.Run(ctx, q.client, keys, taskJSON, score).Err(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Consume retrieves the highest priority job, the oldest among equal
+// priorities. It returns nil and no error when no job is available.
+func (q *ZSetQueue) Consume(ctx context.Context) (*Job, error) {
+	if err := q.promoteDelayed(ctx); err != nil {
+		return nil, err
+	}
+
+	for {
+		popped, err := q.client.ZPopMin(ctx, queueKey(ZSetTasksKey), 1).Result()
+		if err != nil {
+			return nil, err
+		}
+		if len(popped) == 0 {
+			return nil, nil
+		}
+
+		taskJSON, _ := popped[0].Member.(string)
+
+		var task Task
+		if err := q.tasks.decode([]byte(taskJSON), &task); err != nil {
+			q.tasks.quarantine(ZSetTasksKey, taskJSON, err)
+			continue
+		}
+
+		// Drop tasks that were cancelled or already finished while queued
+		task.Status = "running"
+		err = q.tasks.UpdateStatus(&task)
+		if errors.Is(err, ErrInvalidTransition) {
+			q.logger.Info(fmt.Sprintf("Skipping task %s: %v", task.ID, err))
+			continue
+		}
+		if err != nil {
+			q.logger.Info(fmt.Sprintf("Failed to update status for task %s: %v", task.ID, err))
+		}
+
+		return JobFromTask(&task), nil
+	}
+}
+
+// UpdateStatus updates a job's status. As on the in-memory queue, a job
+// marked retrying counts an attempt and waits out the retry backoff before it
+// is consumed again, and a job marked failed is moved to the dead letter queue.
+func (q *ZSetQueue) UpdateStatus(ctx context.Context, jobID string, status JobStatus, err error) error {
+	task, getErr := q.tasks.GetTaskStatus(jobID)
+	if getErr != nil {
+		return getErr
+	}
+
+	if err != nil {
+		task.LastError = err.Error()
+	}
+
+	switch status {
+	case StatusRetrying:
+		task.Attempts++
+		task.Status = string(status)
+		if updateErr := q.tasks.UpdateStatus(task); updateErr != nil {
+			return updateErr
+		}
+		return q.delay(ctx, task, time.Duration(q.tasks.RetryPolicy().DelaySeconds(task.Attempts))*time.Second)
+
+	case StatusFailed:
+		if err == nil {
+			err = errors.New(task.LastError)
+		}
+		return q.tasks.MoveToDeadLetterQueue(task, err)
+
+	default:
+		task.Status = string(status)
+		return q.tasks.UpdateStatus(task)
+	}
+}
+
+// GetJob retrieves a job by ID
+func (q *ZSetQueue) GetJob(ctx context.Context, jobID string) (*Job, error) {
+	task, err := q.tasks.GetTaskStatus(jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	return JobFromTask(task), nil
+}
+
+// GetStats returns the number of ready, delayed and dead-lettered tasks
+func (q *ZSetQueue) GetStats(ctx context.Context) (map[string]interface{}, error) {
+	stats := make(map[string]interface{})
+
+	// The dead letter count is reported under the same name as on the list-based queue
+	sets := map[string]string{
+		ZSetTasksKey:        ZSetTasksKey,
+		ZSetDelayedTasksKey: ZSetDelayedTasksKey,
+		DeadLetterQueue:     DeadLetterTasksKey,
+	}
+	for name, key := range sets {
+		count, err := q.client.ZCard(ctx, queueKey(key)).Result()
+		if err != nil {
+			return nil, err
+		}
+		stats[name] = count
+	}
+
+	return stats, nil
+}
+
+// Close closes the queue connection
+func (q *ZSetQueue) Close() error {
+	return q.client.Close()
+}
+
+// ZSetQueueFactory creates sorted set queues
+type ZSetQueueFactory struct {
+	logger Logger
+}
+
+// NewZSetQueueFactory creates a new sorted set queue factory
+func NewZSetQueueFactory(logger Logger) QueueFactory {
+	return &ZSetQueueFactory{
+		logger: logger,
+	}
+}
+
+// CreateQueue creates a new sorted set queue with the provided Redis configuration
+func (f *ZSetQueueFactory) CreateQueue(config map[string]string) (Queue, error) {
+	client, err := NewRedisClient(config)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := client.Ping(context.Background()).Err(); err != nil {
+		f.logger.Info("Failed to connect to Redis: " + err.Error())
+		return nil, err
+	}
+
+	return NewZSetQueue(client, f.logger), nil
+}
+
+// Close for the factory (nothing to release)
+func (f *ZSetQueueFactory) Close() error {
+	return nil
+}
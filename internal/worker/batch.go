@@ -0,0 +1,105 @@
+// internal/worker/batch.go
+package worker
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"BoltQ/internal/queue"
+	"BoltQ/pkg/logger"
+)
+
+// BatchResult is the outcome of one task of a batch
+type BatchResult struct {
+	Result map[string]interface{}
+	Err    error
+}
+
+// BatchProcessor is a function that processes several tasks of one job type
+// at once. It returns one result per task, in the order of the tasks; a task
+// without a result is treated as failed.
+type BatchProcessor func(ctx context.Context, tasks []*queue.Task) []BatchResult
+
+// batchRegistration is a batch processor with its batching limits
+type batchRegistration struct {
+	processor BatchProcessor
+	maxItems  int
+	maxWait   time.Duration
+}
+
+// RegisterBatchProcessor registers a processor that receives up to maxItems
+// tasks of a job type at once. A worker that consumes a task of this type
+// waits up to maxWait for more of them to be next in the same queue. Each
+// task of the batch completes, retries or is dead lettered on its own.
+// Running batches are not stopped when one of their tasks is cancelled.
+func (p *WorkerPool) RegisterBatchProcessor(jobType string, processor BatchProcessor, maxItems int, maxWait time.Duration) {
+	if maxItems < 1 {
+		maxItems = 1
+	}
+
+	p.registry.registerBatch(jobType, batchRegistration{
+		processor: processor,
+		maxItems:  maxItems,
+		maxWait:   maxWait,
+	})
+	p.logger.Info(fmt.Sprintf("Registered batch processor for job type: %s (up to %d tasks)", jobType, maxItems))
+}
+
+// processBatch fills a batch starting with an admitted task, runs the batch
+// processor on it and records the outcome of every task
+func (p *WorkerPool) processBatch(workerID string, first *queue.Task, batch batchRegistration) {
+	tasks, err := p.queue.FillBatch(first, batch.maxItems, batch.maxWait)
+	if err != nil {
+		p.logger.Error(fmt.Sprintf("Error filling batch of %s tasks: %v", first.Type, err))
+	}
+
+	// The first task was admitted before the batch was filled
+	admitted := tasks[:1]
+	for _, task := range tasks[1:] {
+		task.WorkerID = workerID
+		p.metrics.RecordQueueWaitTime(task.Type, task.Priority, queueWaitTime(task).Seconds())
+		if p.admitTask(task) {
+			admitted = append(admitted, task)
+		}
+	}
+
+	// Update metrics
+	p.metrics.IncrementActiveWorkers(len(admitted))
+	defer p.metrics.IncrementActiveWorkers(-len(admitted))
+
+	p.logger.Info(fmt.Sprintf("Worker %s processing batch of %d tasks of type %s", workerID, len(admitted), first.Type),
+		map[string]interface{}{logger.SampleKeyField: "batch_processing"})
+
+	for _, task := range admitted {
+		p.publishRunning(task)
+	}
+
+	// The batch must finish by the earliest deadline of its tasks
+	deadline := p.processingDeadline(admitted[0])
+	for _, task := range admitted[1:] {
+		if taskDeadline := p.processingDeadline(task); taskDeadline.Before(deadline) {
+			deadline = taskDeadline
+		}
+	}
+
+	processingCtx, cancel := context.WithDeadline(p.ctx, deadline)
+	defer cancel()
+
+	startTime := time.Now()
+	results := batch.processor(processingCtx, admitted)
+	processingTime := time.Since(startTime).Seconds()
+
+	for i, task := range admitted {
+		var result BatchResult
+		if i < len(results) {
+			result = results[i]
+		} else {
+			result.Err = fmt.Errorf("batch processor returned no result for task %s", task.ID)
+		}
+
+		// Attribute an equal share of the batch time to every task
+		p.metrics.RecordJobProcessingTime(task.Type, processingTime/float64(len(admitted)))
+		p.finishTask(workerID, task, result.Result, result.Err, processingTime)
+	}
+}
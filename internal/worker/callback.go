@@ -0,0 +1,208 @@
+// internal/worker/callback.go
+package worker
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"BoltQ/internal/job"
+	"BoltQ/internal/queue"
+	"BoltQ/pkg/logger"
+	"BoltQ/pkg/metrics"
+)
+
+// CallbackSignatureHeader carries the HMAC-SHA256 signature of a callback body
+const CallbackSignatureHeader = "X-BoltQ-Signature"
+
+// CallbackPayload is the body POSTed to a task's callback URL
+type CallbackPayload struct {
+	JobID      string                 `json:"job_id"`
+	Type       string                 `json:"type"`
+	Status     string                 `json:"status"`
+	Result     map[string]interface{} `json:"result,omitempty"`
+	Error      string                 `json:"error,omitempty"`
+	Attempts   int                    `json:"attempts"`
+	FinishedAt time.Time              `json:"finished_at"`
+}
+
+// WorkflowCallbackPayload is the body POSTed to a workflow's completion webhook
+type WorkflowCallbackPayload struct {
+	WorkflowID string                         `json:"workflow_id"`
+	Name       string                         `json:"name"`
+	Status     job.WorkflowStatus             `json:"status"`
+	Result     map[string]interface{}         `json:"result,omitempty"`
+	Steps      map[string]WorkflowStepOutcome `json:"steps"`
+	FinishedAt time.Time                      `json:"finished_at"`
+}
+
+// WorkflowStepOutcome is the final state of a step in a workflow callback
+type WorkflowStepOutcome struct {
+	Status job.WorkflowStepStatus `json:"status"`
+	Error  string                 `json:"error,omitempty"`
+}
+
+// errCallbackRejected marks a callback the receiver refused, which won't
+// succeed on retry
+var errCallbackRejected = errors.New("callback rejected")
+
+// CallbackNotifier delivers job completion and failure callbacks over HTTP.
+// When a secret is set, each body is signed with HMAC-SHA256 and the hex
+// digest is sent as "sha256=<digest>" in the X-BoltQ-Signature header.
+type CallbackNotifier struct {
+	client      *http.Client
+	secret      []byte
+	maxAttempts int
+	logger      *logger.Logger
+}
+
+// NewCallbackNotifier creates a new callback notifier
+func NewCallbackNotifier(secret string, maxAttempts int, logger *logger.Logger) *CallbackNotifier {
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+
+	return &CallbackNotifier{
+		client:      &http.Client{Timeout: 10 * time.Second},
+		secret:      []byte(secret),
+		maxAttempts: maxAttempts,
+		logger:      logger,
+	}
+}
+
+// Notify delivers the final state of a task to its callback URL in the
+// background, so callback delivery never delays job processing. Tasks without
+// a callback URL are ignored.
+func (n *CallbackNotifier) Notify(task *queue.Task, result map[string]interface{}, jobErr error) {
+	if task.CallbackURL == "" {
+		return
+	}
+
+	payload := CallbackPayload{
+		JobID:      task.ID,
+		Type:       task.Type,
+		Status:     task.Status,
+		Result:     result,
+		Attempts:   task.Attempts,
+		FinishedAt: time.Now(),
+	}
+	if jobErr != nil {
+		payload.Error = jobErr.Error()
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		n.logger.Error(fmt.Sprintf("Failed to encode callback for task %s: %v", task.ID, err))
+		return
+	}
+
+	go n.deliver(task.CallbackURL, "task", task.ID, body)
+}
+
+// NotifyWorkflow delivers the final state of a workflow to its completion
+// webhook in the background. If the webhook can't be delivered, onFailure is
+// called with the last error. Workflows without a completion webhook are ignored.
+func (n *CallbackNotifier) NotifyWorkflow(workflow *job.Workflow, onFailure func(error)) {
+	webhook := workflow.CompletionWebhook()
+	if webhook == "" {
+		return
+	}
+
+	payload := WorkflowCallbackPayload{
+		WorkflowID: workflow.ID,
+		Name:       workflow.Name,
+		Status:     workflow.Status,
+		Result:     workflow.Result,
+		Steps:      make(map[string]WorkflowStepOutcome, len(workflow.Steps)),
+		FinishedAt: time.Now(),
+	}
+	if workflow.FinishedAt != nil {
+		payload.FinishedAt = *workflow.FinishedAt
+	}
+	for stepID, step := range workflow.Steps {
+		payload.Steps[stepID] = WorkflowStepOutcome{Status: step.Status, Error: step.ErrorMessage}
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		n.logger.Error(fmt.Sprintf("Failed to encode completion webhook for workflow %s: %v", workflow.ID, err))
+		return
+	}
+
+	go func() {
+		if err := n.deliver(webhook, "workflow", workflow.ID, body); err != nil && onFailure != nil {
+			onFailure(err)
+		}
+	}()
+}
+
+// deliver POSTs the callback body of a task or workflow, retrying with
+// exponential backoff on network errors, 429 and 5xx responses. It returns the
+// last error if the callback could not be delivered.
+func (n *CallbackNotifier) deliver(url, kind, id string, body []byte) error {
+	backoff := time.Second
+
+	var err error
+	for attempt := 1; attempt <= n.maxAttempts; attempt++ {
+		err = n.post(url, body)
+		if err == nil {
+			n.logger.Info(fmt.Sprintf("Delivered callback for %s %s", kind, id))
+			return nil
+		}
+
+		n.logger.Error(fmt.Sprintf("Callback attempt %d/%d for %s %s failed: %v", attempt, n.maxAttempts, kind, id, err))
+
+		// Other client errors won't succeed on retry, so don't try again
+		if errors.Is(err, errCallbackRejected) {
+			break
+		}
+		if attempt < n.maxAttempts {
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+	}
+
+	metrics.CallbackFailures.WithLabelValues(kind).Inc()
+	return err
+}
+
+// post sends a single signed callback request
+func (n *CallbackNotifier) post(url string, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	if len(n.secret) > 0 {
+		req.Header.Set(CallbackSignatureHeader, "sha256="+n.sign(body))
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		return fmt.Errorf("callback returned status %d", resp.StatusCode)
+	}
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%w with status %d", errCallbackRejected, resp.StatusCode)
+	}
+
+	return nil
+}
+
+// sign returns the hex-encoded HMAC-SHA256 of body using the shared secret
+func (n *CallbackNotifier) sign(body []byte) string {
+	mac := hmac.New(sha256.New, n.secret)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,213 @@
+// internal/worker/circuit_breaker.go
+package worker
+
+import (
+	"sync"
+	"time"
+)
+
+// BreakerState represents the state of a circuit breaker for a job type
+type BreakerState int
+
+const (
+	// BreakerClosed lets tasks through normally
+	BreakerClosed BreakerState = iota
+
+	// BreakerHalfOpen lets a single probe task through to test recovery
+	BreakerHalfOpen
+
+	// BreakerOpen rejects tasks until the cooldown has elapsed
+	BreakerOpen
+)
+
+// String returns a human-readable name for the state
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerHalfOpen:
+		return "half-open"
+	case BreakerOpen:
+		return "open"
+	default:
+		return "closed"
+	}
+}
+
+// typeBreaker tracks the breaker state of a single job type
+type typeBreaker struct {
+	state        BreakerState
+	failures     int
+	firstFailure time.Time
+	openedAt     time.Time
+	probing      bool
+	probeStarted time.Time
+}
+
+// CircuitBreaker stops processing of a job type after repeated system failures.
+// After threshold consecutive failures within window the breaker opens and
+// tasks of that type are requeued instead of processed. Once cooldown has
+// elapsed a single probe task is let through (half-open); its success closes
+// the breaker and its failure opens it again. A probe that is not reported
+// within another cooldown, e.g. because its worker died, is given up and the
+// next task becomes the probe.
+type CircuitBreaker struct {
+	threshold int
+	window    time.Duration
+	cooldown  time.Duration
+	breakers  map[string]*typeBreaker
+	onChange  func(jobType string, state BreakerState)
+	mu        sync.Mutex
+}
+
+// NewCircuitBreaker creates a new circuit breaker
+func NewCircuitBreaker(threshold int, window, cooldown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{
+		threshold: threshold,
+		window:    window,
+		cooldown:  cooldown,
+		breakers:  make(map[string]*typeBreaker),
+	}
+}
+
+// OnStateChange registers a callback invoked whenever a job type changes state
+func (cb *CircuitBreaker) OnStateChange(fn func(jobType string, state BreakerState)) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.onChange = fn
+}
+
+// Allow reports whether a task of the given type may be processed now
+func (cb *CircuitBreaker) Allow(jobType string) bool {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	b, exists := cb.breakers[jobType]
+	if !exists {
+		return true
+	}
+
+	switch b.state {
+	case BreakerOpen:
+		if time.Since(b.openedAt) < cb.cooldown {
+			return false
+		}
+		cb.setState(jobType, b, BreakerHalfOpen)
+		b.startProbe()
+		return true
+
+	case BreakerHalfOpen:
+		if b.probing && time.Since(b.probeStarted) < cb.cooldown {
+			return false
+		}
+		b.startProbe()
+		return true
+
+	default:
+		return true
+	}
+}
+
+// startProbe lets a single task through to test recovery
+func (b *typeBreaker) startProbe() {
+	b.probing = true
+	b.probeStarted = time.Now()
+}
+
+// RemainingCooldown returns how long the breaker for a job type stays open
+func (cb *CircuitBreaker) RemainingCooldown(jobType string) time.Duration {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	b, exists := cb.breakers[jobType]
+	if !exists || b.state != BreakerOpen {
+		return 0
+	}
+
+	remaining := cb.cooldown - time.Since(b.openedAt)
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
+
+// RecordSuccess resets the failure count and closes the breaker for a job type
+func (cb *CircuitBreaker) RecordSuccess(jobType string) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	b, exists := cb.breakers[jobType]
+	if !exists {
+		return
+	}
+
+	b.failures = 0
+	b.probing = false
+	if b.state != BreakerClosed {
+		cb.setState(jobType, b, BreakerClosed)
+	}
+}
+
+// Release ends a half-open probe that finished without an outcome, e.g.
+// because the task was cancelled, so that the next task becomes the probe
+func (cb *CircuitBreaker) Release(jobType string) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if b, exists := cb.breakers[jobType]; exists && b.state == BreakerHalfOpen {
+		b.probing = false
+	}
+}
+
+// RecordFailure counts a system failure for a job type, opening the breaker
+// once the threshold is reached within the window or if a probe fails
+func (cb *CircuitBreaker) RecordFailure(jobType string) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	b, exists := cb.breakers[jobType]
+	if !exists {
+		b = &typeBreaker{}
+		cb.breakers[jobType] = b
+	}
+
+	now := time.Now()
+
+	if b.state == BreakerHalfOpen {
+		// The probe failed, stay open for another cooldown
+		b.probing = false
+		b.openedAt = now
+		cb.setState(jobType, b, BreakerOpen)
+		return
+	}
+
+	if b.failures == 0 || now.Sub(b.firstFailure) > cb.window {
+		b.failures = 0
+		b.firstFailure = now
+	}
+	b.failures++
+
+	if b.state == BreakerClosed && b.failures >= cb.threshold {
+		b.openedAt = now
+		cb.setState(jobType, b, BreakerOpen)
+	}
+}
+
+// States returns the current state of every tracked job type
+func (cb *CircuitBreaker) States() map[string]string {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	states := make(map[string]string, len(cb.breakers))
+	for jobType, b := range cb.breakers {
+		states[jobType] = b.state.String()
+	}
+	return states
+}
+
+// setState changes the state of a breaker and notifies the listener; callers must hold cb.mu
+func (cb *CircuitBreaker) setState(jobType string, b *typeBreaker, state BreakerState) {
+	b.state = state
+	if cb.onChange != nil {
+		cb.onChange(jobType, state)
+	}
+}
@@ -0,0 +1,105 @@
+package worker
+
+import (
+	"testing"
+	"time"
+
+	"BoltQ/internal/queue"
+)
+
+// openBreaker returns a breaker for job type "test" that has opened and whose
+// cooldown has elapsed, so that the next Allow starts a probe
+func openBreaker(t *testing.T, cooldown time.Duration) *CircuitBreaker {
+	t.Helper()
+
+	breaker := NewCircuitBreaker(1, time.Minute, cooldown)
+	breaker.RecordFailure("test")
+	if breaker.Allow("test") {
+		t.Fatal("Allow on an open breaker = true, want false")
+	}
+	time.Sleep(cooldown)
+	return breaker
+}
+
+func TestCircuitBreakerProbe(t *testing.T) {
+	const cooldown = 50 * time.Millisecond
+
+	tests := []struct {
+		name      string
+		report    func(cb *CircuitBreaker)
+		wantState string
+		wantAllow bool
+	}{
+		{"successful probe closes", func(cb *CircuitBreaker) { cb.RecordSuccess("test") }, "closed", true},
+		{"failed probe reopens", func(cb *CircuitBreaker) { cb.RecordFailure("test") }, "open", false},
+		{"released probe lets the next task probe", func(cb *CircuitBreaker) { cb.Release("test") }, "half-open", true},
+		{"unreported probe blocks others", func(cb *CircuitBreaker) {}, "half-open", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			breaker := openBreaker(t, cooldown)
+
+			if !breaker.Allow("test") {
+				t.Fatal("Allow after the cooldown = false, want a probe")
+			}
+			tt.report(breaker)
+
+			if state := breaker.States()["test"]; state != tt.wantState {
+				t.Errorf("state = %s, want %s", state, tt.wantState)
+			}
+			if allowed := breaker.Allow("test"); allowed != tt.wantAllow {
+				t.Errorf("Allow after the probe = %v, want %v", allowed, tt.wantAllow)
+			}
+		})
+	}
+}
+
+func TestCircuitBreakerProbeExpires(t *testing.T) {
+	const cooldown = 50 * time.Millisecond
+	breaker := openBreaker(t, cooldown)
+
+	// The probe is never reported, e.g. because its worker died
+	if !breaker.Allow("test") {
+		t.Fatal("Allow after the cooldown = false, want a probe")
+	}
+	if breaker.Allow("test") {
+		t.Fatal("second Allow while probing = true, want false")
+	}
+
+	// Another probe is let through once the first has run for a cooldown
+	time.Sleep(cooldown)
+	if !breaker.Allow("test") {
+		t.Fatal("Allow after the probe expired = false, want a new probe")
+	}
+	if breaker.Allow("test") {
+		t.Error("Allow while the new probe runs = true, want false")
+	}
+
+	breaker.RecordSuccess("test")
+	if state := breaker.States()["test"]; state != "closed" {
+		t.Errorf("state after the new probe succeeded = %s, want closed", state)
+	}
+}
+
+func TestAdmitTaskWaitingDependencyKeepsProbe(t *testing.T) {
+	pool, q := newTestPool(t)
+	breaker := openBreaker(t, 50*time.Millisecond)
+	pool.SetCircuitBreaker(breaker)
+
+	dependency := &queue.Task{ID: "dependency", Type: "other"}
+	if err := q.Publish(dependency); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+
+	waiting := &queue.Task{ID: "waiting", Type: "test", DependsOnJobID: dependency.ID}
+	if pool.admitTask(waiting) {
+		t.Fatal("admitTask of a task with a pending dependency = true, want false")
+	}
+
+	// The waiting task must not have taken the half-open breaker's probe
+	probe := &queue.Task{ID: "probe", Type: "test"}
+	if !pool.admitTask(probe) {
+		t.Error("admitTask after a waiting task = false, want the probe admitted")
+	}
+}
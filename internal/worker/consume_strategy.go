@@ -0,0 +1,65 @@
+// internal/worker/consume_strategy.go
+package worker
+
+import (
+	"fmt"
+
+	"BoltQ/internal/queue"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// ConsumeStrategy decides the order in which a pool bound to several tags
+// checks their queues
+type ConsumeStrategy string
+
+const (
+	// ConsumeStrict takes the highest priority task of any tag, checking the
+	// tags in the order they were configured at each priority
+	ConsumeStrict ConsumeStrategy = "strict"
+
+	// ConsumeFair takes turns between the tags and the highest priority task
+	// within the tag whose turn it is, so a flood of high priority tasks for
+	// one tag does not starve the others
+	ConsumeFair ConsumeStrategy = "fair"
+)
+
+// ParseConsumeStrategy parses a consume strategy name
+func ParseConsumeStrategy(name string) (ConsumeStrategy, error) {
+	switch ConsumeStrategy(name) {
+	case ConsumeStrict, ConsumeFair:
+		return ConsumeStrategy(name), nil
+	default:
+		return "", fmt.Errorf("unknown consume strategy: %s", name)
+	}
+}
+
+// SetConsumeStrategy sets how a pool restricted to several tags shares its
+// workers between them. It has no effect on pools without tags.
+func (p *WorkerPool) SetConsumeStrategy(strategy ConsumeStrategy) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.consumeStrategy = strategy
+}
+
+// consumeFair checks the tags round-robin, starting after the tag that the
+// last task was taken from, and each tag's queues from the highest priority
+func (p *WorkerPool) consumeFair(workerID string, tags []string, priorities []int) (*queue.Task, error) {
+	start := int(p.nextTag.Load())
+
+	for i := range tags {
+		turn := (start + i) % len(tags)
+
+		task, err := p.queue.ConsumeTag(workerID, tags[turn], priorities)
+		if err == redis.Nil {
+			continue
+		}
+		if err == nil {
+			p.nextTag.Store(uint32((turn + 1) % len(tags)))
+		}
+		return task, err
+	}
+
+	return nil, redis.Nil
+}
@@ -0,0 +1,60 @@
+package worker
+
+import (
+	"fmt"
+	"testing"
+
+	"BoltQ/internal/queue"
+)
+
+func TestConsumeStrategyUnderHighPriorityLoad(t *testing.T) {
+	tests := []struct {
+		strategy    ConsumeStrategy
+		wantReports int
+	}{
+		{ConsumeStrict, 0},
+		{ConsumeFair, 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.strategy), func(t *testing.T) {
+			pool, q := newTestPool(t)
+			pool.SetRequiredTags("bulk", "reports")
+			pool.SetConsumeStrategy(tt.strategy)
+
+			next := 0
+			publish := func(tag string, priority int) {
+				t.Helper()
+				next++
+				task := &queue.Task{ID: fmt.Sprintf("%s-%d", tag, next), Type: "test", Priority: priority, Tags: []string{tag}}
+				if err := q.Publish(task); err != nil {
+					t.Fatalf("Publish(%s): %v", task.ID, err)
+				}
+			}
+
+			for i := 0; i < 5; i++ {
+				publish("reports", queue.PriorityLow)
+			}
+			for i := 0; i < 10; i++ {
+				publish("bulk", queue.PriorityCritical)
+			}
+
+			// Keep the critical queue full while consuming
+			reports := 0
+			for i := 0; i < 10; i++ {
+				task, err := pool.consume("worker-1")
+				if err != nil {
+					t.Fatalf("consume: %v", err)
+				}
+				if task.Tags[0] == "reports" {
+					reports++
+				}
+				publish("bulk", queue.PriorityCritical)
+			}
+
+			if reports != tt.wantReports {
+				t.Errorf("consumed %d low priority report tasks out of 10, want %d", reports, tt.wantReports)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+// internal/worker/dead_letter_sweeper.go
+package worker
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"BoltQ/internal/queue"
+	"BoltQ/pkg/logger"
+)
+
+// DeadLetterSweeper periodically removes dead-lettered tasks older than the
+// retention period so the dead letter queue cannot grow without bound
+type DeadLetterSweeper struct {
+	queue     *queue.RedisQueue
+	logger    *logger.Logger
+	retention time.Duration
+	ticker    *time.Ticker
+	stopChan  chan struct{}
+	wg        sync.WaitGroup
+}
+
+// NewDeadLetterSweeper creates a sweeper that keeps dead-lettered tasks for retention
+func NewDeadLetterSweeper(queue *queue.RedisQueue, logger *logger.Logger, retention time.Duration) *DeadLetterSweeper {
+	return &DeadLetterSweeper{
+		queue:     queue,
+		logger:    logger,
+		retention: retention,
+		stopChan:  make(chan struct{}),
+	}
+}
+
+// Start migrates any dead letter list left by earlier versions, then trims
+// expired tasks at regular intervals
+func (s *DeadLetterSweeper) Start(interval time.Duration) {
+	if migrated, err := s.queue.MigrateLegacyDeadLetterQueue(); err != nil {
+		s.logger.Error("Error migrating legacy dead letter queue: " + err.Error())
+	} else if migrated > 0 {
+		s.logger.Info(fmt.Sprintf("Migrated %d tasks from the legacy dead letter queue", migrated))
+	}
+
+	s.ticker = time.NewTicker(interval)
+	s.wg.Add(1)
+
+	go func() {
+		defer s.wg.Done()
+
+		s.sweep()
+		for {
+			select {
+			case <-s.ticker.C:
+				s.sweep()
+			case <-s.stopChan:
+				s.ticker.Stop()
+				return
+			}
+		}
+	}()
+
+	s.logger.Info(fmt.Sprintf("Dead letter sweeper started (retention %s)", s.retention))
+}
+
+// Stop gracefully stops the sweeper
+func (s *DeadLetterSweeper) Stop() {
+	close(s.stopChan)
+	s.wg.Wait()
+	s.logger.Info("Dead letter sweeper stopped")
+}
+
+// sweep removes the dead-lettered tasks past the retention period
+func (s *DeadLetterSweeper) sweep() {
+	removed, err := s.queue.TrimDeadLetterQueue(s.retention)
+	if err != nil {
+		s.logger.Error("Error trimming dead letter queue: " + err.Error())
+		return
+	}
+
+	if removed > 0 {
+		s.logger.Info(fmt.Sprintf("Removed %d expired tasks from the dead letter queue", removed))
+	}
+}
@@ -1,7 +1,9 @@
 package worker
 
 import (
+	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"BoltQ/internal/queue"
@@ -9,6 +11,9 @@ import (
 	"BoltQ/pkg/metrics"
 )
 
+// defaultDelayedBatchSize is how many ready jobs a sweep moves by default
+const defaultDelayedBatchSize = queue.DefaultDelayedBatchSize
+
 // DelayedJobProcessor is responsible for moving ready delayed jobs to regular queues
 type DelayedJobProcessor struct {
 	queue        *queue.RedisQueue
@@ -18,18 +23,47 @@ type DelayedJobProcessor struct {
 	stopChan     chan struct{}
 	wg           sync.WaitGroup
 	processCount int64
+	batchSize    int
+
+	// Leader election, so that only one instance sweeps the delayed set
+	leaderID  string
+	leaderTTL time.Duration
+	isLeader  atomic.Bool
 }
 
 // NewDelayedJobProcessor creates a new processor for delayed jobs
 func NewDelayedJobProcessor(queue *queue.RedisQueue, logger *logger.Logger, metrics *metrics.MetricsCollector) *DelayedJobProcessor {
 	return &DelayedJobProcessor{
-		queue:    queue,
-		logger:   logger,
-		metrics:  metrics,
-		stopChan: make(chan struct{}),
+		queue:     queue,
+		logger:    logger,
+		metrics:   metrics,
+		stopChan:  make(chan struct{}),
+		batchSize: defaultDelayedBatchSize,
 	}
 }
 
+// SetBatchSize bounds how many ready jobs a single sweep moves. While a sweep
+// fills its batch, another one runs right away instead of waiting for the next
+// tick. A size of 0 moves every ready job at once. It must be called before Start.
+func (p *DelayedJobProcessor) SetBatchSize(batchSize int) {
+	p.batchSize = batchSize
+}
+
+// EnableLeaderElection makes the processor run only while instanceID holds the
+// delayed processor lock. The lock is renewed on every run and expires after ttl
+// if this instance dies, letting another instance take over. ttl should be a few
+// times the processing interval.
+func (p *DelayedJobProcessor) EnableLeaderElection(instanceID string, ttl time.Duration) {
+	p.leaderID = instanceID
+	p.leaderTTL = ttl
+}
+
+// IsLeader reports whether this instance currently runs the delayed processor.
+// It is always true when leader election is disabled.
+func (p *DelayedJobProcessor) IsLeader() bool {
+	return p.leaderID == "" || p.isLeader.Load()
+}
+
 // Start begins the processing of delayed jobs at regular intervals
 func (p *DelayedJobProcessor) Start(interval time.Duration) {
 	p.ticker = time.NewTicker(interval)
@@ -56,11 +90,25 @@ func (p *DelayedJobProcessor) Start(interval time.Duration) {
 func (p *DelayedJobProcessor) Stop() {
 	close(p.stopChan)
 	p.wg.Wait()
+
+	// Hand the lock over right away instead of waiting for it to expire
+	if p.leaderID != "" && p.isLeader.Load() {
+		if err := p.queue.ReleaseLeadership(queue.DelayedProcessorLeaderKey, p.leaderID); err != nil {
+			p.logger.Error("Error releasing delayed processor leadership: " + err.Error())
+		}
+		p.setLeader(false)
+	}
+
 	p.logger.Info("Delayed job processor stopped")
 }
 
-// processDelayedJobs moves ready jobs from delayed queue to regular queues
+// processDelayedJobs moves ready jobs from delayed queue to regular queues and
+// requeues leased jobs whose lease has expired
 func (p *DelayedJobProcessor) processDelayedJobs() {
+	if p.leaderID != "" && !p.acquireLeadership() {
+		return
+	}
+
 	startTime := time.Now()
 
 	// Record metrics for monitoring
@@ -69,21 +117,83 @@ func (p *DelayedJobProcessor) processDelayedJobs() {
 		p.metrics.RecordDelayedJobProcessorRun(processingTime)
 	}()
 
-	// Process all jobs that are ready
-	count, err := p.queue.ProcessDelayedTasks()
-	if err != nil {
-		p.logger.Error("Error processing delayed tasks: " + err.Error())
-		return
+	// Process the ready jobs in batches until the backlog is drained
+	count := 0
+	for {
+		moved, more, err := p.queue.ProcessDelayedTasks(p.batchSize)
+		if err != nil {
+			p.logger.Error("Error processing delayed tasks: " + err.Error())
+			if count == 0 {
+				return
+			}
+			break
+		}
+		count += moved
+
+		// Stop if a full batch moved nothing, e.g. because another
+		// processor claimed it, rather than spin on the same tasks
+		if !more || moved == 0 || p.stopping() {
+			break
+		}
+	}
+
+	// Put tasks leased over HTTP back once their lease has expired
+	if requeued, err := p.queue.RequeueExpiredLeases(p.batchSize); err != nil {
+		p.logger.Error("Error requeuing tasks with expired leases: " + err.Error())
+	} else if requeued > 0 {
+		p.logger.Info(fmt.Sprintf("Requeued %d tasks with expired leases", requeued))
+	}
+
+	// Let health checks see that the processor is running
+	if err := p.queue.RecordDelayedProcessorRun(); err != nil {
+		p.logger.Error("Error recording delayed processor run: " + err.Error())
 	}
 
 	if count > 0 {
-		p.processCount += int64(count)
+		atomic.AddInt64(&p.processCount, int64(count))
 		p.metrics.RecordDelayedJobsProcessed(count)
-		p.logger.Info("Processed " + string(count) + " delayed tasks")
+		p.logger.Info(fmt.Sprintf("Processed %d delayed tasks", count))
+	}
+}
+
+// stopping reports whether Stop has been called
+func (p *DelayedJobProcessor) stopping() bool {
+	select {
+	case <-p.stopChan:
+		return true
+	default:
+		return false
 	}
 }
 
 // GetProcessCount returns the total number of processed delayed jobs
 func (p *DelayedJobProcessor) GetProcessCount() int64 {
-	return p.processCount
+	return atomic.LoadInt64(&p.processCount)
+}
+
+// acquireLeadership takes or renews the delayed processor lock and reports
+// whether this instance holds it
+func (p *DelayedJobProcessor) acquireLeadership() bool {
+	leader, err := p.queue.AcquireLeadership(queue.DelayedProcessorLeaderKey, p.leaderID, p.leaderTTL)
+	if err != nil {
+		p.logger.Error("Error acquiring delayed processor leadership: " + err.Error())
+		leader = false
+	}
+
+	p.setLeader(leader)
+	return leader
+}
+
+// setLeader records a leadership change
+func (p *DelayedJobProcessor) setLeader(leader bool) {
+	if p.isLeader.Swap(leader) == leader {
+		return
+	}
+
+	if leader {
+		p.logger.Info(fmt.Sprintf("Instance %s is now the delayed processor leader", p.leaderID))
+	} else {
+		p.logger.Info(fmt.Sprintf("Instance %s is no longer the delayed processor leader", p.leaderID))
+	}
+	p.metrics.SetDelayedProcessorLeader(leader)
 }
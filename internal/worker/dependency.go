@@ -0,0 +1,96 @@
+// internal/worker/dependency.go
+package worker
+
+import (
+	"fmt"
+	"time"
+
+	"BoltQ/internal/queue"
+)
+
+const (
+	// DefaultDependencyPollInterval is how long a task waits before its dependency is checked again
+	DefaultDependencyPollInterval = 5 * time.Second
+
+	// DefaultDependencyMaxWait is how long a task waits for its dependency before failing
+	DefaultDependencyMaxWait = time.Hour
+)
+
+// SetDependencyWait configures tasks that depend on another job: they are
+// requeued every pollInterval until the job completes, and fail once they
+// have waited for maxWait.
+func (p *WorkerPool) SetDependencyWait(pollInterval, maxWait time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if pollInterval < time.Second {
+		pollInterval = time.Second
+	}
+	p.dependencyPoll = pollInterval
+	p.dependencyWait = maxWait
+}
+
+// dependencyMet reports whether a task can run now. A task whose dependency
+// is still pending is requeued, and one whose dependency failed, was
+// cancelled, is unknown or did not complete in time is dead-lettered.
+func (p *WorkerPool) dependencyMet(task *queue.Task) bool {
+	if task.DependsOnJobID == "" {
+		return true
+	}
+
+	p.mu.RLock()
+	pollInterval, maxWait := p.dependencyPoll, p.dependencyWait
+	p.mu.RUnlock()
+
+	dependency, err := p.queue.GetTaskStatus(task.DependsOnJobID)
+	if err != nil && err.Error() == "task not found" {
+		p.failDependentTask(task, fmt.Errorf("dependency %s not found", task.DependsOnJobID))
+		return false
+	}
+
+	if err == nil {
+		switch queue.JobStatus(dependency.Status) {
+		case queue.StatusCompleted:
+			return true
+		case queue.StatusFailed, queue.StatusCancelled:
+			p.failDependentTask(task, fmt.Errorf("dependency %s %s", task.DependsOnJobID, dependency.Status))
+			return false
+		}
+	} else {
+		p.logger.Error(fmt.Sprintf("Error checking dependency %s of task %s: %v", task.DependsOnJobID, task.ID, err))
+	}
+
+	// Still waiting; the wait is measured from the first check since
+	// requeuing resets the task's creation time
+	if task.WaitingSince == nil {
+		now := time.Now()
+		task.WaitingSince = &now
+	}
+
+	if time.Since(*task.WaitingSince) >= maxWait {
+		p.failDependentTask(task, fmt.Errorf("dependency %s did not complete within %s", task.DependsOnJobID, maxWait))
+		return false
+	}
+
+	if err := p.queue.PublishDelayed(task, int(pollInterval.Seconds())); err != nil {
+		p.logger.Error(fmt.Sprintf("Error requeuing task %s waiting for dependency %s: %v", task.ID, task.DependsOnJobID, err))
+	}
+
+	return false
+}
+
+// failDependentTask fails a task for good because its dependency cannot be met
+func (p *WorkerPool) failDependentTask(task *queue.Task, err error) {
+	p.logger.Error(fmt.Sprintf("Task %s cannot run: %v", task.ID, err))
+
+	task.RecordAttempt(err)
+	if moveErr := p.queue.MoveToDeadLetterQueue(task, err); moveErr != nil {
+		p.logger.Error(fmt.Sprintf("Error moving task %s to dead letter queue: %v", task.ID, moveErr))
+	}
+
+	p.metrics.IncrementJobCounter("failed")
+	p.notifyCallback(task, nil, err)
+	p.websocket.PublishJobUpdate(task.ID, "failed", map[string]interface{}{
+		"error": err.Error(),
+	})
+}
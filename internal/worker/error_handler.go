@@ -7,6 +7,7 @@ import (
 	"net"
 	"strings"
 	"syscall"
+	"time"
 
 	"BoltQ/internal/queue"
 	"BoltQ/pkg/logger"
@@ -32,40 +33,85 @@ const (
 
 // ErrorHandler manages error handling and retry logic
 type ErrorHandler struct {
-	queue   *queue.RedisQueue
-	logger  *logger.Logger
-	metrics *metrics.MetricsCollector
+	queue            *queue.RedisQueue
+	logger           *logger.Logger
+	metrics          *metrics.MetricsCollector
+	breaker          *CircuitBreaker
+	maxRetryDuration time.Duration
+	redactedKeys     []string
+	rules            []ErrorRule
 }
 
 // NewErrorHandler creates a new error handler
 func NewErrorHandler(q *queue.RedisQueue, l *logger.Logger, m *metrics.MetricsCollector) *ErrorHandler {
 	return &ErrorHandler{
-		queue:   q,
-		logger:  l,
-		metrics: m,
+		queue:        q,
+		logger:       l,
+		metrics:      m,
+		redactedKeys: DefaultRedactedKeys,
 	}
 }
 
+// SetCircuitBreaker sets the circuit breaker that system errors are reported to
+func (h *ErrorHandler) SetCircuitBreaker(breaker *CircuitBreaker) {
+	h.breaker = breaker
+}
+
+// SetMaxRetryDuration bounds how long a task may keep retrying. The window
+// starts at the task's first failure; a task that fails after it has passed is
+// dead-lettered even if it has attempts left. A duration of 0 removes the bound.
+func (h *ErrorHandler) SetMaxRetryDuration(d time.Duration) {
+	h.maxRetryDuration = d
+}
+
+// SetRedactedKeys sets the payload keys whose values are hidden when a
+// dead-lettered task is logged. The dead letter entry keeps the full payload.
+func (h *ErrorHandler) SetRedactedKeys(keys []string) {
+	h.redactedKeys = keys
+}
+
 // HandleJobError processes an error from a job and determines the appropriate action
 func (h *ErrorHandler) HandleJobError(task *queue.Task, err error) error {
 	if err == nil {
 		return nil
 	}
 
+	// Keep the failure in the task's attempt history
+	task.RecordAttempt(err)
+
 	// Categorize the error
 	category := h.categorizeError(err)
-	h.metrics.IncrementErrorCounter(string(category))
+	h.metrics.IncrementErrorCounter(categoryToString(category))
+	if err := h.queue.RecordError(task.Type, categoryToString(category)); err != nil {
+		h.logger.Warn(fmt.Sprintf("Failed to record error stats for task %s: %v", task.ID, err))
+	}
+
+	// Repeated system errors trip the circuit breaker for this job type
+	if h.breaker != nil {
+		if category == SystemError {
+			h.breaker.RecordFailure(task.Type)
+		} else {
+			h.breaker.RecordSuccess(task.Type)
+		}
+	}
 
 	// Log error with proper context
 	h.logger.Error(fmt.Sprintf("Task %s failed with error [%s]: %v",
 		task.ID, categoryToString(category), err))
 
+	// Give up on tasks that have been retrying for too long
+	if category != DataError && h.retryWindowExceeded(task) {
+		h.logger.Error(fmt.Sprintf("Moving task %s to dead letter queue after retrying past %s",
+			task.ID, task.RetryDeadline.Format(time.RFC3339)))
+		return h.moveToDeadLetterQueue(task, err, category)
+	}
+
 	// Handle based on category
 	switch category {
 	case TransientError:
 		// Retry with exponential backoff if under max attempts
 		if task.Attempts < getMaxAttempts(category) {
-			return h.queue.RetryTask(task, err)
+			return h.retry(task, err, category)
 		}
 		// Otherwise treat as permanent failure
 		fallthrough
@@ -73,31 +119,94 @@ func (h *ErrorHandler) HandleJobError(task *queue.Task, err error) error {
 	case DataError:
 		// Data errors are not retried, move to dead letter queue
 		h.logger.Error(fmt.Sprintf("Moving task %s to dead letter queue due to data error", task.ID))
-		return h.queue.MoveToDeadLetterQueue(task, err)
+		return h.moveToDeadLetterQueue(task, err, category)
 
 	case SystemError:
-		// System errors have different max attempts and backoff strategy
+		// System errors get more attempts
 		if task.Attempts < getMaxAttempts(category) {
-			// Use a different backoff strategy for system errors
-			return h.retryWithSystemErrorBackoff(task, err)
+			return h.retry(task, err, category)
 		}
 		h.logger.Error(fmt.Sprintf("Moving task %s to dead letter queue after exhausting system error retries", task.ID))
-		return h.queue.MoveToDeadLetterQueue(task, err)
+		return h.moveToDeadLetterQueue(task, err, category)
 
 	case UnknownError:
 		// Unknown errors get default retry behavior
 		if task.Attempts < getMaxAttempts(category) {
-			return h.queue.RetryTask(task, err)
+			return h.retry(task, err, category)
 		}
 		h.logger.Error(fmt.Sprintf("Moving task %s to dead letter queue after exhausting retries", task.ID))
-		return h.queue.MoveToDeadLetterQueue(task, err)
+		return h.moveToDeadLetterQueue(task, err, category)
+	}
+
+	return nil
+}
+
+// retryWindowExceeded starts the retry window of a task at its first failure
+// and reports whether the window has passed
+func (h *ErrorHandler) retryWindowExceeded(task *queue.Task) bool {
+	if h.maxRetryDuration <= 0 {
+		return false
+	}
+
+	if task.RetryDeadline == nil {
+		deadline := time.Now().Add(h.maxRetryDuration)
+		task.RetryDeadline = &deadline
+		return false
 	}
 
+	return time.Now().After(*task.RetryDeadline)
+}
+
+// retry requeues a task with the backoff of the queue's retry policy and
+// records the retry and its delay
+func (h *ErrorHandler) retry(task *queue.Task, err error, category ErrorCategory) error {
+	if err := h.queue.RetryTask(task, err); err != nil {
+		return err
+	}
+
+	backoffSeconds := h.queue.RetryPolicy().DelaySeconds(task.Attempts)
+	if category == SystemError {
+		h.logger.Info(fmt.Sprintf("System error for task %s, attempt %d. Retrying in %d seconds",
+			task.ID, task.Attempts, backoffSeconds))
+	}
+
+	h.metrics.RecordRetry(task.Type, categoryToString(category), float64(backoffSeconds))
+	return nil
+}
+
+// moveToDeadLetterQueue dead-letters a task, logs it in full for postmortems
+// and counts the move by error category
+func (h *ErrorHandler) moveToDeadLetterQueue(task *queue.Task, err error, category ErrorCategory) error {
+	task.ErrorCategory = categoryToString(category)
+	if moveErr := h.queue.MoveToDeadLetterQueue(task, err); moveErr != nil {
+		return moveErr
+	}
+
+	h.logger.Error(fmt.Sprintf("Task %s moved to dead letter queue", task.ID), map[string]interface{}{
+		"task_id":         task.ID,
+		"type":            task.Type,
+		"priority":        task.Priority,
+		"data":            redactPayload(task.Data, h.redactedKeys),
+		"attempts":        task.Attempts,
+		"attempt_history": task.AttemptHistory,
+		"last_error":      task.LastError,
+		"error_category":  task.ErrorCategory,
+		"created_at":      task.CreatedAt,
+		"worker_id":       task.WorkerID,
+		"tags":            task.Tags,
+	})
+
+	h.metrics.RecordDeadLetter(task.Type, categoryToString(category))
 	return nil
 }
 
 // categorizeError determines what type of error occurred
 func (h *ErrorHandler) categorizeError(err error) ErrorCategory {
+	// Custom rules take precedence over the built-in ones
+	if category, ok := h.customCategory(err); ok {
+		return category
+	}
+
 	errMsg := err.Error()
 
 	// Check for network and system errors (usually transient)
@@ -121,6 +230,9 @@ func (h *ErrorHandler) categorizeError(err error) ErrorCategory {
 	}
 
 	// Check for data validation errors
+	if errors.Is(err, ErrResultTooLarge) || errors.Is(err, ErrNonSerializableResult) {
+		return DataError
+	}
 	if strings.Contains(errMsg, "validation failed") ||
 		strings.Contains(errMsg, "invalid parameter") ||
 		strings.Contains(errMsg, "not found") ||
@@ -132,27 +244,6 @@ func (h *ErrorHandler) categorizeError(err error) ErrorCategory {
 	return UnknownError
 }
 
-// retryWithSystemErrorBackoff uses a custom backoff for system errors
-func (h *ErrorHandler) retryWithSystemErrorBackoff(task *queue.Task, err error) error {
-	task.Attempts++
-	task.Status = "retrying"
-	task.LastError = err.Error()
-
-	// For system errors, we use a more aggressive linear backoff
-	// starting with 5 seconds and increasing by 5 seconds each attempt
-	backoffSeconds := 5 * task.Attempts
-
-	// Cap at 2 minutes
-	if backoffSeconds > 120 {
-		backoffSeconds = 120
-	}
-
-	h.logger.Info(fmt.Sprintf("System error for task %s, attempt %d. Retrying in %d seconds",
-		task.ID, task.Attempts, backoffSeconds))
-
-	return h.queue.PublishDelayed(task, int(backoffSeconds))
-}
-
 // getMaxAttempts returns the maximum number of retry attempts based on error category
 func getMaxAttempts(category ErrorCategory) int {
 	switch category {
@@ -0,0 +1,54 @@
+package worker
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"BoltQ/internal/queue"
+)
+
+// timeoutError is a network error that timed out
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestHandleJobErrorRetryDelay(t *testing.T) {
+	policy := queue.RetryPolicy{BaseDelay: 10 * time.Second, Multiplier: 3, MaxDelay: time.Minute}
+
+	tests := []struct {
+		name     string
+		err      error
+		attempts int
+		want     time.Duration
+	}{
+		{"transient first retry", timeoutError{}, 0, 10 * time.Second},
+		{"transient capped", timeoutError{}, 2, time.Minute},
+		{"system first retry", errors.New("connection refused"), 0, 10 * time.Second},
+		{"system second retry", errors.New("connection refused"), 1, 30 * time.Second},
+		{"system capped", errors.New("connection refused"), 5, time.Minute},
+		{"unknown first retry", errors.New("boom"), 0, 10 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pool, q := newTestPool(t)
+			q.SetRetryPolicy(policy)
+
+			task := &queue.Task{ID: "task", Type: "test", Attempts: tt.attempts}
+			before := time.Now()
+			if err := pool.errorHandler.HandleJobError(task, tt.err); err != nil {
+				t.Fatalf("HandleJobError: %v", err)
+			}
+
+			if task.Status != "scheduled" {
+				t.Fatalf("status = %q, want scheduled for a retry", task.Status)
+			}
+			if delay := task.ScheduledAt.Sub(before); delay < tt.want || delay > tt.want+time.Second {
+				t.Errorf("retry delay = %s, want %s", delay, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+// internal/worker/error_rules.go
+package worker
+
+import (
+	"errors"
+	"regexp"
+)
+
+// ErrorRule assigns a category to the errors it matches
+type ErrorRule struct {
+	Match    func(err error) bool
+	Category ErrorCategory
+}
+
+// AddErrorRule registers a rule that categorizes the errors match returns true
+// for. Custom rules are evaluated in the order they were added, before the
+// built-in rules, and the first one that matches decides the category. Rules
+// should be added before the worker pool starts.
+func (h *ErrorHandler) AddErrorRule(match func(err error) bool, category ErrorCategory) {
+	h.rules = append(h.rules, ErrorRule{Match: match, Category: category})
+}
+
+// AddErrorPattern registers a rule that categorizes the errors whose message,
+// including wrapped errors, matches the regular expression pattern
+func (h *ErrorHandler) AddErrorPattern(pattern string, category ErrorCategory) error {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return err
+	}
+
+	h.AddErrorRule(func(err error) bool {
+		return re.MatchString(err.Error())
+	}, category)
+	return nil
+}
+
+// AddErrorTarget registers a rule that categorizes the errors that are, or wrap,
+// target, as reported by errors.Is
+func (h *ErrorHandler) AddErrorTarget(target error, category ErrorCategory) {
+	h.AddErrorRule(func(err error) bool {
+		return errors.Is(err, target)
+	}, category)
+}
+
+// customCategory returns the category of the first custom rule matching err
+func (h *ErrorHandler) customCategory(err error) (ErrorCategory, bool) {
+	for _, rule := range h.rules {
+		if rule.Match(err) {
+			return rule.Category, true
+		}
+	}
+	return UnknownError, false
+}
@@ -0,0 +1,41 @@
+package worker
+
+import (
+	"testing"
+	"time"
+
+	"BoltQ/internal/job"
+	"BoltQ/internal/queue"
+	"BoltQ/pkg/logger"
+	"BoltQ/pkg/metrics"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+)
+
+// testPublisher discards job and workflow updates
+type testPublisher struct{}
+
+func (testPublisher) PublishJobUpdate(string, string, map[string]interface{}) error { return nil }
+
+func (testPublisher) PublishWorkflowUpdate(string, job.WorkflowStatus, map[string]interface{}) error {
+	return nil
+}
+
+// newTestPool returns a worker pool, not started, on a queue backed by an
+// in-memory Redis server
+func newTestPool(t testing.TB) (*WorkerPool, *queue.RedisQueue) {
+	t.Helper()
+
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	log := logger.NewLogger("test")
+	collector := metrics.NewMetricsCollector("test")
+	q := queue.NewRedisQueue(client, log)
+	errorHandler := NewErrorHandler(q, log, collector)
+	workflowManager := job.NewWorkflowManager(client, log)
+
+	return NewWorkerPool(q, log, collector, errorHandler, workflowManager, testPublisher{}, 1, time.Millisecond), q
+}
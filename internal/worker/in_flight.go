@@ -0,0 +1,52 @@
+// internal/worker/in_flight.go
+package worker
+
+import "fmt"
+
+// SetMaxInFlight caps the number of tasks the whole pool works on at once,
+// across all workers and their concurrency. A worker that finds the pool full
+// does not consume and backs off instead, so no task is taken only to be put
+// back. A limit of 0 removes the cap. It can be changed while the pool runs.
+func (p *WorkerPool) SetMaxInFlight(limit int) {
+	if limit < 0 {
+		limit = 0
+	}
+
+	p.mu.Lock()
+	p.maxInFlight = limit
+	p.mu.Unlock()
+
+	p.metrics.SetMaxTasksInFlight(limit)
+	if limit > 0 {
+		p.logger.Info(fmt.Sprintf("Worker pool limited to %d tasks in flight", limit))
+	}
+}
+
+// InFlight returns the number of tasks the pool is working on
+func (p *WorkerPool) InFlight() int {
+	return int(p.inFlight.Load())
+}
+
+// acquireInFlight reserves room for one more task, reporting false if the pool
+// is at its in-flight limit
+func (p *WorkerPool) acquireInFlight() bool {
+	p.mu.RLock()
+	limit := p.maxInFlight
+	p.mu.RUnlock()
+
+	for {
+		current := p.inFlight.Load()
+		if limit > 0 && int(current) >= limit {
+			return false
+		}
+		if p.inFlight.CompareAndSwap(current, current+1) {
+			p.metrics.SetTasksInFlight(int(current + 1))
+			return true
+		}
+	}
+}
+
+// releaseInFlight frees the room reserved by acquireInFlight
+func (p *WorkerPool) releaseInFlight() {
+	p.metrics.SetTasksInFlight(int(p.inFlight.Add(-1)))
+}
@@ -4,13 +4,33 @@ package worker
 import (
 	"context"
 	"fmt"
+	"os"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"BoltQ/internal/job"
 	"BoltQ/internal/queue"
 	"BoltQ/pkg/logger"
 	"BoltQ/pkg/metrics"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// cancelCheckInterval is how often a running task's status is checked for cancellation
+const cancelCheckInterval = 2 * time.Second
+
+// maxProcessingTime is the longest a processor may run on a single task,
+// unless its job type has its own timeout
+const maxProcessingTime = 5 * time.Minute
+
+// consumeErrorBackoff is how long a worker waits after a failed queue poll,
+// such as when Redis is down. It doubles with every further failure in a row,
+// up to maxConsumeErrorBackoff.
+const (
+	consumeErrorBackoff    = 1 * time.Second
+	maxConsumeErrorBackoff = 30 * time.Second
 )
 
 // JobProcessor is a function that processes a task
@@ -18,20 +38,39 @@ type JobProcessor func(ctx context.Context, task *queue.Task) (map[string]interf
 
 // WorkerPool manages a pool of worker goroutines
 type WorkerPool struct {
-	queue           *queue.RedisQueue
-	logger          *logger.Logger
-	metrics         *metrics.MetricsCollector
-	processors      map[string]JobProcessor
-	errorHandler    *ErrorHandler
-	workflowManager *job.WorkflowManager
-	websocket       WebSocketPublisher
-	numWorkers      int
-	pollingInterval time.Duration
-	wg              sync.WaitGroup
-	ctx             context.Context
-	cancel          context.CancelFunc
-	mu              sync.RWMutex
-	activeWorkers   int32 // Atomic counter for active workers
+	queue              *queue.RedisQueue
+	logger             *logger.Logger
+	metrics            *metrics.MetricsCollector
+	registry           *processorRegistry
+	errorHandler       *ErrorHandler
+	breaker            *CircuitBreaker
+	callbacks          *CallbackNotifier
+	workflowManager    *job.WorkflowManager
+	websocket          WebSocketPublisher
+	instanceID         string
+	numWorkers         int
+	concurrency        int
+	concurrencyChanged chan struct{}
+	maxInFlight        int
+	inFlight           atomic.Int32
+	pollingInterval    time.Duration
+	maxPollInterval    time.Duration
+	blockTimeout       time.Duration
+	dependencyPoll     time.Duration
+	dependencyWait     time.Duration
+	priorities         []int
+	tags               []string
+	consumeStrategy    ConsumeStrategy
+	maxResultSize      int64
+	resultPolicy       ResultOverflowPolicy
+	nextTag            atomic.Uint32
+	workerCancels      []context.CancelFunc
+	started            bool
+	wg                 sync.WaitGroup
+	ctx                context.Context
+	cancel             context.CancelFunc
+	mu                 sync.RWMutex
+	activeWorkers      int32 // Atomic counter for active workers
 }
 
 // WebSocketPublisher interface for publishing updates
@@ -54,55 +93,200 @@ func NewWorkerPool(
 	ctx, cancel := context.WithCancel(context.Background())
 
 	return &WorkerPool{
-		queue:           queue,
-		logger:          logger,
-		metrics:         metrics,
-		processors:      make(map[string]JobProcessor),
-		errorHandler:    errorHandler,
-		workflowManager: workflowManager,
-		websocket:       websocket,
-		numWorkers:      numWorkers,
-		pollingInterval: pollingInterval,
-		ctx:             ctx,
-		cancel:          cancel,
+		queue:              queue,
+		logger:             logger,
+		metrics:            metrics,
+		registry:           newProcessorRegistry(),
+		resultPolicy:       ResultReject,
+		errorHandler:       errorHandler,
+		workflowManager:    workflowManager,
+		websocket:          websocket,
+		instanceID:         DefaultInstanceID(),
+		numWorkers:         numWorkers,
+		concurrency:        1,
+		concurrencyChanged: make(chan struct{}),
+		consumeStrategy:    ConsumeStrict,
+		pollingInterval:    pollingInterval,
+		maxPollInterval:    defaultMaxPollInterval(pollingInterval),
+		dependencyPoll:     DefaultDependencyPollInterval,
+		dependencyWait:     DefaultDependencyMaxWait,
+		ctx:                ctx,
+		cancel:             cancel,
 	}
 }
 
-// RegisterProcessor registers a processor for a specific job type
+// DefaultInstanceID identifies this process as hostname-pid
+func DefaultInstanceID() string {
+	hostname, _ := os.Hostname()
+	return fmt.Sprintf("%s-%d", hostname, os.Getpid())
+}
+
+// RegisterProcessor registers a processor for a specific job type. Processors
+// may be registered while the pool is running; tasks consumed afterwards use them.
 func (p *WorkerPool) RegisterProcessor(jobType string, processor JobProcessor) {
+	p.registry.register(jobType, processor)
+	p.logger.Info(fmt.Sprintf("Registered processor for job type: %s", jobType))
+}
+
+// SetCallbackNotifier enables HTTP callbacks for tasks that carry a callback URL.
+// Callbacks are sent when a task completes or fails terminally.
+func (p *WorkerPool) SetCallbackNotifier(notifier *CallbackNotifier) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
-	p.processors[jobType] = processor
-	p.logger.Info(fmt.Sprintf("Registered processor for job type: %s", jobType))
+	p.callbacks = notifier
 }
 
-// HasProcessorFor checks if a processor is registered for a job type
-func (p *WorkerPool) HasProcessorFor(jobType string) bool {
+// SetMaxPollingInterval sets the cap for the empty-queue polling backoff.
+// Workers start at the base polling interval and double it after every empty
+// poll until this cap is reached. A value at or below the base interval
+// disables the backoff.
+func (p *WorkerPool) SetMaxPollingInterval(maxInterval time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.maxPollInterval = maxInterval
+}
+
+// SetAllowedPriorities restricts the pool to the given priority queues.
+// Workers drain the allowed queues from highest to lowest priority and never
+// touch the others, so a dedicated pool can be run for critical work.
+// Calling it with no priorities lets the pool consume from every queue again.
+func (p *WorkerPool) SetAllowedPriorities(priorities ...int) {
+	sorted := append([]int(nil), priorities...)
+	sort.Sort(sort.Reverse(sort.IntSlice(sorted)))
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.priorities = sorted
+	if len(sorted) > 0 {
+		p.logger.Info(fmt.Sprintf("Worker pool restricted to priorities %v", sorted))
+	}
+}
+
+// SetRequiredTags restricts the pool to tasks routed by one of the given tags.
+// Tagged tasks are published to per-tag queues keyed by their first tag, so a
+// tagged pool never pops work it would have to put back and untagged pools
+// are not starved by it. A tagged pool does not consume untagged tasks.
+// Calling it with no tags lets the pool consume from the default queues again.
+func (p *WorkerPool) SetRequiredTags(tags ...string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.tags = append([]string(nil), tags...)
+	if len(tags) > 0 {
+		p.logger.Info(fmt.Sprintf("Worker pool restricted to tags %v", tags))
+	}
+}
+
+// SetCircuitBreaker enables a per-job-type circuit breaker. While the breaker
+// for a type is open, tasks of that type are requeued instead of processed.
+func (p *WorkerPool) SetCircuitBreaker(breaker *CircuitBreaker) {
+	breaker.OnStateChange(func(jobType string, state BreakerState) {
+		p.metrics.SetCircuitBreakerState(jobType, int(state))
+		p.logger.Info(fmt.Sprintf("Circuit breaker for job type %s is now %s", jobType, state))
+	})
+
+	p.mu.Lock()
+	p.breaker = breaker
+	p.mu.Unlock()
+
+	p.errorHandler.SetCircuitBreaker(breaker)
+}
+
+// Stats returns runtime statistics about the worker pool
+func (p *WorkerPool) Stats() map[string]interface{} {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	stats := map[string]interface{}{
+		"num_workers":      p.numWorkers,
+		"concurrency":      p.concurrency,
+		"in_flight":        p.inFlight.Load(),
+		"max_in_flight":    p.maxInFlight,
+		"polling_interval": p.pollingInterval.String(),
+	}
+
+	if len(p.priorities) > 0 {
+		stats["priorities"] = p.priorities
+	}
+
+	if len(p.tags) > 0 {
+		stats["tags"] = p.tags
+		stats["consume_strategy"] = p.consumeStrategy
+	}
+
+	if p.breaker != nil {
+		stats["circuit_breakers"] = p.breaker.States()
+	}
+
+	return stats
+}
+
+// SetInstanceID sets the ID identifying this worker process, such as a pod
+// name. Worker IDs are prefixed with it so that tasks, logs and heartbeats can
+// be attributed to a process. Call it before Start.
+func (p *WorkerPool) SetInstanceID(instanceID string) {
+	if instanceID == "" {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.instanceID = instanceID
+}
+
+// InstanceID returns the ID identifying this worker process
+func (p *WorkerPool) InstanceID() string {
 	p.mu.RLock()
 	defer p.mu.RUnlock()
+	return p.instanceID
+}
 
-	_, exists := p.processors[jobType]
-	return exists
+// SetDefaultProcessor sets a processor for tasks whose job type has no
+// processor of its own. Without one, which is the default, such tasks are
+// dead-lettered. Passing nil removes it.
+func (p *WorkerPool) SetDefaultProcessor(processor JobProcessor) {
+	p.registry.setDefault(processor)
+}
+
+// HasProcessorFor checks if a processor or batch processor is registered for a
+// job type. The default processor doesn't count.
+func (p *WorkerPool) HasProcessorFor(jobType string) bool {
+	return p.registry.has(jobType)
 }
 
 // Start starts the worker pool
 func (p *WorkerPool) Start() {
+	p.mu.Lock()
 	p.logger.Info(fmt.Sprintf("Starting worker pool with %d workers", p.numWorkers))
 
 	// Start task workers
-	for i := 0; i < p.numWorkers; i++ {
-		p.wg.Add(1)
-		go p.startWorker(i)
-	}
+	p.started = true
+	p.resizeLocked(p.numWorkers)
+	p.mu.Unlock()
 
 	// Start workflow processor
 	p.wg.Add(1)
 	go p.startWorkflowProcessor()
 
+	// Start heartbeats so health checks can count live workers
+	p.wg.Add(1)
+	go p.startHeartbeat()
+
 	p.logger.Info("Worker pool started")
 }
 
+// Running reports whether the pool has been started and not stopped
+func (p *WorkerPool) Running() bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.started && p.ctx.Err() == nil
+}
+
 // Stop gracefully stops the worker pool
 func (p *WorkerPool) Stop() {
 	p.logger.Info("Stopping worker pool...")
@@ -111,34 +295,320 @@ func (p *WorkerPool) Stop() {
 	p.logger.Info("Worker pool stopped")
 }
 
-// startWorker starts a worker goroutine
-func (p *WorkerPool) startWorker(id int) {
+// SetNumWorkers changes the number of worker loops. On a running pool, extra
+// workers are started immediately; surplus workers finish their in-flight task
+// before exiting, so no work is dropped.
+func (p *WorkerPool) SetNumWorkers(numWorkers int) {
+	if numWorkers < 0 {
+		numWorkers = 0
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if numWorkers == p.numWorkers {
+		return
+	}
+
+	p.logger.Info(fmt.Sprintf("Resizing worker pool from %d to %d workers", p.numWorkers, numWorkers))
+	p.numWorkers = numWorkers
+
+	if p.started {
+		p.resizeLocked(numWorkers)
+	}
+}
+
+// SetWorkerConcurrency lets each worker loop process up to concurrency tasks
+// at once, which suits IO-bound processors. It can be changed while the pool
+// runs; after lowering it, workers finish the tasks they have before taking
+// new ones.
+func (p *WorkerPool) SetWorkerConcurrency(concurrency int) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if concurrency == p.concurrency {
+		return
+	}
+	p.concurrency = concurrency
+
+	// Wake the workers waiting for a slot
+	close(p.concurrencyChanged)
+	p.concurrencyChanged = make(chan struct{})
+}
+
+// SetPollingInterval changes the base polling interval of all workers
+func (p *WorkerPool) SetPollingInterval(interval time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.pollingInterval = interval
+}
+
+// resizeLocked starts or stops worker loops until numWorkers are running; callers must hold p.mu
+func (p *WorkerPool) resizeLocked(numWorkers int) {
+	for len(p.workerCancels) < numWorkers {
+		workerCtx, cancel := context.WithCancel(p.ctx)
+		id := len(p.workerCancels)
+		p.workerCancels = append(p.workerCancels, cancel)
+
+		p.wg.Add(1)
+		go p.startWorker(workerCtx, id)
+	}
+
+	for len(p.workerCancels) > numWorkers {
+		last := len(p.workerCancels) - 1
+		p.workerCancels[last]()
+		p.workerCancels = p.workerCancels[:last]
+	}
+
+	p.metrics.SetWorkerPoolSize(numWorkers)
+}
+
+// startHeartbeat periodically records a heartbeat for every running worker
+// until the pool stops, then removes them
+func (p *WorkerPool) startHeartbeat() {
 	defer p.wg.Done()
 
-	workerID := fmt.Sprintf("worker-%d", id)
-	p.logger.Info(fmt.Sprintf("Worker %s started", workerID))
+	ticker := time.NewTicker(queue.WorkerHeartbeatInterval)
+	defer ticker.Stop()
+
+	p.sendHeartbeat()
 
 	for {
 		select {
 		case <-p.ctx.Done():
+			if err := p.queue.RemoveWorkerHeartbeats(p.heartbeatIDs()); err != nil {
+				p.logger.Error(fmt.Sprintf("Error removing worker heartbeats: %v", err))
+			}
+			return
+
+		case <-ticker.C:
+			p.sendHeartbeat()
+		}
+	}
+}
+
+// sendHeartbeat records a heartbeat for every running worker, for the job
+// types the pool can process and for the tags it consumes
+func (p *WorkerPool) sendHeartbeat() {
+	if err := p.queue.RecordWorkerHeartbeats(p.heartbeatIDs()); err != nil {
+		p.logger.Error(fmt.Sprintf("Error recording worker heartbeats: %v", err))
+	}
+
+	if err := p.queue.RecordJobTypes(p.registry.jobTypes()); err != nil {
+		p.logger.Error(fmt.Sprintf("Error recording job types: %v", err))
+	}
+
+	p.mu.RLock()
+	tags := p.tags
+	p.mu.RUnlock()
+
+	if err := p.queue.RecordWorkerTags(tags); err != nil {
+		p.logger.Error(fmt.Sprintf("Error recording worker tags: %v", err))
+	}
+}
+
+// heartbeatIDs returns the cluster-wide IDs of the pool's running workers
+func (p *WorkerPool) heartbeatIDs() []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	ids := make([]string, 0, len(p.workerCancels))
+	for i := range p.workerCancels {
+		ids = append(ids, fmt.Sprintf("%s/worker-%d", p.instanceID, i))
+	}
+	return ids
+}
+
+// startWorker starts a worker goroutine that runs until its context is cancelled.
+// The worker processes up to the pool's concurrency of tasks at once and waits
+// for its in-flight tasks before exiting.
+func (p *WorkerPool) startWorker(workerCtx context.Context, id int) {
+	defer p.wg.Done()
+
+	p.mu.RLock()
+	concurrency := p.concurrency
+	workerID := fmt.Sprintf("%s/worker-%d", p.instanceID, id)
+	p.mu.RUnlock()
+
+	p.logger.Info(fmt.Sprintf("Worker %s started with concurrency %d", workerID, concurrency))
+
+	slots := newWorkerSlots()
+	var inFlight sync.WaitGroup
+	defer inFlight.Wait()
+
+	interval := p.basePollInterval()
+	var errorBackoff time.Duration
+
+	for {
+		// Wait for a free slot before taking another task
+		if workerCtx.Err() != nil || !p.acquireSlot(workerCtx, slots) {
 			p.logger.Info(fmt.Sprintf("Worker %s shutting down", workerID))
 			return
-		default:
-			p.processNextTask(workerID)
+		}
 
-			// Sleep briefly before next poll to avoid hammering Redis
-			time.Sleep(p.pollingInterval)
+		// Don't take a task while the pool is at its in-flight limit
+		if !p.acquireInFlight() {
+			slots.release()
+			interval = p.nextPollInterval(interval)
+		} else if task, err := p.nextTask(workerID); task != nil {
+			// Found work, go back to the base interval
+			interval = p.basePollInterval()
+			errorBackoff = 0
+
+			inFlight.Add(1)
+			go func() {
+				defer inFlight.Done()
+				defer slots.release()
+				defer p.releaseInFlight()
+				p.processTask(workerID, task)
+			}()
+		} else if err != nil {
+			// The queue could not be polled, wait before trying again instead
+			// of spinning while Redis is unavailable
+			p.releaseInFlight()
+			slots.release()
+			errorBackoff = nextConsumeErrorBackoff(errorBackoff)
+			interval = errorBackoff
+			p.logger.Error(fmt.Sprintf("Worker %s failed to consume a task, retrying in %s: %v", workerID, errorBackoff, err))
+		} else {
+			// Queue was empty, back off exponentially up to the cap
+			p.releaseInFlight()
+			slots.release()
+			errorBackoff = 0
+			interval = p.nextPollInterval(interval)
+
+			// A blocking consume has already waited for the next task
+			if p.blockingTimeout() > 0 {
+				interval = 0
+			}
 		}
+
+		// Sleep before next poll to avoid hammering Redis
+		select {
+		case <-workerCtx.Done():
+		case <-time.After(interval):
+		}
+	}
+}
+
+// SetBlockingConsume makes workers that find the queues empty wait for the
+// next task with a blocking pop of up to timeout, instead of sleeping until
+// their next poll. A task pushed to the queues, including a delayed task
+// becoming due, is then picked up right away. Each waiting worker holds a
+// Redis connection. A timeout of 0 or less restores polling.
+func (p *WorkerPool) SetBlockingConsume(timeout time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.blockTimeout = timeout
+}
+
+// blockingTimeout returns how long an idle worker waits in a blocking pop,
+// or 0 if workers poll
+func (p *WorkerPool) blockingTimeout() time.Duration {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.blockTimeout
+}
+
+// basePollInterval returns the current base polling interval
+func (p *WorkerPool) basePollInterval() time.Duration {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.pollingInterval
+}
+
+// nextPollInterval doubles the current polling interval, capped at maxPollInterval
+func (p *WorkerPool) nextPollInterval(current time.Duration) time.Duration {
+	p.mu.RLock()
+	base := p.pollingInterval
+	maxInterval := p.maxPollInterval
+	p.mu.RUnlock()
+
+	if maxInterval <= base {
+		return base
 	}
+
+	if current < base {
+		current = base
+	}
+
+	next := current * 2
+	if next > maxInterval {
+		next = maxInterval
+	}
+	return next
 }
 
-// processNextTask processes the next task from the queue
-func (p *WorkerPool) processNextTask(workerID string) {
+// nextConsumeErrorBackoff doubles the wait after a failed queue poll, starting
+// at consumeErrorBackoff and capped at maxConsumeErrorBackoff
+func nextConsumeErrorBackoff(current time.Duration) time.Duration {
+	if current < consumeErrorBackoff {
+		return consumeErrorBackoff
+	}
+
+	next := current * 2
+	if next > maxConsumeErrorBackoff {
+		next = maxConsumeErrorBackoff
+	}
+	return next
+}
+
+// defaultMaxPollInterval returns the default polling backoff cap for a base
+// interval: twenty times the base interval, but at least 2 seconds
+func defaultMaxPollInterval(base time.Duration) time.Duration {
+	if base*20 > 2*time.Second {
+		return base * 20
+	}
+	return 2 * time.Second
+}
+
+// nextTask consumes the next task from the queue, recording consume metrics.
+// It returns a nil task and a nil error if no task was available, and the
+// error if the queue could not be polled.
+func (p *WorkerPool) nextTask(workerID string) (*queue.Task, error) {
 	// Get next task from queue
-	task, err := p.queue.Consume()
+	consumeStart := time.Now()
+	task, err := p.consume(workerID)
+	consumeTime := time.Since(consumeStart).Seconds()
 
-	if err != nil {
+	if err == redis.Nil {
 		// No tasks available
+		p.metrics.RecordConsume("empty", consumeTime)
+		return nil, nil
+	}
+
+	if err != nil {
+		p.metrics.RecordConsume("error", consumeTime)
+		p.metrics.RecordConsumeError()
+		return nil, err
+	}
+
+	p.metrics.RecordConsume("task", consumeTime)
+	p.metrics.RecordQueueWaitTime(task.Type, task.Priority, queueWaitTime(task).Seconds())
+
+	return task, nil
+}
+
+// processTask runs a consumed task on its processor and records the outcome
+func (p *WorkerPool) processTask(workerID string, task *queue.Task) {
+	task.WorkerID = workerID
+	if !p.admitTask(task) {
+		return
+	}
+
+	// Job types with a batch processor are run together with more queued
+	// tasks of the same type
+	processor, batch := p.registry.lookup(task.Type)
+	if batch != nil {
+		p.processBatch(workerID, task, *batch)
 		return
 	}
 
@@ -146,19 +616,19 @@ func (p *WorkerPool) processNextTask(workerID string) {
 	p.metrics.IncrementActiveWorkers(1)
 	defer p.metrics.IncrementActiveWorkers(-1)
 
-	p.logger.Info(fmt.Sprintf("Worker %s processing task %s of type %s", workerID, task.ID, task.Type))
+	p.logger.Info(fmt.Sprintf("Worker %s processing task %s of type %s", workerID, task.ID, task.Type),
+		map[string]interface{}{logger.SampleKeyField: "task_processing"})
 
-	// Get processor for this job type
-	p.mu.RLock()
-	processor, exists := p.processors[task.Type]
-	p.mu.RUnlock()
-
-	if !exists {
+	// Without a processor of its own or a default processor, the task fails
+	if processor == nil {
 		err := fmt.Errorf("no processor registered for job type: %s", task.Type)
 		p.logger.Error(err.Error())
 
 		// Handle error (move to dead letter queue)
 		p.errorHandler.HandleJobError(task, err)
+		if task.Status == "failed" {
+			p.notifyCallback(task, nil, err)
+		}
 
 		// Publish update
 		p.websocket.PublishJobUpdate(task.ID, "failed", map[string]interface{}{
@@ -168,10 +638,16 @@ func (p *WorkerPool) processNextTask(workerID string) {
 		return
 	}
 
-	// Create task context with timeout
-	processingCtx, cancel := context.WithTimeout(p.ctx, 5*time.Minute)
+	p.publishRunning(task)
+
+	// Create task context with the task's deadline, capped by the pool maximum
+	processingCtx, cancel := context.WithDeadline(p.ctx, p.processingDeadline(task))
 	defer cancel()
 
+	// Stop processing if the task is cancelled while it runs
+	var cancelled atomic.Bool
+	go p.watchCancellation(processingCtx, task.ID, cancel, &cancelled)
+
 	// Record start time for metrics
 	startTime := time.Now()
 
@@ -182,11 +658,69 @@ func (p *WorkerPool) processNextTask(workerID string) {
 	processingTime := time.Since(startTime).Seconds()
 	p.metrics.RecordJobProcessingTime(task.Type, processingTime)
 
+	if cancelled.Load() {
+		// A cancelled task says nothing about whether its job type recovered
+		if breaker := p.circuitBreaker(); breaker != nil {
+			breaker.Release(task.Type)
+		}
+
+		p.logger.Info(fmt.Sprintf("Task %s was cancelled while processing", task.ID))
+		p.metrics.IncrementJobCounter("cancelled")
+		p.websocket.PublishJobUpdate(task.ID, "cancelled", nil)
+		return
+	}
+
+	p.finishTask(workerID, task, result, err, processingTime)
+}
+
+// publishRunning tells WebSocket clients that a worker has started a task
+func (p *WorkerPool) publishRunning(task *queue.Task) {
+	p.websocket.PublishJobUpdate(task.ID, "running", map[string]interface{}{
+		"worker_id":   task.WorkerID,
+		"instance_id": p.InstanceID(),
+	})
+}
+
+// admitTask reports whether a consumed task may be processed now. Expired
+// tasks, tasks whose circuit breaker is open and tasks waiting for another job
+// are handled here and must not be processed.
+func (p *WorkerPool) admitTask(task *queue.Task) bool {
+	// Don't start a task whose deadline has passed
+	if task.Deadline != nil && !time.Now().Before(*task.Deadline) {
+		p.expireTask(task)
+		return false
+	}
+
+	// Hold the task back until the job it depends on has completed. This is
+	// checked first so that a waiting task doesn't take the breaker's probe.
+	if !p.dependencyMet(task) {
+		return false
+	}
+
+	// Requeue the task if its job type's circuit breaker is open
+	return p.allowTask(task)
+}
+
+// finishTask records the outcome of a processed task: it completes the task,
+// or hands the error to the error handler to retry or dead letter it
+func (p *WorkerPool) finishTask(workerID string, task *queue.Task, result map[string]interface{}, err error, processingTime float64) {
+	if err == nil {
+		// Keep oversized and unencodable results out of Redis
+		result, err = p.limitResult(task, result)
+	}
+
 	if err != nil {
 		p.logger.Error(fmt.Sprintf("Error processing task %s: %v", task.ID, err))
 
 		// Handle the error with appropriate retry/dead letter strategy
 		p.errorHandler.HandleJobError(task, err)
+		p.metrics.IncrementJobCounter("failed")
+
+		// A dead-lettered task has failed for good
+		if task.Status == "failed" {
+			p.completeWorkflowStep(task, nil, err)
+			p.notifyCallback(task, nil, err)
+		}
 
 		// Publish update
 		p.websocket.PublishJobUpdate(task.ID, "failed", map[string]interface{}{
@@ -199,8 +733,15 @@ func (p *WorkerPool) processNextTask(workerID string) {
 	// Task completed successfully
 	task.Status = "completed"
 
+	if breaker := p.circuitBreaker(); breaker != nil {
+		breaker.RecordSuccess(task.Type)
+	}
+
 	if result != nil {
 		// Convert result to JSON string for storage in Redis
+		if task.Data == nil {
+			task.Data = make(map[string]interface{})
+		}
 		task.Data["result"] = result
 	}
 
@@ -209,8 +750,12 @@ func (p *WorkerPool) processNextTask(workerID string) {
 		p.logger.Error(fmt.Sprintf("Error updating task status: %v", err))
 	}
 
+	p.completeWorkflowStep(task, result, nil)
+	p.notifyCallback(task, result, nil)
+
 	// Increment completed counter
 	p.metrics.IncrementJobCounter("completed")
+	p.metrics.RecordJobLatency(task.Type, task.Latency().Seconds())
 
 	// Publish update
 	p.websocket.PublishJobUpdate(task.ID, "completed", map[string]interface{}{
@@ -218,7 +763,206 @@ func (p *WorkerPool) processNextTask(workerID string) {
 	})
 
 	p.logger.Info(fmt.Sprintf("Worker %s completed task %s in %.2f seconds",
-		workerID, task.ID, processingTime))
+		workerID, task.ID, processingTime), map[string]interface{}{logger.SampleKeyField: "task_completed"})
+}
+
+// expireTask marks a task whose deadline passed before it could run as expired
+func (p *WorkerPool) expireTask(task *queue.Task) {
+	err := fmt.Errorf("deadline %s passed before the task ran", task.Deadline.Format(time.RFC3339))
+	p.logger.Info(fmt.Sprintf("Task %s expired: %v", task.ID, err))
+
+	task.Status = string(queue.StatusExpired)
+	task.LastError = err.Error()
+	if updateErr := p.queue.UpdateStatus(task); updateErr != nil {
+		p.logger.Error(fmt.Sprintf("Error updating task status: %v", updateErr))
+	}
+
+	p.metrics.IncrementJobCounter("expired")
+	p.notifyCallback(task, nil, err)
+	p.websocket.PublishJobUpdate(task.ID, "expired", map[string]interface{}{
+		"error": err.Error(),
+	})
+}
+
+// consume retrieves the next task for a worker, honouring the allowed priority
+// set if one is configured, and waits for one if the queues are empty and
+// blocking consume is enabled
+func (p *WorkerPool) consume(workerID string) (*queue.Task, error) {
+	p.mu.RLock()
+	priorities := p.priorities
+	tags := p.tags
+	strategy := p.consumeStrategy
+	p.mu.RUnlock()
+
+	var task *queue.Task
+	var err error
+	switch {
+	case len(tags) > 1 && strategy == ConsumeFair:
+		task, err = p.consumeFair(workerID, tags, priorities)
+	case len(tags) > 0:
+		task, err = p.queue.ConsumeTags(workerID, tags, priorities)
+	default:
+		task, err = p.queue.ConsumePriorities(workerID, priorities)
+	}
+
+	// Wait for the next task to arrive instead of polling again later
+	if timeout := p.blockingTimeout(); err == redis.Nil && timeout > 0 {
+		return p.queue.ConsumeBlocking(workerID, queue.ConsumeQueueNames(tags, priorities), timeout)
+	}
+	return task, err
+}
+
+// queueWaitTime returns how long a task waited in the queue, counting delayed
+// tasks from the time they became due rather than from their creation
+func queueWaitTime(task *queue.Task) time.Duration {
+	readyAt := task.CreatedAt
+	if task.ScheduledAt.After(readyAt) {
+		readyAt = task.ScheduledAt
+	}
+
+	wait := time.Since(readyAt)
+	if wait < 0 {
+		return 0
+	}
+	return wait
+}
+
+// watchCancellation polls the stored status of a running task and cancels its
+// processing context once the task has been cancelled, e.g. by a workflow cancellation
+func (p *WorkerPool) watchCancellation(ctx context.Context, taskID string, cancel context.CancelFunc, cancelled *atomic.Bool) {
+	ticker := time.NewTicker(cancelCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			task, err := p.queue.GetTaskStatus(taskID)
+			if err == nil && task.Status == "cancelled" {
+				cancelled.Store(true)
+				cancel()
+				return
+			}
+		}
+	}
+}
+
+// notifyCallback sends the task's completion callback if callbacks are enabled
+func (p *WorkerPool) notifyCallback(task *queue.Task, result map[string]interface{}, err error) {
+	p.mu.RLock()
+	callbacks := p.callbacks
+	p.mu.RUnlock()
+
+	if callbacks != nil {
+		callbacks.Notify(task, result, err)
+	}
+}
+
+// finishWorkflow publishes the final state of a workflow and delivers its
+// completion webhook, once per workflow even if it is processed again
+func (p *WorkerPool) finishWorkflow(workflow *job.Workflow) {
+	p.websocket.PublishWorkflowUpdate(workflow.ID, workflow.Status, nil)
+
+	p.mu.RLock()
+	callbacks := p.callbacks
+	p.mu.RUnlock()
+
+	if callbacks == nil || workflow.CompletionWebhook() == "" {
+		return
+	}
+
+	claimed, err := p.workflowManager.ClaimCompletionWebhook(workflow.ID)
+	if err != nil {
+		p.logger.Error(fmt.Sprintf("Error claiming completion webhook of workflow %s: %v", workflow.ID, err))
+		return
+	}
+	if !claimed {
+		return
+	}
+
+	callbacks.NotifyWorkflow(workflow, func(webhookErr error) {
+		if err := p.workflowManager.RecordCompletionWebhookFailure(workflow.ID, webhookErr); err != nil {
+			p.logger.Error(fmt.Sprintf("Error recording completion webhook failure of workflow %s: %v", workflow.ID, err))
+		}
+	})
+}
+
+// circuitBreaker returns the configured circuit breaker, if any
+func (p *WorkerPool) circuitBreaker() *CircuitBreaker {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.breaker
+}
+
+// allowTask checks the circuit breaker for a task and requeues it if the breaker is open
+func (p *WorkerPool) allowTask(task *queue.Task) bool {
+	breaker := p.circuitBreaker()
+	if breaker == nil || breaker.Allow(task.Type) {
+		return true
+	}
+
+	// Put the task back until the cooldown has elapsed
+	delaySeconds := int(breaker.RemainingCooldown(task.Type).Seconds())
+	if delaySeconds < 1 {
+		delaySeconds = 1
+	}
+
+	if err := p.queue.PublishDelayed(task, delaySeconds); err != nil {
+		p.logger.Error(fmt.Sprintf("Error requeuing task %s while circuit breaker is open: %v", task.ID, err))
+	}
+
+	return false
+}
+
+// completeWorkflowStep reports the outcome of a task to its workflow, if it belongs to one
+func (p *WorkerPool) completeWorkflowStep(task *queue.Task, result map[string]interface{}, taskErr error) {
+	workflowID, _ := task.Data[job.WorkflowIDKey].(string)
+	stepID, _ := task.Data[job.WorkflowStepIDKey].(string)
+	if workflowID == "" || stepID == "" {
+		return
+	}
+
+	if err := p.workflowManager.CompleteStep(workflowID, stepID, result, taskErr); err != nil {
+		p.logger.Error(fmt.Sprintf("Error completing step %s of workflow %s: %v", stepID, workflowID, err))
+	}
+}
+
+// stepTaskData builds the task data for a workflow step: a copy of its params,
+// the workflow context and the results of the steps it depends on under
+// StepInputsKey. Dependencies without a stored result are left out.
+func (p *WorkerPool) stepTaskData(workflow *job.Workflow, step *job.WorkflowStep) map[string]interface{} {
+	data := make(map[string]interface{}, len(step.Params)+3)
+	for k, v := range step.Params {
+		data[k] = v
+	}
+
+	data[job.WorkflowIDKey] = workflow.ID
+	data[job.WorkflowStepIDKey] = step.ID
+
+	if len(step.DependsOn) == 0 {
+		return data
+	}
+
+	inputs := make(map[string]interface{}, len(step.DependsOn))
+	for _, depID := range step.DependsOn {
+		result, err := p.workflowManager.GetStepResult(workflow.ID, depID)
+		if err != nil {
+			// Fall back to the result kept on the workflow itself
+			if depStep, exists := workflow.Steps[depID]; exists && depStep.Result != nil {
+				result = depStep.Result
+			} else {
+				p.logger.Info(fmt.Sprintf("No result available from step %s for step %s of workflow %s",
+					depID, step.ID, workflow.ID))
+				continue
+			}
+		}
+		inputs[depID] = result
+	}
+	data[job.StepInputsKey] = inputs
+
+	return data
 }
 
 // startWorkflowProcessor starts the workflow processor
@@ -257,6 +1001,12 @@ func (p *WorkerPool) processNextWorkflow() {
 		return
 	}
 
+	// A finished workflow only needs its final state reported
+	if workflow.IsTerminal() {
+		p.finishWorkflow(workflow)
+		return
+	}
+
 	// Update workflow status to running if it's pending
 	if workflow.Status == job.WorkflowStatusPending {
 		now := time.Now()
@@ -272,6 +1022,24 @@ func (p *WorkerPool) processNextWorkflow() {
 		p.websocket.PublishWorkflowUpdate(workflow.ID, workflow.Status, nil)
 	}
 
+	// Skip steps whose condition on the results before them does not hold
+	if skipped := workflow.SkipUnmetConditions(); len(skipped) > 0 || workflow.IsTerminal() {
+		for _, stepID := range skipped {
+			p.logger.Info(fmt.Sprintf("Skipped workflow step %s of workflow %s because its condition does not hold",
+				stepID, workflow.ID))
+		}
+
+		if err := p.workflowManager.SaveWorkflow(workflow); err != nil {
+			p.logger.Error(fmt.Sprintf("Error saving workflow: %v", err))
+			return
+		}
+
+		if workflow.IsTerminal() {
+			p.finishWorkflow(workflow)
+			return
+		}
+	}
+
 	// Get all ready steps
 	readySteps := workflow.GetReadySteps()
 
@@ -282,7 +1050,7 @@ func (p *WorkerPool) processNextWorkflow() {
 		hasFailed := false
 
 		for _, step := range workflow.Steps {
-			if step.Status == job.StepStatusPending {
+			if step.Status == job.StepStatusPending || step.Status == job.StepStatusRunning {
 				allComplete = false
 			} else if step.Status == job.StepStatusFailed {
 				hasFailed = true
@@ -291,13 +1059,12 @@ func (p *WorkerPool) processNextWorkflow() {
 
 		if allComplete || hasFailed {
 			// Workflow is complete or has failed
-			now := time.Now()
-			workflow.FinishedAt = &now
-
 			if hasFailed {
+				now := time.Now()
+				workflow.FinishedAt = &now
 				workflow.Status = job.WorkflowStatusFailed
 			} else {
-				workflow.Status = job.WorkflowStatusCompleted
+				workflow.Complete()
 			}
 
 			if err := p.workflowManager.SaveWorkflow(workflow); err != nil {
@@ -305,29 +1072,34 @@ func (p *WorkerPool) processNextWorkflow() {
 				return
 			}
 
-			// Publish update
-			p.websocket.PublishWorkflowUpdate(workflow.ID, workflow.Status, nil)
+			p.finishWorkflow(workflow)
 		}
 
 		return
 	}
 
+	// Hold back steps beyond the workflow's parallelism limit; they are
+	// started when the workflow is processed again after a step finishes
+	readySteps = workflow.LimitParallelSteps(readySteps)
+	if len(readySteps) == 0 {
+		return
+	}
+
+	// Spread large fan-outs over time if the workflow asks for it
+	delays := workflow.StepDelays(len(readySteps))
+
 	// Process each ready step
-	for _, step := range readySteps {
+	for i, step := range readySteps {
 		// Create a task for the step
 		task := &queue.Task{
-			ID:        step.ID,
+			ID:        job.StepTaskID(workflow.ID, step.ID),
 			Type:      step.JobType,
-			Data:      step.Params,
-			Priority:  1, // Use normal priority
+			Data:      p.stepTaskData(workflow, step),
+			Priority:  queue.PriorityNormal,
 			CreatedAt: time.Now(),
 			Status:    "pending",
 		}
 
-		// Include workflow context
-		task.Data["workflow_id"] = workflow.ID
-		task.Data["workflow_step_id"] = step.ID
-
 		// Update step status
 		step.Status = job.StepStatusRunning
 		if err := workflow.UpdateStepStatus(step.ID, job.StepStatusRunning, "", nil); err != nil {
@@ -342,7 +1114,12 @@ func (p *WorkerPool) processNextWorkflow() {
 		}
 
 		// Publish step to queue
-		if err := p.queue.Publish(task); err != nil {
+		if delays[i] > 0 {
+			err = p.queue.PublishDelayed(task, delays[i])
+		} else {
+			err = p.queue.Publish(task)
+		}
+		if err != nil {
 			p.logger.Error(fmt.Sprintf("Error publishing step task: %v", err))
 
 			// Update step status as failed
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"BoltQ/internal/job"
+	"BoltQ/internal/queue"
 	"BoltQ/pkg/logger"
 	"BoltQ/pkg/metrics"
 
@@ -111,6 +112,22 @@ func processDefaultJob(ctx context.Context, j *job.Job) error {
 	return nil
 }
 
+// LoggingDefaultProcessor returns a default processor for WorkerPool that,
+// like processDefaultJob, logs tasks of unknown job types with a warning and
+// completes them instead of letting them be dead-lettered
+func LoggingDefaultProcessor(log *logger.Logger) JobProcessor {
+	return func(ctx context.Context, task *queue.Task) (map[string]interface{}, error) {
+		log.Warn("Using default processor for unknown job type", map[string]interface{}{
+			"task_id": task.ID,
+			"type":    task.Type,
+		})
+
+		return map[string]interface{}{
+			"handled_by": "default",
+		}, nil
+	}
+}
+
 // GetProcessorFunc returns an appropriate processor function for a job type
 func GetProcessorFunc(jobType string) ProcessorFunc {
 	// Initialize processors if not already done
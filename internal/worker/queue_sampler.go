@@ -0,0 +1,81 @@
+// internal/worker/queue_sampler.go
+package worker
+
+import (
+	"sync"
+	"time"
+
+	"BoltQ/internal/queue"
+	"BoltQ/pkg/logger"
+	"BoltQ/pkg/metrics"
+)
+
+// QueueDepthSampler periodically records queue depths, including the dead letter queue, as metrics
+type QueueDepthSampler struct {
+	queue    *queue.RedisQueue
+	logger   *logger.Logger
+	metrics  *metrics.MetricsCollector
+	ticker   *time.Ticker
+	stopChan chan struct{}
+	wg       sync.WaitGroup
+}
+
+// NewQueueDepthSampler creates a new queue depth sampler
+func NewQueueDepthSampler(queue *queue.RedisQueue, logger *logger.Logger, metrics *metrics.MetricsCollector) *QueueDepthSampler {
+	return &QueueDepthSampler{
+		queue:    queue,
+		logger:   logger,
+		metrics:  metrics,
+		stopChan: make(chan struct{}),
+	}
+}
+
+// Start begins sampling queue depths at regular intervals
+func (s *QueueDepthSampler) Start(interval time.Duration) {
+	s.ticker = time.NewTicker(interval)
+	s.wg.Add(1)
+
+	go func() {
+		defer s.wg.Done()
+
+		for {
+			select {
+			case <-s.ticker.C:
+				s.sample()
+			case <-s.stopChan:
+				s.ticker.Stop()
+				return
+			}
+		}
+	}()
+
+	s.logger.Info("Queue depth sampler started")
+}
+
+// Stop gracefully stops the sampler
+func (s *QueueDepthSampler) Stop() {
+	close(s.stopChan)
+	s.wg.Wait()
+	s.logger.Info("Queue depth sampler stopped")
+}
+
+// sample reads the current queue stats and records them
+func (s *QueueDepthSampler) sample() {
+	stats, err := s.queue.GetQueueStats()
+	if err != nil {
+		s.logger.Error("Error sampling queue depths: " + err.Error())
+		return
+	}
+
+	for name, value := range stats {
+		depth, ok := value.(int64)
+		if !ok {
+			continue
+		}
+
+		if name == queue.DeadLetterQueue {
+			s.metrics.SetDeadLetterQueueSize(float64(depth))
+		}
+		s.metrics.SetQueueDepth(name, float64(depth))
+	}
+}
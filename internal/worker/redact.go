@@ -0,0 +1,55 @@
+// internal/worker/redact.go
+package worker
+
+import "strings"
+
+// DefaultRedactedKeys are the payload keys hidden from dead letter logs unless
+// configured otherwise
+var DefaultRedactedKeys = []string{"password", "secret", "token", "api_key", "authorization"}
+
+// redactedValue replaces the values of redacted payload keys
+const redactedValue = "[REDACTED]"
+
+// redactPayload returns a copy of a task payload in which the values of the
+// given keys are replaced, at any depth. Keys match case-insensitively.
+func redactPayload(data map[string]interface{}, keys []string) map[string]interface{} {
+	if data == nil {
+		return nil
+	}
+
+	redacted := make(map[string]interface{}, len(data))
+	for k, v := range data {
+		if isRedactedKey(k, keys) {
+			redacted[k] = redactedValue
+			continue
+		}
+		redacted[k] = redactValue(v, keys)
+	}
+	return redacted
+}
+
+// redactValue redacts the maps nested in a payload value
+func redactValue(v interface{}, keys []string) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		return redactPayload(value, keys)
+	case []interface{}:
+		items := make([]interface{}, len(value))
+		for i, item := range value {
+			items[i] = redactValue(item, keys)
+		}
+		return items
+	default:
+		return v
+	}
+}
+
+// isRedactedKey reports whether a payload key is one of the redacted keys
+func isRedactedKey(key string, keys []string) bool {
+	for _, redacted := range keys {
+		if strings.EqualFold(key, redacted) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,128 @@
+// internal/worker/registry.go
+package worker
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"BoltQ/internal/queue"
+)
+
+// processorRegistry holds the processors of a worker pool and their per-type
+// settings behind one lock. It is safe to register processors while workers
+// are consuming: a registration applies to tasks looked up after it, and a
+// task that is already running keeps the processor it was started with.
+type processorRegistry struct {
+	mu         sync.RWMutex
+	processors map[string]JobProcessor
+	batches    map[string]batchRegistration
+	timeouts   map[string]time.Duration
+	fallback   JobProcessor
+}
+
+// newProcessorRegistry creates an empty registry
+func newProcessorRegistry() *processorRegistry {
+	return &processorRegistry{
+		processors: make(map[string]JobProcessor),
+		batches:    make(map[string]batchRegistration),
+		timeouts:   make(map[string]time.Duration),
+	}
+}
+
+// register sets the processor of a job type
+func (r *processorRegistry) register(jobType string, processor JobProcessor) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.processors[jobType] = processor
+}
+
+// registerBatch sets the batch processor of a job type
+func (r *processorRegistry) registerBatch(jobType string, batch batchRegistration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.batches[jobType] = batch
+}
+
+// setDefault sets the processor of job types without one; nil removes it
+func (r *processorRegistry) setDefault(processor JobProcessor) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.fallback = processor
+}
+
+// setTimeout sets the processor timeout of a job type; 0 or less removes it
+func (r *processorRegistry) setTimeout(jobType string, timeout time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if timeout <= 0 {
+		delete(r.timeouts, jobType)
+		return
+	}
+	r.timeouts[jobType] = timeout
+}
+
+// lookup returns how tasks of a job type are processed: by a batch processor,
+// if the type has one, or else by its own processor or the default processor.
+// Both are looked up together, so a concurrent registration is either seen in
+// full or not at all. The processor is nil if the type has neither.
+func (r *processorRegistry) lookup(jobType string) (JobProcessor, *batchRegistration) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if batch, ok := r.batches[jobType]; ok {
+		return nil, &batch
+	}
+	if processor, ok := r.processors[jobType]; ok {
+		return processor, nil
+	}
+	return r.fallback, nil
+}
+
+// timeout returns the processor timeout of a job type, if it has its own
+func (r *processorRegistry) timeout(jobType string) (time.Duration, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	timeout, ok := r.timeouts[jobType]
+	return timeout, ok
+}
+
+// has reports whether a job type has a processor or batch processor of its own
+func (r *processorRegistry) has(jobType string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	_, exists := r.processors[jobType]
+	if !exists {
+		_, exists = r.batches[jobType]
+	}
+	return exists
+}
+
+// jobTypes returns the job types with a processor or batch processor, sorted,
+// plus queue.AnyJobType if there is a default processor
+func (r *processorRegistry) jobTypes() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	jobTypes := make([]string, 0, len(r.processors)+len(r.batches)+1)
+	for jobType := range r.processors {
+		jobTypes = append(jobTypes, jobType)
+	}
+	for jobType := range r.batches {
+		if _, ok := r.processors[jobType]; !ok {
+			jobTypes = append(jobTypes, jobType)
+		}
+	}
+	sort.Strings(jobTypes)
+
+	if r.fallback != nil {
+		jobTypes = append(jobTypes, queue.AnyJobType)
+	}
+	return jobTypes
+}
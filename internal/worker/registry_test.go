@@ -0,0 +1,73 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"BoltQ/internal/queue"
+)
+
+// TestRegisterWhileConsuming registers processors and their settings while
+// workers look them up. Run with -race to check the registry's locking.
+func TestRegisterWhileConsuming(t *testing.T) {
+	pool, q := newTestPool(t)
+	pool.SetNumWorkers(4)
+
+	echo := func(ctx context.Context, task *queue.Task) (map[string]interface{}, error) {
+		return task.Data, nil
+	}
+	pool.RegisterProcessor("echo", echo)
+
+	const tasks = 50
+	for i := 0; i < tasks; i++ {
+		task := &queue.Task{ID: fmt.Sprintf("task-%d", i), Type: "echo", Priority: queue.PriorityNormal}
+		if err := q.Publish(task); err != nil {
+			t.Fatalf("Publish(%s): %v", task.ID, err)
+		}
+	}
+
+	pool.Start()
+	defer pool.Stop()
+
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				jobType := fmt.Sprintf("type-%d-%d", g, i)
+				pool.RegisterProcessor("echo", echo)
+				pool.RegisterProcessor(jobType, echo)
+				pool.RegisterBatchProcessor(jobType+"-batch", func(ctx context.Context, tasks []*queue.Task) []BatchResult {
+					return nil
+				}, 10, time.Millisecond)
+				pool.SetProcessorTimeout("echo", time.Minute)
+				pool.SetDefaultProcessor(nil)
+				pool.HasProcessorFor(jobType)
+				pool.registry.jobTypes()
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for i := 0; i < tasks; i++ {
+		id := fmt.Sprintf("task-%d", i)
+		for {
+			task, err := q.GetTaskStatus(id)
+			if err != nil {
+				t.Fatalf("GetTaskStatus(%s): %v", id, err)
+			}
+			if task.Status == "completed" {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("task %s is %s, want completed", id, task.Status)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+}
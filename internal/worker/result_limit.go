@@ -0,0 +1,131 @@
+// internal/worker/result_limit.go
+package worker
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	"BoltQ/internal/queue"
+)
+
+// ErrResultTooLarge fails a task whose result exceeds the maximum result size
+// under the reject policy. It is a data error, so the task is not retried.
+var ErrResultTooLarge = errors.New("result too large")
+
+// ErrNonSerializableResult fails a task whose result can't be encoded as JSON,
+// e.g. because it holds a channel or a function. Storing it would fail, so the
+// task is failed instead, as a data error that is not retried.
+var ErrNonSerializableResult = errors.New("non-serializable result")
+
+// ResultOverflowPolicy decides what happens to a result larger than the
+// maximum result size
+type ResultOverflowPolicy string
+
+const (
+	// ResultReject fails the task with ErrResultTooLarge
+	ResultReject ResultOverflowPolicy = "reject"
+
+	// ResultTruncate completes the task with a truncated preview of the result
+	ResultTruncate ResultOverflowPolicy = "truncate"
+)
+
+// ParseResultOverflowPolicy parses a result overflow policy name
+func ParseResultOverflowPolicy(name string) (ResultOverflowPolicy, error) {
+	switch ResultOverflowPolicy(name) {
+	case ResultReject, ResultTruncate:
+		return ResultOverflowPolicy(name), nil
+	default:
+		return "", fmt.Errorf("unknown result overflow policy: %s", name)
+	}
+}
+
+// SetMaxResultSize limits the serialized size of the results stored with
+// completed tasks, so a processor cannot fill Redis with a huge result. Larger
+// results are rejected or truncated depending on the policy. A maxSize of 0
+// or less removes the limit.
+func (p *WorkerPool) SetMaxResultSize(maxSize int64, policy ResultOverflowPolicy) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.maxResultSize = maxSize
+	p.resultPolicy = policy
+}
+
+// SetProcessorTimeout sets how long processors of a job type may run on a
+// single task, in place of the default of 5 minutes. A task's own deadline
+// still applies if it is sooner. A timeout of 0 or less restores the default.
+func (p *WorkerPool) SetProcessorTimeout(jobType string, timeout time.Duration) {
+	p.registry.setTimeout(jobType, timeout)
+}
+
+// processingDeadline returns when processing of a task must stop: the task's
+// own deadline, if it has one, but no later than the processor timeout of its
+// job type from now
+func (p *WorkerPool) processingDeadline(task *queue.Task) time.Time {
+	timeout, ok := p.registry.timeout(task.Type)
+	if !ok {
+		timeout = maxProcessingTime
+	}
+
+	deadline := time.Now().Add(timeout)
+	if task.Deadline != nil && task.Deadline.Before(deadline) {
+		return *task.Deadline
+	}
+	return deadline
+}
+
+// limitResult checks that a processor result can be encoded and is within the
+// maximum result size. It returns the result to store, which is a truncated
+// preview under the truncate policy, ErrResultTooLarge under the reject policy
+// or ErrNonSerializableResult if the result can't be encoded.
+func (p *WorkerPool) limitResult(task *queue.Task, result map[string]interface{}) (map[string]interface{}, error) {
+	if result == nil {
+		return nil, nil
+	}
+
+	resultJSON, err := json.Marshal(result)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNonSerializableResult, err)
+	}
+
+	p.mu.RLock()
+	maxSize, policy := p.maxResultSize, p.resultPolicy
+	p.mu.RUnlock()
+
+	size := int64(len(resultJSON))
+	if maxSize <= 0 || size <= maxSize {
+		return result, nil
+	}
+
+	p.metrics.RecordOversizedResult(task.Type, string(policy))
+
+	if policy != ResultTruncate {
+		return nil, fmt.Errorf("%w: %d bytes, limit is %d bytes", ErrResultTooLarge, size, maxSize)
+	}
+
+	p.logger.Info(fmt.Sprintf("Truncating %d byte result of task %s to the %d byte limit", size, task.ID, maxSize))
+
+	return map[string]interface{}{
+		"truncated":     true,
+		"original_size": size,
+		"preview":       resultPreview(resultJSON, maxSize/2),
+	}, nil
+}
+
+// resultPreview returns the start of an encoded result, at most n bytes long
+// and cut on a character boundary. Half the limit leaves room for the escaping
+// of the preview string once the truncated result is encoded again.
+func resultPreview(resultJSON []byte, n int64) string {
+	if int64(len(resultJSON)) <= n {
+		return string(resultJSON)
+	}
+
+	preview := resultJSON[:n]
+	for len(preview) > 0 && !utf8.Valid(preview) {
+		preview = preview[:len(preview)-1]
+	}
+	return string(preview)
+}
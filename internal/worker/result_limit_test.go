@@ -0,0 +1,67 @@
+package worker
+
+import (
+	"strings"
+	"testing"
+
+	"BoltQ/internal/queue"
+)
+
+func TestFinishTaskResultEncoding(t *testing.T) {
+	tests := []struct {
+		name       string
+		result     map[string]interface{}
+		wantStatus string
+		wantError  string
+	}{
+		{
+			name:       "encodable result is stored",
+			result:     map[string]interface{}{"answer": 42},
+			wantStatus: "completed",
+		},
+		{
+			name:       "channel fails the job",
+			result:     map[string]interface{}{"updates": make(chan int)},
+			wantStatus: "failed",
+			wantError:  ErrNonSerializableResult.Error(),
+		},
+		{
+			name:       "function fails the job",
+			result:     map[string]interface{}{"nested": map[string]interface{}{"fn": func() {}}},
+			wantStatus: "failed",
+			wantError:  ErrNonSerializableResult.Error(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pool, q := newTestPool(t)
+
+			if err := q.Publish(&queue.Task{ID: "task-1", Type: "test", Priority: queue.PriorityNormal}); err != nil {
+				t.Fatalf("Publish: %v", err)
+			}
+			task, err := q.Consume()
+			if err != nil {
+				t.Fatalf("Consume: %v", err)
+			}
+
+			pool.finishTask("worker-1", task, tt.result, nil, 0)
+
+			stored, err := q.GetTaskStatus(task.ID)
+			if err != nil {
+				t.Fatalf("GetTaskStatus: %v", err)
+			}
+			if stored.Status != tt.wantStatus {
+				t.Errorf("status = %s, want %s", stored.Status, tt.wantStatus)
+			}
+			if !strings.Contains(stored.LastError, tt.wantError) {
+				t.Errorf("last error = %q, want it to contain %q", stored.LastError, tt.wantError)
+			}
+
+			_, hasResult := stored.Data["result"]
+			if hasResult != (tt.wantStatus == "completed") {
+				t.Errorf("stored data = %v, result stored: %v", stored.Data, hasResult)
+			}
+		})
+	}
+}
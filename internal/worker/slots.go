@@ -0,0 +1,54 @@
+// internal/worker/slots.go
+package worker
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// workerSlots counts the tasks one worker loop is running, so that it takes
+// no more than the pool's concurrency at once
+type workerSlots struct {
+	used  atomic.Int32
+	freed chan struct{}
+}
+
+func newWorkerSlots() *workerSlots {
+	return &workerSlots{freed: make(chan struct{}, 1)}
+}
+
+// release frees the slot of a finished task and wakes the worker if it waits
+// for one
+func (s *workerSlots) release() {
+	s.used.Add(-1)
+	select {
+	case s.freed <- struct{}{}:
+	default:
+	}
+}
+
+// acquireSlot waits until the worker runs fewer tasks than the pool's current
+// concurrency and takes a slot. It reports false if ctx is done first. A
+// change of concurrency applies right away: workers waiting for a slot are
+// woken when it is raised, and take no new tasks while above it after it is
+// lowered.
+func (p *WorkerPool) acquireSlot(ctx context.Context, slots *workerSlots) bool {
+	for {
+		p.mu.RLock()
+		concurrency := p.concurrency
+		changed := p.concurrencyChanged
+		p.mu.RUnlock()
+
+		if int(slots.used.Load()) < concurrency {
+			slots.used.Add(1)
+			return true
+		}
+
+		select {
+		case <-ctx.Done():
+			return false
+		case <-slots.freed:
+		case <-changed:
+		}
+	}
+}
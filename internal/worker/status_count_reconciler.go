@@ -0,0 +1,89 @@
+// internal/worker/status_count_reconciler.go
+package worker
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"BoltQ/internal/queue"
+	"BoltQ/pkg/logger"
+)
+
+// StatusCountReconciler periodically recounts the task records by status, so
+// the status counts kept by status updates don't drift as records expire.
+// Only one instance reconciles per interval.
+type StatusCountReconciler struct {
+	queue    *queue.RedisQueue
+	logger   *logger.Logger
+	interval time.Duration
+	ticker   *time.Ticker
+	stopChan chan struct{}
+	wg       sync.WaitGroup
+}
+
+// NewStatusCountReconciler creates a reconciler for the status counts
+func NewStatusCountReconciler(queue *queue.RedisQueue, logger *logger.Logger) *StatusCountReconciler {
+	return &StatusCountReconciler{
+		queue:    queue,
+		logger:   logger,
+		stopChan: make(chan struct{}),
+	}
+}
+
+// Start reconciles the status counts right away and then at regular intervals
+func (r *StatusCountReconciler) Start(interval time.Duration) {
+	r.interval = interval
+	r.ticker = time.NewTicker(interval)
+	r.wg.Add(1)
+
+	go func() {
+		defer r.wg.Done()
+
+		r.reconcile()
+		for {
+			select {
+			case <-r.ticker.C:
+				r.reconcile()
+			case <-r.stopChan:
+				r.ticker.Stop()
+				return
+			}
+		}
+	}()
+
+	r.logger.Info(fmt.Sprintf("Status count reconciler started (interval %s)", interval))
+}
+
+// Stop gracefully stops the reconciler
+func (r *StatusCountReconciler) Stop() {
+	close(r.stopChan)
+	r.wg.Wait()
+	r.logger.Info("Status count reconciler stopped")
+}
+
+// reconcile recounts the task records unless another instance just did
+func (r *StatusCountReconciler) reconcile() {
+	// Leave a little slack so this instance's next tick can claim it again
+	claimed, err := r.queue.ClaimStatusCountsReconcile(r.interval * 9 / 10)
+	if err != nil {
+		r.logger.Error("Error claiming status count reconciliation: " + err.Error())
+		return
+	}
+	if !claimed {
+		return
+	}
+
+	start := time.Now()
+	counts, err := r.queue.ReconcileStatusCounts()
+	if err != nil {
+		r.logger.Error("Error reconciling status counts: " + err.Error())
+		return
+	}
+
+	var total int64
+	for _, count := range counts {
+		total += count
+	}
+	r.logger.Info(fmt.Sprintf("Reconciled status counts of %d tasks in %s", total, time.Since(start).Round(time.Millisecond)))
+}
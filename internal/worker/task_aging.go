@@ -0,0 +1,82 @@
+// internal/worker/task_aging.go
+package worker
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"BoltQ/internal/queue"
+	"BoltQ/pkg/logger"
+	"BoltQ/pkg/metrics"
+)
+
+// TaskAgingSweeper periodically promotes tasks that have waited too long in a
+// low priority queue, bounding the wait time of every task
+type TaskAgingSweeper struct {
+	queue          *queue.RedisQueue
+	logger         *logger.Logger
+	metrics        *metrics.MetricsCollector
+	maxAge         time.Duration
+	targetPriority int
+	ticker         *time.Ticker
+	stopChan       chan struct{}
+	wg             sync.WaitGroup
+}
+
+// NewTaskAgingSweeper creates a sweeper that moves tasks older than maxAge to
+// the targetPriority queue
+func NewTaskAgingSweeper(queue *queue.RedisQueue, logger *logger.Logger, metrics *metrics.MetricsCollector, maxAge time.Duration, targetPriority int) *TaskAgingSweeper {
+	return &TaskAgingSweeper{
+		queue:          queue,
+		logger:         logger,
+		metrics:        metrics,
+		maxAge:         maxAge,
+		targetPriority: targetPriority,
+		stopChan:       make(chan struct{}),
+	}
+}
+
+// Start begins promoting aged tasks at regular intervals
+func (s *TaskAgingSweeper) Start(interval time.Duration) {
+	s.ticker = time.NewTicker(interval)
+	s.wg.Add(1)
+
+	go func() {
+		defer s.wg.Done()
+
+		for {
+			select {
+			case <-s.ticker.C:
+				s.sweep()
+			case <-s.stopChan:
+				s.ticker.Stop()
+				return
+			}
+		}
+	}()
+
+	s.logger.Info(fmt.Sprintf("Task aging sweeper started (max age %s, target priority %s)",
+		s.maxAge, queue.PriorityName(s.targetPriority)))
+}
+
+// Stop gracefully stops the sweeper
+func (s *TaskAgingSweeper) Stop() {
+	close(s.stopChan)
+	s.wg.Wait()
+	s.logger.Info("Task aging sweeper stopped")
+}
+
+// sweep promotes the tasks that are past the age threshold
+func (s *TaskAgingSweeper) sweep() {
+	promoted, err := s.queue.PromoteAgedTasks(s.maxAge, s.targetPriority, queue.DefaultAgingBatchSize)
+
+	// Count the promotions made before any error
+	for fromPriority, count := range promoted {
+		s.metrics.RecordTaskPromotions(fromPriority, s.targetPriority, count)
+	}
+
+	if err != nil {
+		s.logger.Error("Error promoting aged tasks: " + err.Error())
+	}
+}
@@ -2,6 +2,8 @@
 package api
 
 import (
+	"time"
+
 	"BoltQ/internal/job"
 	"BoltQ/internal/queue"
 )
@@ -27,17 +29,29 @@ import (
 
 // Job submission request
 type SubmitJobRequest struct {
+	ID           string                 `json:"id,omitempty" example:"order-1234-invoice" description:"Optional caller-chosen job ID; resubmitting an existing ID returns the existing job"`
 	Type         string                 `json:"type" example:"echo" description:"Type of job to run"`
 	Data         map[string]interface{} `json:"data" example:"{\"message\":\"Hello World\"}" description:"Job parameters"`
-	Priority     int                    `json:"priority,omitempty" example:"1" description:"Job priority (0=high, 1=normal, 2=low)"`
+	Priority     interface{}            `json:"priority,omitempty" example:"high" description:"Job priority as 0-3 or low, normal, high, critical (default normal)"`
 	DelaySeconds int                    `json:"delay_seconds,omitempty" example:"60" description:"Delay execution by this many seconds"`
+	CallbackURL  string                 `json:"callback_url,omitempty" example:"https://example.com/hooks/boltq" description:"URL that receives a signed POST when the job completes or fails"`
+	DependsOn    string                 `json:"depends_on_job_id,omitempty" example:"order-1234-export" description:"ID of a job that must complete before this job runs"`
+	Deadline     string                 `json:"deadline,omitempty" example:"2025-01-01T12:00:00Z" description:"RFC 3339 time after which the job is no longer run"`
+	Tags         []string               `json:"tags,omitempty" example:"gpu" description:"Tags routing the job to worker pools; the first tag selects the queue"`
+}
+
+// Job update request
+type UpdateJobRequest struct {
+	Priority interface{} `json:"priority" example:"high" description:"New priority as 0-3 or low, normal, high, critical"`
 }
 
 // Job submission response
 type SubmitJobResponse struct {
 	Success bool `json:"success" example:"true"`
 	Data    struct {
-		JobID string `json:"job_id" example:"f47ac10b-58cc-4372-a567-0e02b2c3d479"`
+		JobID      string `json:"job_id" example:"f47ac10b-58cc-4372-a567-0e02b2c3d479"`
+		StatusURL  string `json:"status_url" example:"/api/v1/jobs/f47ac10b-58cc-4372-a567-0e02b2c3d479"`
+		UpdatesURL string `json:"updates_url" example:"/ws/jobs?job_id=f47ac10b-58cc-4372-a567-0e02b2c3d479"`
 	} `json:"data"`
 }
 
@@ -47,15 +61,31 @@ type JobStatusResponse struct {
 	Data    queue.Task `json:"data"`
 }
 
+// Job history response
+type JobHistoryResponse struct {
+	Success bool               `json:"success" example:"true"`
+	Data    []queue.AuditEvent `json:"data"`
+}
+
 // Queue stats response
 type QueueStatsResponse struct {
 	Success bool `json:"success" example:"true"`
 	Data    struct {
-		TaskQueueHigh   int64 `json:"task_queue:0" example:"5"`
-		TaskQueueNormal int64 `json:"task_queue:1" example:"10"`
-		TaskQueueLow    int64 `json:"task_queue:2" example:"3"`
-		DelayedTasks    int64 `json:"delayed_tasks" example:"7"`
-		DeadLetterQueue int64 `json:"dead_letter_queue" example:"2"`
+		TotalPending int64            `json:"total_pending" example:"19"`
+		ByPriority   map[string]int64 `json:"by_priority" example:"critical:1,high:5,normal:10,low:3"`
+		Delayed      int64            `json:"delayed" example:"7"`
+		DeadLetter   int64            `json:"dead_letter" example:"2"`
+		Total        int64            `json:"total" example:"28"`
+		Queues       struct {
+			TaskQueueCritical int64 `json:"task_queue:3" example:"1"`
+			TaskQueueHigh     int64 `json:"task_queue:2" example:"5"`
+			TaskQueueNormal   int64 `json:"task_queue:1" example:"10"`
+			TaskQueueLow      int64 `json:"task_queue:0" example:"3"`
+			DelayedTasks      int64 `json:"delayed_tasks" example:"7"`
+			DeadLetterQueue   int64 `json:"dead_letter_queue" example:"2"`
+			PoisonTasks       int64 `json:"poison_tasks" example:"0"`
+		} `json:"queues" description:"Raw counts keyed by queue name"`
+		SuppressedDuplicates int64 `json:"suppressed_duplicates" example:"4"`
 	} `json:"data"`
 }
 
@@ -74,6 +104,15 @@ type CreateWorkflowResponse struct {
 	} `json:"data"`
 }
 
+// Workflow validation response
+type ValidateWorkflowResponse struct {
+	Success bool `json:"success" example:"true"`
+	Data    struct {
+		Valid  bool                  `json:"valid" example:"false"`
+		Errors []job.ValidationError `json:"errors"`
+	} `json:"data"`
+}
+
 // Workflow status response
 type WorkflowStatusResponse struct {
 	Success bool         `json:"success" example:"true"`
@@ -100,6 +139,13 @@ type ErrorResponse struct {
 	Error   string `json:"error" example:"Job not found"`
 }
 
+// Validation error response
+type ValidationErrorResponse struct {
+	Success bool                  `json:"success" example:"false"`
+	Error   string                `json:"error" example:"Job type is required"`
+	Errors  []job.ValidationError `json:"errors"`
+}
+
 // Health check response
 type HealthResponse struct {
 	Success bool `json:"success" example:"true"`
@@ -109,6 +155,21 @@ type HealthResponse struct {
 	} `json:"data"`
 }
 
+// Detailed health check response
+type DetailedHealthResponse struct {
+	Success bool `json:"success" example:"true"`
+	Data    struct {
+		Status                  string    `json:"status" example:"healthy"`
+		Redis                   string    `json:"redis" example:"ok"`
+		LiveWorkers             int64     `json:"live_workers" example:"4"`
+		DelayedProcessorHealthy bool      `json:"delayed_processor_healthy" example:"true"`
+		DelayedProcessorLastRun time.Time `json:"delayed_processor_last_run,omitempty"`
+		Backlog                 int64     `json:"backlog" example:"12"`
+		OldestTaskAgeSeconds    float64   `json:"oldest_task_age_seconds" example:"3.5"`
+	} `json:"data"`
+	Error string `json:"error,omitempty"`
+}
+
 // SwaggerInfo holds exported Swagger Info so clients can modify it
 type SwaggerInfo struct {
 	Version     string
@@ -121,7 +182,9 @@ type SwaggerInfo struct {
 
 // WorkflowStepInput represents input for a workflow step
 type WorkflowStepInput struct {
+	ID        string                 `json:"id,omitempty" example:"step-1"`
 	JobType   string                 `json:"job_type" example:"process_data"`
 	Params    map[string]interface{} `json:"params" example:"{\"input_file\":\"data.csv\"}"`
 	DependsOn []string               `json:"depends_on,omitempty" example:"[\"step-1\",\"step-2\"]"`
+	Condition string                 `json:"condition,omitempty" example:"step-1.anomalies > 0"`
 }
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"strconv"
 	"time"
@@ -13,6 +14,17 @@ func GetEnv(key, fallback string) string {
 	return fallback
 }
 
+// GetInstanceName returns the name identifying this process in logs and
+// metrics, taken from INSTANCE_NAME (e.g. a pod name) and defaulting to the
+// hostname and process ID
+func GetInstanceName() string {
+	if name := os.Getenv("INSTANCE_NAME"); name != "" {
+		return name
+	}
+	hostname, _ := os.Hostname()
+	return fmt.Sprintf("%s-%d", hostname, os.Getpid())
+}
+
 func GetEnvAsInt(key string, defaultValue int) int {
 	valueStr := os.Getenv(key)
 	if valueStr == "" {
@@ -25,6 +37,18 @@ func GetEnvAsInt(key string, defaultValue int) int {
 	return value
 }
 
+func GetEnvAsFloat(key string, defaultValue float64) float64 {
+	valueStr := os.Getenv(key)
+	if valueStr == "" {
+		return defaultValue
+	}
+	value, err := strconv.ParseFloat(valueStr, 64)
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
 func GetEnvAsDuration(name string, defaultValue time.Duration) time.Duration {
 	valueStr := os.Getenv(name)
 	if valueStr == "" {
@@ -36,3 +60,29 @@ func GetEnvAsDuration(name string, defaultValue time.Duration) time.Duration {
 	}
 	return value
 }
+
+func GetEnvAsBool(key string, defaultValue bool) bool {
+	valueStr := os.Getenv(key)
+	if valueStr == "" {
+		return defaultValue
+	}
+	value, err := strconv.ParseBool(valueStr)
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+// GetRedisConfig returns the Redis connection settings from the environment
+// in the config map format understood by queue.NewRedisClient
+func GetRedisConfig() map[string]string {
+	return map[string]string{
+		"mode":           GetEnv("REDIS_MODE", "single"),
+		"addr":           GetEnv("REDIS_ADDR", "localhost:6379"),
+		"master_name":    GetEnv("REDIS_MASTER_NAME", ""),
+		"sentinel_addrs": GetEnv("REDIS_SENTINEL_ADDRS", ""),
+		"cluster_addrs":  GetEnv("REDIS_CLUSTER_ADDRS", ""),
+		"password":       GetEnv("REDIS_PASSWORD", ""),
+		"db":             GetEnv("REDIS_DB", "0"),
+	}
+}
@@ -0,0 +1,477 @@
+// pkg/config/load.go
+package config
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"BoltQ/pkg/payload"
+)
+
+// Config holds the settings of a BoltQ service, read from the environment and
+// validated once at startup by Load. Only the settings of the named service
+// are read; the others keep their zero value. An empty list or a zero limit
+// leaves the feature disabled.
+//
+// Settings whose values are defined by other packages, such as serializer
+// names and rate limits, are kept as written and parsed by the service that
+// uses them.
+type Config struct {
+	Service      string
+	InstanceName string
+
+	// Logging and tracing
+	LogSampleRate   int
+	LogSampleWindow time.Duration
+	TracingEnabled  bool
+
+	// Servers
+	APIPort              string
+	MetricsPort          string
+	MetricsShutdownDelay time.Duration
+
+	// Redis and task storage
+	Redis               map[string]string
+	TaskSerializer      string
+	AuditLogEnabled     bool
+	MaxPayloadSize      int64
+	MaxQueueLength      int64
+	QueueOverflowPolicy string
+	LIFOQueues          []string
+	TaskTTL             time.Duration
+	TerminalTaskTTL     time.Duration
+
+	// Retries, dead letters and callbacks of failed jobs
+	RetryBaseDelay       time.Duration
+	RetryMultiplier      float64
+	RetryMinDelay        time.Duration
+	RetryMaxDelay        time.Duration
+	MaxRetryDuration     time.Duration
+	DeadLetterRedactKeys []string
+	CallbackSecret       string
+	CallbackMaxAttempts  int
+
+	// Workflows
+	WorkflowArchiveRetention time.Duration
+	WorkflowArchiveMax       int
+
+	// API service
+	APIKeys                   string
+	AllowedJobTypes           []string
+	RequireRegisteredJobTypes bool
+	SubmitRateLimit           string
+	ClientRateLimits          string
+	CORSOrigins               string
+	WebSocketBatchWindow      time.Duration
+	SubmitBufferDir           string
+	SubmitBufferMaxTasks      int
+	SubmitBufferFlushInterval time.Duration
+	JobLeaseTimeout           time.Duration
+	ReadOnly                  bool
+
+	// Worker pool
+	NumWorkers              int
+	WorkerConcurrency       int
+	MaxInFlight             int
+	PollingInterval         time.Duration
+	MaxPollingInterval      time.Duration
+	BlockingConsumeTimeout  time.Duration
+	DependencyPollInterval  time.Duration
+	DependencyMaxWait       time.Duration
+	WorkerPriorities        []int
+	WorkerTags              []string
+	ConsumeStrategy         string
+	MaxResultSize           int64
+	ResultOverflowPolicy    string
+	ProcessorTimeouts       map[string]time.Duration
+	PayloadRefMaxSize       int64
+	DefaultProcessorEnabled bool
+	CircuitBreakerThreshold int
+	CircuitBreakerWindow    time.Duration
+	CircuitBreakerCooldown  time.Duration
+	DeadLetterWebhookURL    string
+
+	// Background processors
+	DelayedProcessorEnabled        bool
+	DelayedProcessorInterval       time.Duration
+	DelayedProcessorBatchSize      int
+	DelayedProcessorLeaderElection bool
+	DelayedProcessorLeaderTTL      time.Duration
+	QueueSampleInterval            time.Duration
+	DeadLetterRetention            time.Duration
+	DeadLetterSweepInterval        time.Duration
+	StatusCountsReconcileInterval  time.Duration
+	TaskAgingThreshold             time.Duration
+	TaskAgingInterval              time.Duration
+	TaskAgingTargetPriority        int
+}
+
+// defaultMetricsPorts keeps the services' metrics servers apart when they run
+// on one host
+var defaultMetricsPorts = map[string]string{
+	"api":       "9093",
+	"worker":    "9094",
+	"scheduler": "9095",
+}
+
+// Load reads the configuration of a service ("api", "worker", "scheduler" or
+// "boltqctl") from the environment. Unlike the GetEnv helpers, which fall back
+// to the default, it reports every setting with an invalid value in one error.
+// Settings of other services are neither read nor checked, so a bad worker
+// setting doesn't keep the API from starting.
+func Load(service string) (*Config, error) {
+	l := &loader{}
+	cfg := &Config{
+		Service:      service,
+		InstanceName: GetInstanceName(),
+	}
+
+	cfg.loadCommon(l)
+	switch service {
+	case "api":
+		cfg.loadAPI(l)
+		cfg.loadFailures(l)
+		cfg.loadWorkflows(l)
+	case "worker":
+		cfg.loadWorkerPool(l)
+		cfg.loadFailures(l)
+		cfg.loadWorkflows(l)
+		cfg.loadBackground(l)
+	case "scheduler":
+		cfg.loadBackground(l)
+	case "boltqctl":
+		// Only the Redis and task storage settings
+	default:
+		return nil, fmt.Errorf("unknown service %q", service)
+	}
+
+	if err := l.problems.Err(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// loadCommon reads the logging, metrics, Redis and task storage settings
+// shared by every service
+func (c *Config) loadCommon(l *loader) {
+	c.LogSampleRate = l.int("LOG_SAMPLE_RATE", 1)
+	c.LogSampleWindow = l.duration("LOG_SAMPLE_WINDOW", time.Second)
+	c.TracingEnabled = l.bool("TRACING_ENABLED", false)
+
+	c.MetricsPort = GetEnv("METRICS_PORT", defaultMetricsPorts[c.Service])
+	c.MetricsShutdownDelay = l.duration("METRICS_SHUTDOWN_DELAY", 0)
+
+	c.Redis = GetRedisConfig()
+	c.TaskSerializer = GetEnv("TASK_SERIALIZER", "json")
+	c.AuditLogEnabled = l.bool("AUDIT_LOG_ENABLED", false)
+	c.MaxPayloadSize = l.int64("MAX_PAYLOAD_SIZE", 1<<20)
+	c.MaxQueueLength = l.int64("MAX_QUEUE_LENGTH", 0)
+	c.QueueOverflowPolicy = GetEnv("QUEUE_OVERFLOW_POLICY", "reject")
+	c.LIFOQueues = List(GetEnv("LIFO_QUEUES", ""))
+	c.TaskTTL = l.duration("TASK_TTL", 24*time.Hour)
+	c.TerminalTaskTTL = l.duration("TASK_TERMINAL_TTL", 0)
+
+	l.check(c.LogSampleRate >= 1, "LOG_SAMPLE_RATE", "must be at least 1")
+	l.positive("LOG_SAMPLE_WINDOW", c.LogSampleWindow)
+	if c.Service != "boltqctl" {
+		l.check(validPort(c.MetricsPort), "METRICS_PORT", "must be a port number")
+	}
+	l.nonNegative("METRICS_SHUTDOWN_DELAY", c.MetricsShutdownDelay)
+	if _, err := strconv.Atoi(c.Redis["db"]); err != nil {
+		l.check(false, "REDIS_DB", "must be an integer")
+	}
+	l.check(c.MaxPayloadSize >= 0, "MAX_PAYLOAD_SIZE", "must not be negative")
+	l.check(c.MaxQueueLength >= 0, "MAX_QUEUE_LENGTH", "must not be negative")
+	l.positive("TASK_TTL", c.TaskTTL)
+	l.nonNegative("TASK_TERMINAL_TTL", c.TerminalTaskTTL)
+}
+
+// loadAPI reads the settings of the API service
+func (c *Config) loadAPI(l *loader) {
+	c.APIPort = GetEnv("API_PORT", "8080")
+	c.APIKeys = GetEnv("API_KEYS", "")
+	c.AllowedJobTypes = List(GetEnv("ALLOWED_JOB_TYPES", ""))
+	c.RequireRegisteredJobTypes = l.bool("REQUIRE_REGISTERED_JOB_TYPES", false)
+	c.SubmitRateLimit = GetEnv("SUBMIT_RATE_LIMIT", "0")
+	c.ClientRateLimits = GetEnv("SUBMIT_RATE_LIMITS", "")
+	c.CORSOrigins = GetEnv("CORS_ALLOWED_ORIGINS", "http://localhost:5173")
+	c.WebSocketBatchWindow = l.duration("WEBSOCKET_BATCH_WINDOW", 100*time.Millisecond)
+	c.SubmitBufferDir = GetEnv("SUBMIT_BUFFER_DIR", "")
+	c.SubmitBufferMaxTasks = l.int("SUBMIT_BUFFER_MAX_TASKS", 10000)
+	c.SubmitBufferFlushInterval = l.duration("SUBMIT_BUFFER_FLUSH_INTERVAL", 5*time.Second)
+	c.JobLeaseTimeout = l.duration("JOB_LEASE_TIMEOUT", 0)
+	c.ReadOnly = l.bool("READ_ONLY", false)
+
+	l.check(validPort(c.APIPort), "API_PORT", "must be a port number")
+	l.nonNegative("WEBSOCKET_BATCH_WINDOW", c.WebSocketBatchWindow)
+	l.check(c.SubmitBufferMaxTasks >= 1, "SUBMIT_BUFFER_MAX_TASKS", "must be at least 1")
+	l.positive("SUBMIT_BUFFER_FLUSH_INTERVAL", c.SubmitBufferFlushInterval)
+	l.nonNegative("JOB_LEASE_TIMEOUT", c.JobLeaseTimeout)
+}
+
+// loadFailures reads how failed jobs are retried, dead-lettered and reported,
+// which both workers and the API's HTTP consumers do
+func (c *Config) loadFailures(l *loader) {
+	c.RetryBaseDelay = l.duration("RETRY_BASE_DELAY", 2*time.Second)
+	c.RetryMultiplier = l.float("RETRY_MULTIPLIER", 2)
+	c.RetryMinDelay = l.duration("RETRY_MIN_DELAY", 0)
+	c.RetryMaxDelay = l.duration("RETRY_MAX_DELAY", 5*time.Minute)
+	c.MaxRetryDuration = l.duration("MAX_RETRY_DURATION", 0)
+	c.DeadLetterRedactKeys = List(GetEnv("DEAD_LETTER_REDACT_KEYS", ""))
+	c.CallbackSecret = GetEnv("CALLBACK_SECRET", "")
+	c.CallbackMaxAttempts = l.int("CALLBACK_MAX_ATTEMPTS", 5)
+
+	l.nonNegative("MAX_RETRY_DURATION", c.MaxRetryDuration)
+	l.check(c.CallbackMaxAttempts >= 1, "CALLBACK_MAX_ATTEMPTS", "must be at least 1")
+}
+
+// loadWorkflows reads the workflow archive settings
+func (c *Config) loadWorkflows(l *loader) {
+	c.WorkflowArchiveRetention = l.duration("WORKFLOW_ARCHIVE_RETENTION", 0)
+	c.WorkflowArchiveMax = l.int("WORKFLOW_ARCHIVE_MAX", 10000)
+
+	l.nonNegative("WORKFLOW_ARCHIVE_RETENTION", c.WorkflowArchiveRetention)
+	l.check(c.WorkflowArchiveMax >= 0, "WORKFLOW_ARCHIVE_MAX", "must not be negative")
+}
+
+// loadWorkerPool reads the settings of the worker pool
+func (c *Config) loadWorkerPool(l *loader) {
+	c.NumWorkers = l.int("NUM_WORKERS", 4)
+	c.WorkerConcurrency = l.int("WORKER_CONCURRENCY", 1)
+	c.MaxInFlight = l.int("MAX_IN_FLIGHT", 0)
+	c.PollingInterval = l.duration("POLLING_INTERVAL", 100*time.Millisecond)
+	c.MaxPollingInterval = l.duration("MAX_POLLING_INTERVAL", 2*time.Second)
+	c.BlockingConsumeTimeout = l.duration("BLOCKING_CONSUME_TIMEOUT", 0)
+	c.DependencyPollInterval = l.duration("DEPENDENCY_POLL_INTERVAL", 5*time.Second)
+	c.DependencyMaxWait = l.duration("DEPENDENCY_MAX_WAIT", time.Hour)
+	c.WorkerTags = List(GetEnv("WORKER_TAGS", ""))
+	c.ConsumeStrategy = GetEnv("WORKER_CONSUME_STRATEGY", "strict")
+	c.MaxResultSize = l.int64("MAX_RESULT_SIZE", 0)
+	c.ResultOverflowPolicy = GetEnv("RESULT_OVERFLOW_POLICY", "reject")
+	c.PayloadRefMaxSize = l.int64("PAYLOAD_REF_MAX_SIZE", payload.DefaultMaxSize)
+	c.DefaultProcessorEnabled = l.bool("DEFAULT_PROCESSOR_ENABLED", false)
+	c.CircuitBreakerThreshold = l.int("CIRCUIT_BREAKER_THRESHOLD", 0)
+	c.CircuitBreakerWindow = l.duration("CIRCUIT_BREAKER_WINDOW", time.Minute)
+	c.CircuitBreakerCooldown = l.duration("CIRCUIT_BREAKER_COOLDOWN", 30*time.Second)
+	c.DeadLetterWebhookURL = GetEnv("DEAD_LETTER_WEBHOOK_URL", "")
+
+	var err error
+	if c.WorkerPriorities, err = ParsePriorities(GetEnv("WORKER_PRIORITIES", "")); err != nil {
+		l.invalid("WORKER_PRIORITIES", err)
+	}
+	if c.ProcessorTimeouts, err = ParseProcessorTimeouts(GetEnv("PROCESSOR_TIMEOUTS", "")); err != nil {
+		l.invalid("PROCESSOR_TIMEOUTS", err)
+	}
+
+	l.check(c.NumWorkers >= 1, "NUM_WORKERS", "must be at least 1")
+	l.check(c.WorkerConcurrency >= 1, "WORKER_CONCURRENCY", "must be at least 1")
+	l.check(c.MaxInFlight >= 0, "MAX_IN_FLIGHT", "must not be negative")
+	l.positive("POLLING_INTERVAL", c.PollingInterval)
+	l.positive("MAX_POLLING_INTERVAL", c.MaxPollingInterval)
+	l.check(c.BlockingConsumeTimeout == 0 || c.BlockingConsumeTimeout >= time.Second, "BLOCKING_CONSUME_TIMEOUT",
+		"must be 0 or at least 1s")
+	l.positive("DEPENDENCY_POLL_INTERVAL", c.DependencyPollInterval)
+	l.nonNegative("DEPENDENCY_MAX_WAIT", c.DependencyMaxWait)
+	l.check(c.MaxResultSize >= 0, "MAX_RESULT_SIZE", "must not be negative")
+	l.check(c.PayloadRefMaxSize >= 0, "PAYLOAD_REF_MAX_SIZE", "must not be negative")
+	l.check(c.CircuitBreakerThreshold >= 0, "CIRCUIT_BREAKER_THRESHOLD", "must not be negative")
+	l.positive("CIRCUIT_BREAKER_WINDOW", c.CircuitBreakerWindow)
+	l.positive("CIRCUIT_BREAKER_COOLDOWN", c.CircuitBreakerCooldown)
+}
+
+// loadBackground reads the settings of the processors that workers and the
+// scheduler run next to the worker pool
+func (c *Config) loadBackground(l *loader) {
+	c.DelayedProcessorEnabled = l.bool("DELAYED_PROCESSOR_ENABLED", true)
+	c.DelayedProcessorInterval = l.duration("DELAYED_PROCESSOR_INTERVAL", 5*time.Second)
+	c.DelayedProcessorBatchSize = l.int("DELAYED_PROCESSOR_BATCH_SIZE", 1000)
+	// Schedulers are meant to run as several replicas, so leader election
+	// is on by default there
+	c.DelayedProcessorLeaderElection = l.bool("DELAYED_PROCESSOR_LEADER_ELECTION", c.Service == "scheduler")
+	c.DelayedProcessorLeaderTTL = l.duration("DELAYED_PROCESSOR_LEADER_TTL", 15*time.Second)
+	c.QueueSampleInterval = l.duration("QUEUE_SAMPLE_INTERVAL", 15*time.Second)
+	c.DeadLetterRetention = l.duration("DEAD_LETTER_RETENTION", 7*24*time.Hour)
+	c.DeadLetterSweepInterval = l.duration("DEAD_LETTER_SWEEP_INTERVAL", 10*time.Minute)
+	c.StatusCountsReconcileInterval = l.duration("STATUS_COUNTS_RECONCILE_INTERVAL", 10*time.Minute)
+	c.TaskAgingThreshold = l.duration("TASK_AGING_THRESHOLD", 0)
+	c.TaskAgingInterval = l.duration("TASK_AGING_INTERVAL", 10*time.Second)
+	c.TaskAgingTargetPriority = l.int("TASK_AGING_TARGET_PRIORITY", 2)
+
+	l.positive("DELAYED_PROCESSOR_INTERVAL", c.DelayedProcessorInterval)
+	l.check(c.DelayedProcessorBatchSize >= 1, "DELAYED_PROCESSOR_BATCH_SIZE", "must be at least 1")
+	l.positive("DELAYED_PROCESSOR_LEADER_TTL", c.DelayedProcessorLeaderTTL)
+	l.positive("QUEUE_SAMPLE_INTERVAL", c.QueueSampleInterval)
+	l.nonNegative("DEAD_LETTER_RETENTION", c.DeadLetterRetention)
+	l.positive("DEAD_LETTER_SWEEP_INTERVAL", c.DeadLetterSweepInterval)
+	l.nonNegative("STATUS_COUNTS_RECONCILE_INTERVAL", c.StatusCountsReconcileInterval)
+	l.nonNegative("TASK_AGING_THRESHOLD", c.TaskAgingThreshold)
+	l.positive("TASK_AGING_INTERVAL", c.TaskAgingInterval)
+}
+
+// validPort reports whether a value is a TCP port number
+func validPort(value string) bool {
+	port, err := strconv.Atoi(value)
+	return err == nil && port > 0 && port <= 65535
+}
+
+// List splits a comma-separated list, dropping blank entries
+func List(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// ParsePriorities parses a comma-separated list of priority levels
+func ParsePriorities(value string) ([]int, error) {
+	var priorities []int
+	for _, part := range List(value) {
+		priority, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, err
+		}
+		priorities = append(priorities, priority)
+	}
+	return priorities, nil
+}
+
+// ParseProcessorTimeouts parses a comma-separated list of type=duration pairs
+func ParseProcessorTimeouts(value string) (map[string]time.Duration, error) {
+	timeouts := make(map[string]time.Duration)
+	for _, part := range List(value) {
+		jobType, timeoutStr, ok := strings.Cut(part, "=")
+		if !ok || strings.TrimSpace(jobType) == "" {
+			return nil, fmt.Errorf("expected type=duration, got %q", part)
+		}
+		timeout, err := time.ParseDuration(strings.TrimSpace(timeoutStr))
+		if err != nil || timeout <= 0 {
+			return nil, fmt.Errorf("invalid timeout for %s: %q", jobType, timeoutStr)
+		}
+		timeouts[strings.TrimSpace(jobType)] = timeout
+	}
+	return timeouts, nil
+}
+
+// Problems collects invalid settings so that they can be reported together
+type Problems []string
+
+// Add records a setting with an invalid value
+func (p *Problems) Add(key string, err error) {
+	*p = append(*p, fmt.Sprintf("%s: %v", key, err))
+}
+
+// Err returns an error listing every problem, or nil if there are none
+func (p Problems) Err() error {
+	if len(p) == 0 {
+		return nil
+	}
+	sorted := append([]string(nil), p...)
+	sort.Strings(sorted)
+	return fmt.Errorf("invalid configuration: %s", strings.Join(sorted, "; "))
+}
+
+// loader reads typed environment variables, collecting a problem for each
+// value that doesn't parse instead of falling back to the default
+type loader struct {
+	problems Problems
+}
+
+// invalid records a setting with an invalid value
+func (l *loader) invalid(key string, err error) {
+	l.problems.Add(key, err)
+}
+
+// check records a problem with a setting unless ok
+func (l *loader) check(ok bool, key, problem string) {
+	if !ok {
+		l.problems = append(l.problems, fmt.Sprintf("%s %s", key, problem))
+	}
+}
+
+// positive checks an interval that drives a ticker or timer
+func (l *loader) positive(key string, value time.Duration) {
+	l.check(value > 0, key, "must be positive")
+}
+
+// nonNegative checks a duration where 0 disables the feature
+func (l *loader) nonNegative(key string, value time.Duration) {
+	l.check(value >= 0, key, "must not be negative")
+}
+
+// lookup returns the value of a set, non-empty environment variable
+func (l *loader) lookup(key string) (string, bool) {
+	value := strings.TrimSpace(os.Getenv(key))
+	return value, value != ""
+}
+
+func (l *loader) int(key string, defaultValue int) int {
+	valueStr, ok := l.lookup(key)
+	if !ok {
+		return defaultValue
+	}
+	value, err := strconv.Atoi(valueStr)
+	if err != nil {
+		l.invalid(key, fmt.Errorf("%q is not an integer", valueStr))
+		return defaultValue
+	}
+	return value
+}
+
+func (l *loader) int64(key string, defaultValue int64) int64 {
+	valueStr, ok := l.lookup(key)
+	if !ok {
+		return defaultValue
+	}
+	value, err := strconv.ParseInt(valueStr, 10, 64)
+	if err != nil {
+		l.invalid(key, fmt.Errorf("%q is not an integer", valueStr))
+		return defaultValue
+	}
+	return value
+}
+
+func (l *loader) float(key string, defaultValue float64) float64 {
+	valueStr, ok := l.lookup(key)
+	if !ok {
+		return defaultValue
+	}
+	value, err := strconv.ParseFloat(valueStr, 64)
+	if err != nil {
+		l.invalid(key, fmt.Errorf("%q is not a number", valueStr))
+		return defaultValue
+	}
+	return value
+}
+
+func (l *loader) duration(key string, defaultValue time.Duration) time.Duration {
+	valueStr, ok := l.lookup(key)
+	if !ok {
+		return defaultValue
+	}
+	value, err := time.ParseDuration(valueStr)
+	if err != nil {
+		l.invalid(key, fmt.Errorf("%q is not a duration such as 30s or 5m", valueStr))
+		return defaultValue
+	}
+	return value
+}
+
+func (l *loader) bool(key string, defaultValue bool) bool {
+	valueStr, ok := l.lookup(key)
+	if !ok {
+		return defaultValue
+	}
+	value, err := strconv.ParseBool(valueStr)
+	if err != nil {
+		l.invalid(key, fmt.Errorf("%q is not true or false", valueStr))
+		return defaultValue
+	}
+	return value
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"sync"
 	"time"
 )
 
@@ -22,13 +23,45 @@ type LogEntry struct {
 	Level     string                 `json:"level"`
 	Message   string                 `json:"message"`
 	Component string                 `json:"component"`
+	Instance  string                 `json:"instance,omitempty"`
 	JobID     string                 `json:"job_id,omitempty"`
 	Data      map[string]interface{} `json:"data,omitempty"`
 }
 
+// SampleKeyField is the data field naming the sampling key of a message.
+// Messages without it are sampled by their text. The field is not logged.
+const SampleKeyField = "sample_key"
+
 // Logger represents a structured logger
 type Logger struct {
 	component string
+	instance  string
+	sampler   *sampler
+}
+
+// sampler keeps 1 in every n messages per key within each window
+type sampler struct {
+	n           int
+	window      time.Duration
+	windowStart time.Time
+	counts      map[string]int
+	mu          sync.Mutex
+}
+
+// allow reports whether a message with the given key should be emitted
+func (s *sampler) allow(key string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(s.windowStart) >= s.window {
+		s.windowStart = now
+		s.counts = make(map[string]int)
+	}
+
+	count := s.counts[key]
+	s.counts[key] = count + 1
+	return count%s.n == 0
 }
 
 // NewLogger creates a new logger for a specific component
@@ -38,13 +71,78 @@ func NewLogger(component string) *Logger {
 	}
 }
 
+// WithSampling returns a logger for the same component that emits only the
+// first of every n Info and Debug messages with the same key within each
+// window; counts reset when a window ends. Errors and warnings are always
+// logged. The key is taken from the "sample_key" data field, or the message
+// text if it is absent. An n of 1 or less disables sampling.
+func (l *Logger) WithSampling(n int, window time.Duration) *Logger {
+	if n <= 1 {
+		return &Logger{component: l.component, instance: l.instance}
+	}
+
+	return &Logger{
+		component: l.component,
+		instance:  l.instance,
+		sampler: &sampler{
+			n:      n,
+			window: window,
+			counts: make(map[string]int),
+		},
+	}
+}
+
+// WithInstance returns a logger for the same component that tags every entry
+// with the name of the process instance, such as a hostname or pod name, so
+// that logs from several replicas can be told apart
+func (l *Logger) WithInstance(instance string) *Logger {
+	return &Logger{
+		component: l.component,
+		instance:  instance,
+		sampler:   l.sampler,
+	}
+}
+
+// sampled reports whether a message should be dropped by sampling, and
+// strips the sampling key from its data
+func (l *Logger) sampled(level Level, msg string, data map[string]interface{}) (bool, map[string]interface{}) {
+	key, hasKey := data[SampleKeyField].(string)
+	if hasKey {
+		trimmed := make(map[string]interface{}, len(data))
+		for k, v := range data {
+			if k != SampleKeyField {
+				trimmed[k] = v
+			}
+		}
+		if len(trimmed) == 0 {
+			trimmed = nil
+		}
+		data = trimmed
+	}
+
+	if l.sampler == nil || (level != InfoLevel && level != DebugLevel) {
+		return false, data
+	}
+
+	if !hasKey {
+		key = msg
+	}
+	return !l.sampler.allow(key), data
+}
+
 // log writes a log entry to stdout
 func (l *Logger) log(level Level, msg string, jobID string, data map[string]interface{}) {
+	dropped, data := l.sampled(level, msg, data)
+	if dropped {
+		return
+	}
+
 	entry := LogEntry{
 		Timestamp: time.Now().UTC().Format(time.RFC3339),
 		Level:     string(level),
 		Message:   msg,
 		Component: l.component,
+		Instance:  l.instance,
 		JobID:     jobID,
 		Data:      data,
 	}
@@ -3,21 +3,44 @@ package metrics
 
 import (
 	"fmt"
+	"sync"
 	"sync/atomic"
 )
 
 // MetricsCollector handles Prometheus metrics collection
 // This is a wrapper around the global Prometheus metrics defined in prometheus.go
+// It also keeps in-process aggregates so they can be read back without Prometheus
 type MetricsCollector struct {
 	namespace          string
 	activeWorkersCount int32 // atomic counter
+	jobCounts          map[string]int64
+	processingTimes    map[string]*timingAggregate
+	mu                 sync.RWMutex
+}
+
+// timingAggregate accumulates processing times for a job type
+type timingAggregate struct {
+	count int64
+	total float64
+}
+
+// Summary is a snapshot of the aggregates kept by a MetricsCollector
+type Summary struct {
+	Submitted            int64              `json:"submitted"`
+	Processed            int64              `json:"processed"`
+	Completed            int64              `json:"completed"`
+	Failed               int64              `json:"failed"`
+	ActiveWorkers        int                `json:"active_workers"`
+	AvgProcessingSeconds map[string]float64 `json:"avg_processing_seconds"`
 }
 
 // NewMetricsCollector creates a new metrics collector
 func NewMetricsCollector(namespace string) *MetricsCollector {
 	// Create a metrics collector that uses the global metrics
 	mc := &MetricsCollector{
-		namespace: namespace,
+		namespace:       namespace,
+		jobCounts:       make(map[string]int64),
+		processingTimes: make(map[string]*timingAggregate),
 	}
 
 	// Set initial values for relevant gauges
@@ -30,11 +53,48 @@ func NewMetricsCollector(namespace string) *MetricsCollector {
 // IncrementJobCounter increments the job counter for a status
 func (mc *MetricsCollector) IncrementJobCounter(status string) {
 	JobsProcessed.WithLabelValues("all", status).Inc()
+
+	mc.mu.Lock()
+	mc.jobCounts[status]++
+	mc.mu.Unlock()
 }
 
 // RecordJobProcessingTime records the time taken to process a job
 func (mc *MetricsCollector) RecordJobProcessingTime(jobType string, seconds float64) {
 	JobProcessingTime.WithLabelValues(jobType).Observe(seconds)
+
+	mc.mu.Lock()
+	agg, exists := mc.processingTimes[jobType]
+	if !exists {
+		agg = &timingAggregate{}
+		mc.processingTimes[jobType] = agg
+	}
+	agg.count++
+	agg.total += seconds
+	mc.mu.Unlock()
+}
+
+// RecordConsume records a queue poll and how long it took. The result is
+// "task" when a task was consumed, "empty" when every queue was empty or "error".
+func (mc *MetricsCollector) RecordConsume(result string, seconds float64) {
+	ConsumePolls.WithLabelValues(result).Inc()
+	ConsumeDuration.WithLabelValues(result).Observe(seconds)
+}
+
+// RecordConsumeError records a queue poll that failed with an error rather
+// than finding the queues empty
+func (mc *MetricsCollector) RecordConsumeError() {
+	ConsumeErrors.Inc()
+}
+
+// RecordJobLatency records the time from a job's submission to its completion
+func (mc *MetricsCollector) RecordJobLatency(jobType string, seconds float64) {
+	JobLatency.WithLabelValues(jobType).Observe(seconds)
+}
+
+// RecordQueueWaitTime records how long a job waited in the queue before being consumed
+func (mc *MetricsCollector) RecordQueueWaitTime(jobType string, priority int, seconds float64) {
+	QueueWaitTime.WithLabelValues(jobType, fmt.Sprintf("%d", priority)).Observe(seconds)
 }
 
 // SetQueueDepth sets the queue depth for a queue
@@ -42,18 +102,40 @@ func (mc *MetricsCollector) SetQueueDepth(queue string, depth float64) {
 	JobsInQueue.WithLabelValues(queue, "all").Set(depth)
 }
 
+// SetDeadLetterQueueSize records the number of tasks in the dead letter queue
+func (mc *MetricsCollector) SetDeadLetterQueueSize(size float64) {
+	DeadLetterQueueSize.Set(size)
+}
+
 // IncrementErrorCounter increments the error counter for a type
 func (mc *MetricsCollector) IncrementErrorCounter(errorType string) {
 	// Use Redis operation metrics for errors
 	RedisOperations.WithLabelValues("error", errorType).Inc()
 }
 
-// IncrementActiveWorkers increments or decrements the active workers count
+// RecordHandlerPanic counts an HTTP request whose handler panicked
+func (mc *MetricsCollector) RecordHandlerPanic() {
+	HTTPPanics.WithLabelValues(mc.namespace).Inc()
+	mc.IncrementErrorCounter("http_panic")
+}
+
+// SetWorkerPoolSize records the number of worker loops in the pool
+func (mc *MetricsCollector) SetWorkerPoolSize(size int) {
+	WorkerPoolSize.Set(float64(size))
+}
+
+// IncrementActiveWorkers increments or decrements the active workers count,
+// which counts concurrent task executions rather than worker loops
 func (mc *MetricsCollector) IncrementActiveWorkers(delta int) {
 	newCount := atomic.AddInt32(&mc.activeWorkersCount, int32(delta))
 	ActiveWorkers.Set(float64(newCount))
 }
 
+// SetCircuitBreakerState records the circuit breaker state of a job type
+func (mc *MetricsCollector) SetCircuitBreakerState(jobType string, state int) {
+	CircuitBreakerState.WithLabelValues(jobType).Set(float64(state))
+}
+
 // RecordDelayedJobsProcessed records the number of delayed jobs processed
 func (mc *MetricsCollector) RecordDelayedJobsProcessed(count int) {
 	JobsProcessed.WithLabelValues("delayed", "processed").Add(float64(count))
@@ -65,8 +147,112 @@ func (mc *MetricsCollector) RecordDelayedJobProcessorRun(seconds float64) {
 	RedisOperationDuration.WithLabelValues("delayed_processor").Observe(seconds)
 }
 
+// RecordTaskPromotions records aged tasks moved from one priority to another
+func (mc *MetricsCollector) RecordTaskPromotions(fromPriority, toPriority, count int) {
+	TasksPromoted.WithLabelValues(fmt.Sprintf("%d", fromPriority), fmt.Sprintf("%d", toPriority)).Add(float64(count))
+}
+
+// RecordBackpressure records tasks rejected or dropped because a queue was full
+func (mc *MetricsCollector) RecordBackpressure(queue, policy string, count int) {
+	QueueBackpressure.WithLabelValues(queue, policy).Add(float64(count))
+}
+
+// RecordPoisonTask records an undecodable queue entry moved to the poison list
+func (mc *MetricsCollector) RecordPoisonTask(queue string) {
+	PoisonTasks.WithLabelValues(queue).Inc()
+}
+
+// RecordDuplicateSubmission records a submission suppressed because a task
+// with the same ID already existed
+func (mc *MetricsCollector) RecordDuplicateSubmission(jobType string) {
+	DuplicateSubmissions.WithLabelValues(jobType).Inc()
+}
+
+// RecordRetry records a job retry and the backoff before it runs
+func (mc *MetricsCollector) RecordRetry(jobType, category string, backoffSeconds float64) {
+	JobRetries.WithLabelValues(jobType, category).Inc()
+	RetryBackoff.WithLabelValues(category).Observe(backoffSeconds)
+}
+
+// RecordDeadLetter records a job moved to the dead letter queue
+func (mc *MetricsCollector) RecordDeadLetter(jobType, category string) {
+	DeadLetterMoves.WithLabelValues(jobType, category).Inc()
+}
+
+// RecordOversizedResult records a job result over the maximum result size
+// and whether it was rejected or truncated
+func (mc *MetricsCollector) RecordOversizedResult(jobType, action string) {
+	OversizedResults.WithLabelValues(jobType, action).Inc()
+}
+
+// SetTasksInFlight records the number of tasks the worker pool is working on
+func (mc *MetricsCollector) SetTasksInFlight(count int) {
+	TasksInFlight.Set(float64(count))
+}
+
+// SetMaxTasksInFlight records the worker pool's in-flight limit
+func (mc *MetricsCollector) SetMaxTasksInFlight(limit int) {
+	MaxTasksInFlight.Set(float64(limit))
+}
+
+// SetInstanceInfo records the instance name a component runs as
+func (mc *MetricsCollector) SetInstanceInfo(instanceName, component string) {
+	InstanceInfo.WithLabelValues(instanceName, component).Set(1)
+}
+
+// SetDelayedProcessorLeader records whether this instance holds the delayed processor lock
+func (mc *MetricsCollector) SetDelayedProcessorLeader(leader bool) {
+	if leader {
+		DelayedProcessorLeader.Set(1)
+	} else {
+		DelayedProcessorLeader.Set(0)
+	}
+}
+
 // RecordAPIRequestDuration records the time taken to process an API request
 func (mc *MetricsCollector) RecordAPIRequestDuration(endpoint string, seconds float64) {
 	// For API requests, we'll use the Redis operation metrics
 	RedisOperationDuration.WithLabelValues(fmt.Sprintf("api_%s", endpoint)).Observe(seconds)
 }
+
+// JobCount returns how many jobs this collector has counted with a status
+func (mc *MetricsCollector) JobCount(status string) int64 {
+	mc.mu.RLock()
+	defer mc.mu.RUnlock()
+
+	return mc.jobCounts[status]
+}
+
+// ActiveWorkers returns the number of workers currently processing a job
+func (mc *MetricsCollector) ActiveWorkers() int {
+	return int(atomic.LoadInt32(&mc.activeWorkersCount))
+}
+
+// AverageProcessingTimes returns the mean processing time in seconds per job type
+func (mc *MetricsCollector) AverageProcessingTimes() map[string]float64 {
+	mc.mu.RLock()
+	defer mc.mu.RUnlock()
+
+	averages := make(map[string]float64, len(mc.processingTimes))
+	for jobType, agg := range mc.processingTimes {
+		if agg.count > 0 {
+			averages[jobType] = agg.total / float64(agg.count)
+		}
+	}
+	return averages
+}
+
+// Summary returns a snapshot of the aggregates recorded by this collector
+func (mc *MetricsCollector) Summary() Summary {
+	completed := mc.JobCount("completed")
+	failed := mc.JobCount("failed")
+
+	return Summary{
+		Submitted:            mc.JobCount("submitted"),
+		Processed:            completed + failed,
+		Completed:            completed,
+		Failed:               failed,
+		ActiveWorkers:        mc.ActiveWorkers(),
+		AvgProcessingSeconds: mc.AverageProcessingTimes(),
+	}
+}
@@ -44,6 +44,24 @@ var (
 		[]string{"type"},
 	)
 
+	QueueWaitTime = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "boltq_queue_wait_seconds",
+			Help:    "Time jobs waited in the queue before being consumed",
+			Buckets: prometheus.ExponentialBuckets(0.01, 2, 16), // From 10ms to ~5.5min
+		},
+		[]string{"type", "priority"},
+	)
+
+	JobLatency = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "boltq_job_e2e_seconds",
+			Help:    "Time from job submission to completion, including queue wait and retries",
+			Buckets: prometheus.ExponentialBuckets(0.05, 2, 18), // From 50ms to ~1.8h
+		},
+		[]string{"type"},
+	)
+
 	// Worker metrics
 	WorkerPoolSize = promauto.NewGauge(
 		prometheus.GaugeOpts{
@@ -55,11 +73,203 @@ var (
 	ActiveWorkers = promauto.NewGauge(
 		prometheus.GaugeOpts{
 			Name: "boltq_active_workers",
-			Help: "The number of currently active workers",
+			Help: "The number of tasks currently being processed",
+		},
+	)
+
+	CircuitBreakerState = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "boltq_circuit_breaker_state",
+			Help: "Circuit breaker state per job type (0=closed, 1=half-open, 2=open)",
+		},
+		[]string{"type"},
+	)
+
+	ConsumePolls = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "boltq_consume_polls_total",
+			Help: "The number of queue polls by result (task, empty or error)",
+		},
+		[]string{"result"},
+	)
+
+	ConsumeErrors = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "boltq_consume_errors_total",
+			Help: "The number of queue polls that failed, e.g. because Redis was unavailable",
+		},
+	)
+
+	ConsumeDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "boltq_consume_seconds",
+			Help:    "Time spent polling the queues for a task",
+			Buckets: prometheus.ExponentialBuckets(0.0005, 2, 12), // From 0.5ms to ~1s
 		},
+		[]string{"result"},
+	)
+
+	// Retry metrics
+	JobRetries = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "boltq_job_retries_total",
+			Help: "The total number of job retries by job type and error category",
+		},
+		[]string{"type", "category"},
+	)
+
+	RetryBackoff = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "boltq_retry_backoff_seconds",
+			Help:    "Delay before a job is retried",
+			Buckets: prometheus.ExponentialBuckets(1, 2, 10), // From 1s to ~8.5min
+		},
+		[]string{"category"},
+	)
+
+	DeadLetterMoves = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "boltq_dead_letter_moves_total",
+			Help: "The total number of jobs moved to the dead letter queue by job type and error category",
+		},
+		[]string{"type", "category"},
+	)
+
+	OversizedResults = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "boltq_oversized_results_total",
+			Help: "The total number of job results over the maximum result size by job type and action (reject or truncate)",
+		},
+		[]string{"type", "action"},
 	)
 
 	// Queue metrics
+	DeadLetterQueueSize = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "boltq_dead_letter_queue_size",
+			Help: "The number of tasks in the dead letter queue",
+		},
+	)
+
+	// TasksPromoted counts tasks moved to a higher priority after waiting too long
+	TasksPromoted = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "boltq_tasks_promoted_total",
+			Help: "The total number of aged tasks promoted to a higher priority",
+		},
+		[]string{"from_priority", "to_priority"},
+	)
+
+	// TasksInFlight counts the tasks held against the pool's in-flight limit
+	TasksInFlight = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "boltq_tasks_in_flight",
+			Help: "The number of tasks the worker pool has taken and not yet finished",
+		},
+	)
+
+	MaxTasksInFlight = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "boltq_max_tasks_in_flight",
+			Help: "The worker pool's limit on tasks in flight (0 = unlimited)",
+		},
+	)
+
+	// DelayedProcessorLeader is 1 while this instance runs the delayed processor
+	DelayedProcessorLeader = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "boltq_delayed_processor_leader",
+			Help: "Whether this instance holds the delayed processor leader lock",
+		},
+	)
+
+	// InstanceInfo is 1 for the instance name of this process. Joining on it
+	// attributes the other metrics of a scrape target to a hostname or pod.
+	InstanceInfo = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "boltq_instance_info",
+			Help: "The configured instance name of this process",
+		},
+		[]string{"instance_name", "component"},
+	)
+
+	QueueBackpressure = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "boltq_queue_backpressure_total",
+			Help: "The number of tasks rejected or dropped because a queue was full",
+		},
+		[]string{"queue", "policy"},
+	)
+
+	PoisonTasks = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "boltq_poison_tasks_total",
+			Help: "The number of undecodable queue entries moved to the poison list",
+		},
+		[]string{"queue"},
+	)
+
+	DuplicateSubmissions = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "boltq_duplicate_submissions_total",
+			Help: "The number of submissions suppressed because a task with the same ID already existed",
+		},
+		[]string{"type"},
+	)
+
+	CallbackFailures = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "boltq_callback_failures_total",
+			Help: "The number of job callbacks and workflow completion webhooks that could not be delivered",
+		},
+		[]string{"kind"},
+	)
+
+	HTTPPanics = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "boltq_http_panics_total",
+			Help: "The number of HTTP requests whose handler panicked",
+		},
+		[]string{"component"},
+	)
+
+	WebSocketConnections = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "boltq_websocket_connections",
+			Help: "The number of WebSocket clients currently connected",
+		},
+	)
+
+	WebSocketConnects = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "boltq_websocket_connects_total",
+			Help: "The total number of WebSocket clients that connected",
+		},
+	)
+
+	WebSocketDisconnects = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "boltq_websocket_disconnects_total",
+			Help: "The total number of WebSocket clients that disconnected or were evicted",
+		},
+		[]string{"reason"},
+	)
+
+	WebSocketMessagesBroadcast = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "boltq_websocket_messages_broadcast_total",
+			Help: "The number of updates broadcast to the connected WebSocket clients",
+		},
+	)
+
+	WebSocketPublishFailures = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "boltq_websocket_publish_failures_total",
+			Help: "The number of job and workflow updates that could not be published after retrying",
+		},
+		[]string{"channel"},
+	)
+
 	RedisOperations = promauto.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "boltq_redis_operations_total",
@@ -0,0 +1,202 @@
+// pkg/payload/payload.go
+package payload
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+)
+
+// RefKey marks a payload field that holds a reference to external content
+// instead of the content itself: {"$ref": "s3://bucket/key"}
+const RefKey = "$ref"
+
+// DefaultMaxSize is the default limit for the size of resolved content
+const DefaultMaxSize int64 = 100 << 20 // 100MB
+
+// ErrTooLarge is returned when referenced content exceeds the maximum size
+var ErrTooLarge = errors.New("referenced content too large")
+
+// Fetcher opens the content a reference points to
+type Fetcher func(ctx context.Context, ref *url.URL) (io.ReadCloser, error)
+
+var (
+	fetchers = map[string]Fetcher{
+		"http":  fetchHTTP,
+		"https": fetchHTTP,
+	}
+	maxSize = DefaultMaxSize
+	mu      sync.RWMutex
+
+	httpClient = &http.Client{Timeout: 5 * time.Minute}
+)
+
+// RegisterFetcher sets the fetcher for references with the given URL scheme,
+// e.g. an S3 client for "s3". HTTP and HTTPS references are fetched with a
+// plain GET unless another fetcher is registered for them.
+func RegisterFetcher(scheme string, fetcher Fetcher) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	fetchers[scheme] = fetcher
+}
+
+// SetMaxSize limits the size of content returned by Resolve. A maxSize of 0
+// or less removes the limit.
+func SetMaxSize(size int64) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	maxSize = size
+}
+
+// ParseRef parses a reference, which must be an absolute URL
+func ParseRef(ref string) (*url.URL, error) {
+	parsed, err := url.Parse(ref)
+	if err != nil {
+		return nil, fmt.Errorf("invalid payload reference %q: %v", ref, err)
+	}
+	if parsed.Scheme == "" {
+		return nil, fmt.Errorf("payload reference %q has no scheme", ref)
+	}
+	return parsed, nil
+}
+
+// Resolve fetches the content a reference points to with the fetcher
+// registered for its scheme
+func Resolve(ctx context.Context, ref string) ([]byte, error) {
+	parsed, err := ParseRef(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	mu.RLock()
+	fetcher, ok := fetchers[parsed.Scheme]
+	limit := maxSize
+	mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("no fetcher registered for payload references of scheme %s", parsed.Scheme)
+	}
+
+	body, err := fetcher(ctx, parsed)
+	if err != nil {
+		return nil, fmt.Errorf("fetching %s: %w", ref, err)
+	}
+	defer body.Close()
+
+	if limit <= 0 {
+		return io.ReadAll(body)
+	}
+
+	// Read one byte past the limit to tell a full read from a truncated one
+	content, err := io.ReadAll(io.LimitReader(body, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", ref, err)
+	}
+	if int64(len(content)) > limit {
+		return nil, fmt.Errorf("%w: %s is over %d bytes", ErrTooLarge, ref, limit)
+	}
+	return content, nil
+}
+
+// Ref returns the reference held by a payload value of the form
+// {"$ref": "..."}, and whether the value is one
+func Ref(value interface{}) (string, bool) {
+	field, ok := value.(map[string]interface{})
+	if !ok || len(field) != 1 {
+		return "", false
+	}
+
+	ref, ok := field[RefKey].(string)
+	return ref, ok
+}
+
+// Refs returns the references in a payload keyed by the path of their field,
+// e.g. "input" or "files.0", at any depth
+func Refs(data map[string]interface{}) map[string]string {
+	refs := make(map[string]string)
+	collectRefs("", data, refs)
+	return refs
+}
+
+// collectRefs adds the references in a payload value to refs
+func collectRefs(path string, value interface{}, refs map[string]string) {
+	if ref, ok := Ref(value); ok {
+		refs[path] = ref
+		return
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			collectRefs(joinPath(path, key), item, refs)
+		}
+	case []interface{}:
+		for i, item := range v {
+			collectRefs(joinPath(path, fmt.Sprint(i)), item, refs)
+		}
+	}
+}
+
+// joinPath appends a key to a field path
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// RefList returns the distinct references in a payload, sorted
+func RefList(data map[string]interface{}) []string {
+	seen := make(map[string]bool)
+	var list []string
+	for _, ref := range Refs(data) {
+		if !seen[ref] {
+			seen[ref] = true
+			list = append(list, ref)
+		}
+	}
+	sort.Strings(list)
+	return list
+}
+
+// ResolveField returns the content of a top-level payload field: fetched if
+// the field is a reference, or the field's own value if it is a string
+func ResolveField(ctx context.Context, data map[string]interface{}, field string) ([]byte, error) {
+	value, ok := data[field]
+	if !ok {
+		return nil, fmt.Errorf("payload has no field %s", field)
+	}
+
+	if ref, ok := Ref(value); ok {
+		return Resolve(ctx, ref)
+	}
+	if s, ok := value.(string); ok {
+		return []byte(s), nil
+	}
+	return nil, fmt.Errorf("payload field %s is neither a reference nor a string", field)
+}
+
+// fetchHTTP fetches an http or https reference with a GET request
+func fetchHTTP(ctx context.Context, ref *url.URL) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ref.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return resp.Body, nil
+}
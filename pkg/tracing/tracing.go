@@ -18,10 +18,15 @@ import (
 	"google.golang.org/grpc"
 )
 
-var tracer trace.Tracer
+// tracer is a no-op until InitTracer is called
+var tracer = otel.Tracer("github.com/your-username/boltq")
 
-// InitTracer initializes the OpenTelemetry tracer
-func InitTracer(ctx context.Context, serviceName string) (func(), error) {
+// ShutdownFunc flushes buffered spans and stops the tracer provider
+type ShutdownFunc func(ctx context.Context) error
+
+// InitTracer initializes the OpenTelemetry tracer. The returned function must
+// be called on shutdown, otherwise the last batch of spans is lost.
+func InitTracer(ctx context.Context, serviceName string) (ShutdownFunc, error) {
 	// Get the OTLP endpoint from environment or use default
 	endpoint := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")
 	if endpoint == "" {
@@ -69,11 +74,15 @@ func InitTracer(ctx context.Context, serviceName string) (func(), error) {
 	// Get tracer
 	tracer = otel.Tracer("github.com/your-username/boltq")
 
-	// Return a function to shut down the tracer provider
-	return func() {
+	// Return a function to flush and shut down the tracer provider
+	return func(ctx context.Context) error {
+		if err := tp.ForceFlush(ctx); err != nil {
+			return fmt.Errorf("failed to flush spans: %w", err)
+		}
 		if err := tp.Shutdown(ctx); err != nil {
-			fmt.Printf("Error shutting down tracer provider: %v\n", err)
+			return fmt.Errorf("failed to shut down tracer provider: %w", err)
 		}
+		return nil
 	}, nil
 }
 
@@ -22,10 +22,23 @@ const JobDetail = () => {
     if (wsMessage) {
       // Check if the message is a job update for this job
       if (wsMessage.type === 'job_update' && wsMessage.job_id === jobId) {
-        execute();
+        // Show the new status right away
+        setData((prev) => prev?.data ? {
+          ...prev,
+          data: {
+            ...prev.data,
+            status: wsMessage.status,
+            worker_id: wsMessage.data?.worker_id ?? prev.data.worker_id,
+          },
+        } : prev);
+
+        // Only the stored job has the result or error, so fetch it once the job stops running
+        if (wsMessage.status !== 'running') {
+          execute();
+        }
       }
     }
-  }, [wsMessage, jobId, execute]);
+  }, [wsMessage, jobId, execute, setData]);
 
   // Get status icon based on job status
   const getStatusIcon = (status, size = 18) => {
@@ -69,9 +69,19 @@ const JobStatus = () => {
         const update = JSON.parse(event.data);
         
         // Check if this update is for our job
-        if (update.job_id === jobId) {
-          // Refresh job status
-          fetchJobStatus();
+        if (update.type === 'job_update' && update.job_id === jobId) {
+          // Show the new status right away
+          setJob((prev) => prev ? {
+            ...prev,
+            status: update.status,
+            worker_id: update.data?.worker_id ?? prev.worker_id,
+          } : prev);
+          setLastUpdated(new Date());
+
+          // Only the stored job has the result or error, so fetch it once the job stops running
+          if (update.status !== 'running') {
+            fetchJobStatus();
+          }
         }
       } catch (err) {
         console.error('Error processing WebSocket message:', err);